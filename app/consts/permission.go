@@ -0,0 +1,53 @@
+package consts
+
+const (
+	PermissionDashboardView = "dashboard.view"
+	PermissionProductRead   = "product.read"
+	PermissionProductWrite  = "product.write"
+	PermissionProductDelete = "product.delete"
+	PermissionProductImport = "product.import"
+	PermissionCategoryWrite = "category.write"
+	PermissionSearchManage  = "search.manage"
+	PermissionOrderRead     = "order.read"
+	PermissionOrderUpdate   = "order.update"
+	PermissionOrderRefund   = "order.refund"
+	PermissionCustomerRead  = "customer.read"
+	PermissionReportView    = "report.view"
+	PermissionUserManage    = "user.manage"
+	PermissionRoleManage    = "role.manage"
+)
+
+// Permissions berisi semua permission beserta keterangannya, dipakai oleh role:seed.
+var Permissions = map[string]string{
+	PermissionDashboardView: "Melihat dashboard admin",
+	PermissionProductRead:   "Melihat daftar produk di admin",
+	PermissionProductWrite:  "Membuat dan mengubah produk",
+	PermissionProductDelete: "Menghapus produk",
+	PermissionProductImport: "Import dan export produk",
+	PermissionCategoryWrite: "Mengelola section dan kategori",
+	PermissionSearchManage:  "Mengelola sinonim dan laporan pencarian",
+	PermissionOrderRead:     "Melihat order dan item order",
+	PermissionOrderUpdate:   "Mengubah status order",
+	PermissionOrderRefund:   "Melakukan refund order",
+	PermissionCustomerRead:  "Melihat data pelanggan",
+	PermissionReportView:    "Melihat laporan penjualan",
+	PermissionUserManage:    "Mengelola user",
+	PermissionRoleManage:    "Mengatur role user",
+}
+
+// RolePermissions adalah permission bawaan setiap role. Admin selalu mendapat semua permission.
+var RolePermissions = map[string][]string{
+	RoleOperator: {
+		PermissionDashboardView,
+		PermissionProductRead,
+		PermissionProductWrite,
+		PermissionProductImport,
+		PermissionCategoryWrite,
+		PermissionSearchManage,
+		PermissionOrderRead,
+		PermissionOrderUpdate,
+		PermissionCustomerRead,
+		PermissionReportView,
+	},
+	RoleCustomer: {},
+}
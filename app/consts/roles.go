@@ -4,4 +4,5 @@ package consts
 const (
 	RoleAdmin = "admin"
 	RoleOperator = "operator"
+	RoleCustomer = "customer"
 )
\ No newline at end of file
@@ -0,0 +1,255 @@
+package controllers
+
+import (
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/gieart87/gotoko/app/core/session/auth"
+	"github.com/gieart87/gotoko/app/models"
+	"github.com/gorilla/mux"
+)
+
+const (
+	accountOrdersPerPage = 10
+	accountRecentOrders  = 5
+)
+
+// Account menampilkan ringkasan akun: profil, alamat utama dan order terbaru.
+func (server *Server) Account(w http.ResponseWriter, r *http.Request) {
+	user := auth.CurrentUser(server.DB, w, r)
+	if user == nil {
+		http.Redirect(w, r, "/login", http.StatusSeeOther)
+		return
+	}
+
+	addressModel := models.Address{}
+	primaryAddress, _ := addressModel.GetPrimaryAddress(server.DB, user.ID)
+
+	orderModel := models.Order{}
+	orders, err := orderModel.GetUserOrders(server.DB, user.ID, accountRecentOrders)
+	if err != nil {
+		http.Error(w, "Gagal memuat order", http.StatusInternalServerError)
+		return
+	}
+
+	_ = server.getRenderer().HTML(w, http.StatusOK, "account", map[string]interface{}{
+		"user":           user,
+		"primaryAddress": primaryAddress,
+		"orders":         orders,
+		"hasPassword":    user.HasPassword(),
+		"Error":          r.URL.Query().Get("error"),
+		"Message":        r.URL.Query().Get("message"),
+	})
+}
+
+func (server *Server) UpdateAccountProfile(w http.ResponseWriter, r *http.Request) {
+	user := auth.CurrentUser(server.DB, w, r)
+	if user == nil {
+		http.Redirect(w, r, "/login", http.StatusSeeOther)
+		return
+	}
+
+	firstName := strings.TrimSpace(r.FormValue("first_name"))
+	lastName := strings.TrimSpace(r.FormValue("last_name"))
+	if firstName == "" {
+		http.Redirect(w, r, "/account?error=Nama+depan+wajib+diisi", http.StatusSeeOther)
+		return
+	}
+
+	if err := user.UpdateProfile(server.DB, firstName, lastName); err != nil {
+		http.Redirect(w, r, "/account?error=Gagal+menyimpan+profil", http.StatusSeeOther)
+		return
+	}
+
+	http.Redirect(w, r, "/account?message=Profil+berhasil+disimpan", http.StatusSeeOther)
+}
+
+func (server *Server) AccountAddresses(w http.ResponseWriter, r *http.Request) {
+	user := auth.CurrentUser(server.DB, w, r)
+	if user == nil {
+		http.Redirect(w, r, "/login", http.StatusSeeOther)
+		return
+	}
+
+	addressModel := models.Address{}
+	addresses, err := addressModel.GetUserAddresses(server.DB, user.ID)
+	if err != nil {
+		http.Error(w, "Gagal memuat alamat", http.StatusInternalServerError)
+		return
+	}
+
+	_ = server.getRenderer().HTML(w, http.StatusOK, "account_addresses", map[string]interface{}{
+		"user":      user,
+		"addresses": addresses,
+		"provinces": provinces,
+		"cityMap":   cityMap,
+		"Error":     r.URL.Query().Get("error"),
+		"Message":   r.URL.Query().Get("message"),
+	})
+}
+
+func (server *Server) CreateAccountAddress(w http.ResponseWriter, r *http.Request) {
+	user := auth.CurrentUser(server.DB, w, r)
+	if user == nil {
+		http.Redirect(w, r, "/login", http.StatusSeeOther)
+		return
+	}
+
+	address, errMsg := addressFromForm(r)
+	if errMsg != "" {
+		http.Redirect(w, r, "/account/addresses?error="+url.QueryEscape(errMsg), http.StatusSeeOther)
+		return
+	}
+	address.UserID = user.ID
+
+	addressModel := models.Address{}
+	if err := addressModel.SaveAddress(server.DB, address); err != nil {
+		http.Redirect(w, r, "/account/addresses?error=Gagal+menyimpan+alamat", http.StatusSeeOther)
+		return
+	}
+
+	http.Redirect(w, r, "/account/addresses?message=Alamat+berhasil+ditambahkan", http.StatusSeeOther)
+}
+
+func (server *Server) UpdateAccountAddress(w http.ResponseWriter, r *http.Request) {
+	server.updateAccountAddress(w, r, "Alamat berhasil disimpan", func(existing *models.Address) error {
+		address, errMsg := addressFromForm(r)
+		if errMsg != "" {
+			return accountAddressError(errMsg)
+		}
+
+		address.ID = existing.ID
+		address.UserID = existing.UserID
+		// Alamat utama tidak bisa dilepas dari form edit, pilih alamat lain sebagai utama
+		address.IsPrimary = address.IsPrimary || existing.IsPrimary
+
+		addressModel := models.Address{}
+		return addressModel.SaveAddress(server.DB, address)
+	})
+}
+
+func (server *Server) DeleteAccountAddress(w http.ResponseWriter, r *http.Request) {
+	server.updateAccountAddress(w, r, "Alamat berhasil dihapus", func(existing *models.Address) error {
+		return existing.DeleteAddress(server.DB)
+	})
+}
+
+func (server *Server) SetDefaultAccountAddress(w http.ResponseWriter, r *http.Request) {
+	server.updateAccountAddress(w, r, "Alamat utama berhasil diubah", func(existing *models.Address) error {
+		return existing.SetPrimary(server.DB)
+	})
+}
+
+// accountAddressError membawa pesan validasi yang aman ditampilkan ke user.
+type accountAddressError string
+
+func (e accountAddressError) Error() string {
+	return string(e)
+}
+
+// updateAccountAddress memuat alamat {id} milik user yang sedang login, menjalankan update,
+// lalu kembali ke daftar alamat. Alamat milik user lain diperlakukan sebagai tidak ditemukan.
+func (server *Server) updateAccountAddress(w http.ResponseWriter, r *http.Request, message string, update func(existing *models.Address) error) {
+	user := auth.CurrentUser(server.DB, w, r)
+	if user == nil {
+		http.Redirect(w, r, "/login", http.StatusSeeOther)
+		return
+	}
+
+	addressModel := models.Address{}
+	address, err := addressModel.FindUserAddress(server.DB, user.ID, mux.Vars(r)["id"])
+	if err != nil {
+		http.Redirect(w, r, "/account/addresses?error=Alamat+tidak+ditemukan", http.StatusSeeOther)
+		return
+	}
+
+	if err := update(address); err != nil {
+		errMsg := "Gagal menyimpan alamat"
+		if validationErr, ok := err.(accountAddressError); ok {
+			errMsg = string(validationErr)
+		}
+		http.Redirect(w, r, "/account/addresses?error="+url.QueryEscape(errMsg), http.StatusSeeOther)
+		return
+	}
+
+	http.Redirect(w, r, "/account/addresses?message="+url.QueryEscape(message), http.StatusSeeOther)
+}
+
+// addressFromForm membaca dan memvalidasi form alamat. Provinsi dan kota harus salah satu
+// pilihan ongkir agar alamat bisa langsung dipakai saat checkout.
+func addressFromForm(r *http.Request) (*models.Address, string) {
+	address := &models.Address{
+		Label:      strings.TrimSpace(r.FormValue("label")),
+		Name:       strings.TrimSpace(r.FormValue("name")),
+		IsPrimary:  r.FormValue("is_primary") == "1",
+		ProvinceID: strings.TrimSpace(r.FormValue("province_id")),
+		CityID:     strings.TrimSpace(r.FormValue("city_id")),
+		Address1:   strings.TrimSpace(r.FormValue("address1")),
+		Address2:   strings.TrimSpace(r.FormValue("address2")),
+		Phone:      strings.TrimSpace(r.FormValue("phone")),
+		Email:      strings.TrimSpace(r.FormValue("email")),
+		PostCode:   strings.TrimSpace(r.FormValue("post_code")),
+	}
+
+	if address.Name == "" || address.Address1 == "" || address.Phone == "" {
+		return nil, "Nama penerima, alamat dan nomor telepon wajib diisi"
+	}
+
+	cities, ok := cityMap[address.ProvinceID]
+	if !ok {
+		return nil, "Provinsi tidak valid"
+	}
+	if !containsCity(cities, address.CityID) {
+		return nil, "Kota tidak valid untuk provinsi yang dipilih"
+	}
+
+	return address, ""
+}
+
+func containsCity(cities []string, city string) bool {
+	for _, c := range cities {
+		if c == city {
+			return true
+		}
+	}
+
+	return false
+}
+
+// AccountOrders menampilkan riwayat order user beserta status order dan pembayarannya.
+func (server *Server) AccountOrders(w http.ResponseWriter, r *http.Request) {
+	user := auth.CurrentUser(server.DB, w, r)
+	if user == nil {
+		http.Redirect(w, r, "/login", http.StatusSeeOther)
+		return
+	}
+
+	q := r.URL.Query()
+	page, _ := strconv.Atoi(q.Get("page"))
+	if page <= 0 {
+		page = 1
+	}
+
+	orderModel := models.Order{}
+	orders, totalRows, err := orderModel.GetUserOrdersPaginated(server.DB, user.ID, accountOrdersPerPage, page)
+	if err != nil {
+		http.Error(w, "Gagal memuat order", http.StatusInternalServerError)
+		return
+	}
+
+	pagination, _ := GetPaginationLinks(server.AppConfig, PaginationParams{
+		Path:        "account/orders",
+		Query:       q,
+		TotalRows:   int32(totalRows),
+		PerPage:     int32(accountOrdersPerPage),
+		CurrentPage: int32(page),
+	})
+
+	_ = server.getRenderer().HTML(w, http.StatusOK, "account_orders", map[string]interface{}{
+		"user":       user,
+		"orders":     orders,
+		"pagination": pagination,
+	})
+}
@@ -5,30 +5,23 @@ import (
     "time"
 	"net/http"
     "strconv"
-    "os"
-    "io"
-    "bytes"       
-    "encoding/json"
+    "net/url"
+    "strings"
 
-    "path/filepath"
     "github.com/gosimple/slug"
     "github.com/google/uuid"
     "github.com/gorilla/mux"
     "github.com/shopspring/decimal"
+    "github.com/gieart87/gotoko/app/consts"
     "github.com/gieart87/gotoko/app/models"
 	"github.com/gieart87/gotoko/app/core/session/auth"
 	"github.com/unrolled/render"
     "gorm.io/gorm"
 )
 
-type ProductAI struct {
-    Name string `json:"name"`
-    Desc string `json:"desc"`
-}
-
 // Fungsi pembantu untuk inisialisasi render agar tidak ditulis berulang kali
-func adminRender() *render.Render {
-	return render.New(render.Options{
+func adminRender() *viewRender {
+	return newRender(render.Options{
 		Layout:     "admin_layout", // Menggunakan admin_layout.html sebagai induk
 		Extensions: []string{".html", ".tmpl"},
 		Directory:  "templates", // Pastikan ini mengarah ke folder templates Anda
@@ -42,39 +35,41 @@ func (server *Server) AdminDashboard(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Gunakan path lengkap sesuai struktur folder: "pages/admin_dashboard"
-	_ = adminRender().HTML(w, http.StatusOK, "pages/admin_dashboard", map[string]interface{}{
+	data := map[string]interface{}{
 		"user": user,
-	})
+	}
+
+	// Metrik penjualan hanya untuk role yang boleh melihat laporan
+	if user.Can(consts.PermissionReportView) {
+		metrics, err := server.salesDashboard(r)
+		if err != nil {
+			http.Error(w, "Gagal memuat laporan penjualan", http.StatusInternalServerError)
+			return
+		}
+		for key, value := range metrics {
+			data[key] = value
+		}
+	}
+
+	// Gunakan path lengkap sesuai struktur folder: "pages/admin_dashboard"
+	_ = adminRender().HTML(w, http.StatusOK, "pages/admin_dashboard", data)
 }
 
 func (server *Server) AdminProducts(w http.ResponseWriter, r *http.Request) {
-    var products []models.Product
-    
-    err := server.DB.Debug().
-        Preload("Categories").    
-        Preload("ProductImages"). 
-        Order("created_at desc"). 
-        Find(&products).Error
-
-    if err != nil {
-        fmt.Println("Error query produk:", err)
-    }
+	lq := models.ParseListQuery(r.URL.Query(), models.ProductListSpec)
 
-    _ = adminRender().HTML(w, http.StatusOK, "pages/admin_product", map[string]interface{}{
-        "products": products,
-    })
-}
-// Handler untuk halaman Orders
-func (server *Server) AdminOrders(w http.ResponseWriter, r *http.Request) {
-	user := auth.CurrentUser(server.DB, w, r)
-	if user == nil {
-		http.Redirect(w, r, "/login", http.StatusSeeOther)
+	productModel := models.Product{}
+	products, totalRows, err := productModel.FindAdminProducts(server.DB, lq)
+	if err != nil {
+		http.Error(w, "Gagal memuat produk", http.StatusInternalServerError)
 		return
 	}
 
-	_ = adminRender().HTML(w, http.StatusOK, "pages/show_order", map[string]interface{}{
-		"user": user,
+	_ = adminRender().HTML(w, http.StatusOK, "pages/admin_product", map[string]interface{}{
+		"user":       auth.CurrentUser(server.DB, w, r),
+		"products":   products,
+		"list":       lq,
+		"pagination": server.listPagination("admin/products", r, lq, totalRows),
 	})
 }
 
@@ -112,6 +107,7 @@ func (server *Server) CreateProductPage(w http.ResponseWriter, r *http.Request)
     _ = adminRender().HTML(w, http.StatusOK, "pages/admin_product_create", map[string]interface{}{
         "user":       user,
         "categories": categories, // Data ini yang akan diloop di HTML
+        "Error":      r.URL.Query().Get("error"),
     })
 }
 
@@ -123,73 +119,72 @@ func (server *Server) StoreProduct(w http.ResponseWriter, r *http.Request) {
         return
     }
 
-    // 2. Ambil data dari form (Pastikan name di form HTML adalah "category_id")
+    // 2. Ambil data dari form
     name := r.FormValue("name")
     priceStr := r.FormValue("price")
     stockStr := r.FormValue("stock")
-    categoryID := r.FormValue("category_id")
+    manufacturer := strings.TrimSpace(r.FormValue("manufacturer"))
+    dosageForm := strings.ToLower(strings.TrimSpace(r.FormValue("dosage_form")))
 
     // Konversi tipe data
     price, _ := decimal.NewFromString(priceStr)
     stock, _ := strconv.Atoi(stockStr)
     productID := uuid.New().String() // ID unik
 
-    // 3. LOGIKA KATEGORI: Ambil data kategori dari DB berdasarkan ID yang dipilih
-    var categories []models.Category
-    if categoryID != "" {
-        var category models.Category
-        // Cek apakah kategori tersebut ada di database
-        if err := server.DB.Where("id = ?", categoryID).First(&category).Error; err == nil {
-            categories = append(categories, category)
-        }
+    // 3. LOGIKA KATEGORI: Ambil semua kategori yang dipilih (category_ids bisa lebih dari satu)
+    categories, err := server.getFormCategories(r)
+    if err != nil {
+        http.Error(w, "Gagal memuat kategori: "+err.Error(), http.StatusInternalServerError)
+        return
     }
 
     // 4. Inisialisasi Product lengkap dengan slice Categories
     newProduct := models.Product{
-        ID:         productID,
-        UserID:     user.ID,
-        Name:       name,
-        Price:      price,
-        Stock:      stock,
-        Slug:       slug.Make(name),
-        Status:     1,
-        Categories: categories, // Masukkan kategori di sini agar relasi Many-to-Many terbentuk
-        CreatedAt:  time.Now(),
-        UpdatedAt:  time.Now(),
+        ID:           productID,
+        UserID:       user.ID,
+        Name:         name,
+        Price:        price,
+        Stock:        stock,
+        Manufacturer: manufacturer,
+        DosageForm:   dosageForm,
+        Slug:         slug.Make(name),
+        Status:       1,
+        Categories:   categories, // Masukkan kategori di sini agar relasi Many-to-Many terbentuk
+        CreatedAt:    time.Now(),
+        UpdatedAt:    time.Now(),
     }
 
-    // 5. Proses Upload Gambar
-    file, handler, err := r.FormFile("image")
-    if err == nil {
-        defer file.Close()
-        _ = os.MkdirAll("public/uploads", os.ModePerm)
-
-        fileName := fmt.Sprintf("%d-%s", time.Now().Unix(), handler.Filename)
-        dbPath := "uploads/" + fileName
-        physicalPath := "public/" + dbPath
-
-        dst, err := os.Create(physicalPath)
-        if err == nil {
-            defer dst.Close()
-            io.Copy(dst, file)
-
-            newProduct.ProductImages = append(newProduct.ProductImages, models.ProductImage{
-                ID:        uuid.New().String(),
-                ProductID: productID,
-                Path:      dbPath,
-            })
-        }
+    // 5. Proses Upload Gambar (bisa banyak file, gambar pertama menjadi gambar utama)
+    productImages, err := server.saveUploadedImages(r, productID, 0, false, name)
+    if err != nil {
+        http.Redirect(w, r, "/admin/products/create?error="+url.QueryEscape(err.Error()), http.StatusSeeOther)
+        return
     }
+    newProduct.ProductImages = productImages
 
     // 6. Simpan ke Database
-    // GORM akan otomatis mengisi tabel 'products' DAN tabel 'product_categories'
-    err = server.DB.Create(&newProduct).Error
+    // GORM akan otomatis mengisi tabel 'products' DAN tabel 'product_categories'.
+    // Event untuk service AI dicatat di outbox dalam transaksi yang sama.
+    err = server.DB.Transaction(func(tx *gorm.DB) error {
+        if err := tx.Create(&newProduct).Error; err != nil {
+            return err
+        }
+
+        return enqueueAIUpserts(tx, newProduct.ID)
+    })
     if err != nil {
+        server.discardProductImages(productImages)
         fmt.Println("Gagal simpan ke DB:", err)
         http.Error(w, "Gagal menyimpan produk: "+err.Error(), http.StatusInternalServerError)
         return
     }
 
+    // Nama kategori ikut disimpan ke index pencarian
+    if err := newProduct.RefreshSearchCategories(server.DB, newProduct.ID); err != nil {
+        fmt.Println("Gagal memperbarui index pencarian:", err)
+    }
+    server.indexProducts(newProduct.ID)
+
     // 7. Redirect kembali ke daftar produk
     http.Redirect(w, r, "/admin/products", http.StatusSeeOther)
 }
@@ -199,15 +194,29 @@ func (server *Server) EditProductPage(w http.ResponseWriter, r *http.Request) {
     id := vars["id"]
 
     var product models.Product
-   if err := server.DB.Preload("ProductImages").Where("id = ?", id).First(&product).Error; err != nil {
+   if err := server.DB.Preload("ProductImages", models.OrderProductImages).Preload("Categories").Where("id = ?", id).First(&product).Error; err != nil {
         http.Redirect(w, r, "/admin/products", http.StatusSeeOther)
         return
     }
 
+    categoryModel := models.Category{}
+    categories, err := categoryModel.GetCategories(server.DB)
+    if err != nil {
+        fmt.Println("Gagal mengambil kategori:", err)
+    }
+
+    selectedCategories := make(map[string]bool, len(product.Categories))
+    for _, category := range product.Categories {
+        selectedCategories[category.ID] = true
+    }
+
     user := auth.CurrentUser(server.DB, w, r)
     _ = adminRender().HTML(w, http.StatusOK, "pages/admin_product_edit", map[string]interface{}{
-        "user":    user,
-        "product": product,
+        "user":               user,
+        "product":            product,
+        "categories":         categories,
+        "selectedCategories": selectedCategories,
+        "Error":              r.URL.Query().Get("error"),
     })
 }
 
@@ -240,57 +249,83 @@ func (server *Server) UpdateProduct(w http.ResponseWriter, r *http.Request) {
 		stock = old.Stock
 	}
 
+	categories, err := server.getFormCategories(r)
+	if err != nil {
+		http.Error(w, "Gagal memuat kategori: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	manufacturer := strings.TrimSpace(r.FormValue("manufacturer"))
+	dosageForm := strings.ToLower(strings.TrimSpace(r.FormValue("dosage_form")))
+
+	// ===== HANDLE GAMBAR (OPSIONAL) =====
+	// Gambar baru ditambahkan setelah gambar yang sudah ada, gambar lama tidak dihapus.
+	// File ditulis sebelum transaksi dan dihapus lagi jika transaksi batal.
+	imageModel := models.ProductImage{}
+	nextPosition, err := imageModel.NextPosition(server.DB, id)
+	if err != nil {
+		http.Error(w, "Gagal memuat gambar: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	productImages, err := server.saveUploadedImages(r, id, nextPosition, old.PrimaryImage() != nil, name)
+	if err != nil {
+		http.Redirect(w, r, "/admin/products/edit/"+id+"?error="+url.QueryEscape(err.Error()), http.StatusSeeOther)
+		return
+	}
+
 	tx := server.DB.Begin()
 
 	// ===== UPDATE PRODUK =====
 	tx.Model(&models.Product{}).
 		Where("id = ?", id).
 		Updates(map[string]interface{}{
-			"name":       name,
-			"price":      price,
-			"stock":      stock,
-			"slug":       slug.Make(name),
-			"updated_at": time.Now(),
+			"name":         name,
+			"price":        price,
+			"stock":        stock,
+			"manufacturer": manufacturer,
+			"dosage_form":  dosageForm,
+			"slug":         slug.Make(name),
+			"updated_at":   time.Now(),
 		})
 
-	// ===== HANDLE GAMBAR (OPSIONAL) =====
-	file, handler, err := r.FormFile("image")
-	if err == nil {
-		defer file.Close()
-
-		// hapus gambar lama
-		for _, img := range old.ProductImages {
-			if img.Path != "" {
-				_ = os.Remove(filepath.Clean("public/" + img.Path))
-			}
-		}
-
-		// hapus record image lama
-		tx.Unscoped().
-			Where("product_id = ?", id).
-			Delete(&models.ProductImage{})
-
-		// simpan gambar baru
-		_ = os.MkdirAll("public/uploads", os.ModePerm)
+	// ===== UPDATE KATEGORI =====
+	if err := tx.Model(&old).Association("Categories").Replace(categories); err != nil {
+		tx.Rollback()
+		server.discardProductImages(productImages)
+		http.Error(w, "Gagal menyimpan kategori: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
 
-		fileName := fmt.Sprintf("%d-%s", time.Now().Unix(), handler.Filename)
-		dbPath := "uploads/" + fileName
-		physicalPath := "public/" + dbPath
+	for i := range productImages {
+		if err := tx.Create(&productImages[i]).Error; err != nil {
+			tx.Rollback()
+			server.discardProductImages(productImages)
+			http.Error(w, "Gagal menyimpan gambar: "+err.Error(), http.StatusInternalServerError)
+			return
+		}
+	}
 
-		dst, err := os.Create(physicalPath)
-		if err == nil {
-			defer dst.Close()
-			io.Copy(dst, file)
+	if err := old.RefreshSearchCategories(tx, id); err != nil {
+		tx.Rollback()
+		server.discardProductImages(productImages)
+		http.Error(w, "Gagal memperbarui index pencarian: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
 
-			tx.Create(&models.ProductImage{
-				ID:        uuid.New().String(),
-				ProductID: id,
-				Path:      dbPath,
-			})
-		}
+	if err := enqueueAIUpserts(tx, id); err != nil {
+		tx.Rollback()
+		server.discardProductImages(productImages)
+		http.Error(w, "Gagal mencatat sinkronisasi AI: "+err.Error(), http.StatusInternalServerError)
+		return
 	}
 
-	tx.Commit()
+	if err := tx.Commit().Error; err != nil {
+		server.discardProductImages(productImages)
+		http.Error(w, "Gagal menyimpan produk: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	server.indexProducts(id)
 
 	// ===== BALIK KE KATALOG =====
 	http.Redirect(w, r, "/admin/products", http.StatusSeeOther)
@@ -301,6 +336,9 @@ func (server *Server) DeleteProduct(w http.ResponseWriter, r *http.Request) {
     vars := mux.Vars(r)
     id := vars["id"]
 
+    // Gambar dicatat dulu agar file-nya bisa dibersihkan dari storage setelah transaksi berhasil
+    var productImages []models.ProductImage
+
     // Gunakan Transaction untuk memastikan semua terhapus atau tidak sama sekali
     err := server.DB.Transaction(func(tx *gorm.DB) error {
         var product models.Product
@@ -310,6 +348,10 @@ func (server *Server) DeleteProduct(w http.ResponseWriter, r *http.Request) {
             return err
         }
 
+        if err := tx.Where("product_id = ?", id).Find(&productImages).Error; err != nil {
+            return err
+        }
+
         // 1. Bersihkan relasi Many-to-Many di product_categories secara paksa
         // Ini mengatasi error FK Constraint
         if err := tx.Model(&product).Association("Categories").Clear(); err != nil {
@@ -327,7 +369,7 @@ func (server *Server) DeleteProduct(w http.ResponseWriter, r *http.Request) {
             return err
         }
 
-        return nil
+        return enqueueAIDelete(tx, id)
     })
 
     if err != nil {
@@ -336,6 +378,13 @@ func (server *Server) DeleteProduct(w http.ResponseWriter, r *http.Request) {
         return
     }
 
+    server.unindexProduct(id)
+
+    // Hapus file yang sudah tidak direferensikan agar tidak menjadi file yatim di storage
+    for _, productImage := range productImages {
+        server.removeProductImageFiles(r.Context(), productImage)
+    }
+
     http.Redirect(w, r, "/admin/products", http.StatusSeeOther)
 }
 // 1. Dashboard Navigasi (Halaman dengan 3 Kotak)
@@ -345,11 +394,26 @@ func (server *Server) OrderDashboard(w http.ResponseWriter, r *http.Request) {
     server.DB.Model(&models.OrderItem{}).Count(&countItems)
     server.DB.Model(&models.Order{}).Count(&countOrders)
 
+    user := auth.CurrentUser(server.DB, w, r)
     data := map[string]interface{}{
         "countCustomers": countCustomers,
         "countItems":     countItems,
         "countOrders":    countOrders,
-        "user":           auth.CurrentUser(server.DB, w, r), // Agar nama di sidebar muncul
+        "user":           user, // Agar nama di sidebar muncul
+    }
+
+    // Ringkasan lunas/belum lunas pada rentang tanggal yang dipilih
+    if user != nil && user.Can(consts.PermissionReportView) {
+        salesRange, from, to := salesRangeFromQuery(r.URL.Query())
+        reportModel := models.SalesReport{}
+        summary, err := reportModel.Summary(server.DB, salesRange)
+        if err != nil {
+            http.Error(w, "Gagal memuat ringkasan order", http.StatusInternalServerError)
+            return
+        }
+        data["summary"] = summary
+        data["from"] = from
+        data["to"] = to
     }
 
     // Path: templates/admin/orders_dashboard.html
@@ -358,63 +422,67 @@ func (server *Server) OrderDashboard(w http.ResponseWriter, r *http.Request) {
 
 // 2. Tabel Customer
 func (server *Server) ListCustomers(w http.ResponseWriter, r *http.Request) {
-    var customers []models.OrderCustomer
-    server.DB.Order("created_at desc").Find(&customers)
+	lq := models.ParseListQuery(r.URL.Query(), models.OrderCustomerListSpec)
 
-    data := map[string]interface{}{
-        "customers": customers,
-        "user":      auth.CurrentUser(server.DB, w, r),
-    }
+	customerModel := models.OrderCustomer{}
+	customers, totalRows, err := customerModel.FindOrderCustomers(server.DB, lq)
+	if err != nil {
+		http.Error(w, "Gagal memuat pelanggan", http.StatusInternalServerError)
+		return
+	}
 
-    // Path: templates/admin/customers.html
-    _ = adminRender().HTML(w, http.StatusOK, "pages/admin_customers", data)
+	_ = adminRender().HTML(w, http.StatusOK, "pages/admin_customers", map[string]interface{}{
+		"customers":  customers,
+		"user":       auth.CurrentUser(server.DB, w, r),
+		"list":       lq,
+		"pagination": server.listPagination("admin/customers", r, lq, totalRows),
+	})
 }
 
 // 3. Tabel Order Items
 func (server *Server) ListOrderItems(w http.ResponseWriter, r *http.Request) {
-    var items []models.OrderItem
-    server.DB.Preload("Product").Order("created_at desc").Find(&items)
+	lq := models.ParseListQuery(r.URL.Query(), models.OrderItemListSpec)
 
-    data := map[string]interface{}{
-        "items": items,
-        "user":  auth.CurrentUser(server.DB, w, r),
-    }
+	orderItemModel := models.OrderItem{}
+	items, totalRows, err := orderItemModel.FindOrderItems(server.DB, lq)
+	if err != nil {
+		http.Error(w, "Gagal memuat item order", http.StatusInternalServerError)
+		return
+	}
 
-    // Path: templates/admin/order_items.html
-    _ = adminRender().HTML(w, http.StatusOK, "pages/order_item", data)
+	_ = adminRender().HTML(w, http.StatusOK, "pages/order_item", map[string]interface{}{
+		"items":      items,
+		"user":       auth.CurrentUser(server.DB, w, r),
+		"list":       lq,
+		"pagination": server.listPagination("admin/order-items", r, lq, totalRows),
+	})
 }
 
 // 4. Tabel Orders
 func (server *Server) ListOrders(w http.ResponseWriter, r *http.Request) {
-    var orders []models.Order
-    server.DB.Order("created_at desc").Find(&orders)
-
-    data := map[string]interface{}{
-        "orders": orders,
-        "user":   auth.CurrentUser(server.DB, w, r),
-    }
-
-    // Path: templates/admin/orders.html
-    _ = adminRender().HTML(w, http.StatusOK, "pages/order", data)
-}
+	lq := models.ParseListQuery(r.URL.Query(), models.OrderListSpec)
 
-func SyncToAI(name string, desc string) {
-    url := "http://localhost:8000/add-product"
+	orderModel := models.Order{}
+	orders, totalRows, err := orderModel.FindOrders(server.DB, lq)
+	if err != nil {
+		http.Error(w, "Gagal memuat order", http.StatusInternalServerError)
+		return
+	}
 
-    payload := ProductAI{
-        Name: name,
-        Desc: desc,
-    }
-    
-    // Pastikan pakai "json" dari "encoding/json"
-    jsonData, err := json.Marshal(payload)
-    if err != nil {
-        return
-    }
+	var statuses []map[string]interface{}
+	for _, status := range []int{consts.OrderStatusPending, consts.OrderStatusReceived, consts.OrderStatusDelivered, consts.OrderStatusCancelled} {
+		statuses = append(statuses, map[string]interface{}{
+			"Value": strconv.Itoa(status),
+			"Label": models.OrderStatusLabel(status),
+		})
+	}
 
-    // Pastikan pakai "bytes"
-    _, err = http.Post(url, "application/json", bytes.NewBuffer(jsonData))
-    if err != nil {
-        fmt.Println("Gagal konek ke Python:", err)
-    }
-}
\ No newline at end of file
+	_ = adminRender().HTML(w, http.StatusOK, "pages/order", map[string]interface{}{
+		"orders":          orders,
+		"user":            auth.CurrentUser(server.DB, w, r),
+		"list":            lq,
+		"statuses":        statuses,
+		"paymentStatuses": []string{consts.OrderPaymentStatusUnpaid, consts.OrderPaymentStatusPaid},
+		"pagination":      server.listPagination("admin/orders", r, lq, totalRows),
+	})
+}
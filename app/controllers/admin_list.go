@@ -0,0 +1,21 @@
+package controllers
+
+import (
+	"net/http"
+
+	"github.com/gieart87/gotoko/app/models"
+)
+
+// listPagination membuat link paginasi daftar admin dengan tetap membawa filter,
+// pencarian dan urutan yang sedang aktif.
+func (server *Server) listPagination(path string, r *http.Request, lq models.ListQuery, totalRows int64) PaginationLinks {
+	pagination, _ := GetPaginationLinks(server.AppConfig, PaginationParams{
+		Path:        path,
+		Query:       r.URL.Query(),
+		TotalRows:   int32(totalRows),
+		PerPage:     int32(lq.PerPage),
+		CurrentPage: int32(lq.Page),
+	})
+
+	return pagination
+}
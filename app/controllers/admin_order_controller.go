@@ -0,0 +1,138 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/gieart87/gotoko/app/consts"
+	"github.com/gieart87/gotoko/app/core/session/auth"
+	"github.com/gieart87/gotoko/app/models"
+	"github.com/gieart87/gotoko/app/utils"
+	"github.com/gorilla/mux"
+)
+
+const orderNoteMaxLength = 2000
+
+// AdminOrderDetail menampilkan order lengkap dengan item, pelanggan, pembayaran, pengiriman,
+// riwayat status dan status tujuan yang boleh dipilih.
+func (server *Server) AdminOrderDetail(w http.ResponseWriter, r *http.Request) {
+	orderModel := models.Order{}
+	order, err := orderModel.FindByID(server.DB, mux.Vars(r)["id"])
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+
+	paymentModel := models.Payment{}
+	payments, err := paymentModel.GetOrderPayments(server.DB, order.ID)
+	if err != nil {
+		http.Error(w, "Gagal memuat pembayaran", http.StatusInternalServerError)
+		return
+	}
+
+	shipmentModel := models.Shipment{}
+	shipments, err := shipmentModel.GetOrderShipments(server.DB, order.ID)
+	if err != nil {
+		http.Error(w, "Gagal memuat pengiriman", http.StatusInternalServerError)
+		return
+	}
+
+	historyModel := models.OrderStatusHistory{}
+	histories, err := historyModel.GetOrderStatusHistories(server.DB, order.ID)
+	if err != nil {
+		http.Error(w, "Gagal memuat riwayat status", http.StatusInternalServerError)
+		return
+	}
+
+	// Pembatalan order yang sudah dibayar hanya ditampilkan untuk user dengan izin refund
+	user := auth.CurrentUser(server.DB, w, r)
+	var transitions []map[string]interface{}
+	for _, status := range order.AllowedTransitions() {
+		if order.NeedsRefund(status) && (user == nil || !user.Can(consts.PermissionOrderRefund)) {
+			continue
+		}
+		transitions = append(transitions, map[string]interface{}{
+			"Status": status,
+			"Label":  models.OrderStatusLabel(status),
+		})
+	}
+
+	_ = adminRender().HTML(w, http.StatusOK, "pages/admin_order_detail", map[string]interface{}{
+		"user":        user,
+		"order":       order,
+		"payments":    payments,
+		"shipments":   shipments,
+		"histories":   histories,
+		"transitions": transitions,
+		"Error":       r.URL.Query().Get("error"),
+		"Message":     r.URL.Query().Get("message"),
+	})
+}
+
+// UpdateOrderStatus mengubah status order sesuai alur pending → received → delivered
+// (atau cancelled dari pending/received). Alasan wajib diisi saat membatalkan, dan membatalkan
+// order yang sudah dibayar membutuhkan izin refund.
+func (server *Server) UpdateOrderStatus(w http.ResponseWriter, r *http.Request) {
+	server.updateOrder(w, r, "Status order berhasil diubah", func(user *models.User, order *models.Order) error {
+		status, err := strconv.Atoi(r.FormValue("status"))
+		if err != nil {
+			return models.ErrInvalidOrderTransition
+		}
+
+		note := utils.Truncate(strings.TrimSpace(r.FormValue("note")), 255)
+		if status == consts.OrderStatusCancelled && note == "" {
+			return errOrderCancelNoteRequired
+		}
+		if order.NeedsRefund(status) && (user == nil || !user.Can(consts.PermissionOrderRefund)) {
+			return errOrderRefundForbidden
+		}
+
+		return order.TransitionStatus(server.DB, status, user, note)
+	})
+}
+
+// UpdateOrderNote menyimpan catatan internal order.
+func (server *Server) UpdateOrderNote(w http.ResponseWriter, r *http.Request) {
+	server.updateOrder(w, r, "Catatan order berhasil disimpan", func(user *models.User, order *models.Order) error {
+		note := strings.TrimSpace(r.FormValue("note"))
+		if len(note) > orderNoteMaxLength {
+			return errOrderNoteTooLong
+		}
+
+		return order.UpdateNote(server.DB, note)
+	})
+}
+
+var (
+	errOrderCancelNoteRequired = errors.New("Alasan pembatalan wajib diisi")
+	errOrderNoteTooLong        = errors.New("Catatan terlalu panjang")
+	errOrderRefundForbidden    = errors.New("Anda tidak memiliki izin membatalkan order yang sudah dibayar")
+)
+
+// updateOrder memuat order {id}, menjalankan update, lalu kembali ke halaman detail order.
+func (server *Server) updateOrder(w http.ResponseWriter, r *http.Request, message string, update func(user *models.User, order *models.Order) error) {
+	orderID := mux.Vars(r)["id"]
+	redirectURL := "/admin/orders/" + orderID
+
+	orderModel := models.Order{}
+	order, err := orderModel.FindByID(server.DB, orderID)
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+
+	user := auth.CurrentUser(server.DB, w, r)
+	if err := update(user, order); err != nil {
+		errMsg := "Gagal menyimpan order"
+		if errors.Is(err, models.ErrInvalidOrderTransition) || errors.Is(err, errOrderCancelNoteRequired) || errors.Is(err, errOrderNoteTooLong) || errors.Is(err, errOrderRefundForbidden) {
+			errMsg = err.Error()
+		}
+		http.Redirect(w, r, redirectURL+"?error="+url.QueryEscape(errMsg), http.StatusSeeOther)
+		return
+	}
+
+	http.Redirect(w, r, redirectURL+"?message="+url.QueryEscape(message), http.StatusSeeOther)
+}
@@ -0,0 +1,85 @@
+package controllers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/gieart87/gotoko/app/consts"
+	"github.com/gieart87/gotoko/app/models"
+	"github.com/gorilla/mux"
+	"gorm.io/gorm"
+)
+
+// createTestStaff membuat user dengan role yang hanya memiliki permissions.
+func createTestStaff(t *testing.T, db *gorm.DB, email string, permissions ...string) *models.User {
+	t.Helper()
+
+	role := &models.Role{Name: consts.RoleOperator}
+	for _, name := range permissions {
+		role.Permissions = append(role.Permissions, models.Permission{Name: name})
+	}
+	if err := db.Create(role).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	user := createTestUser(t, db, email)
+	if err := user.AssignRole(db, role.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	return user
+}
+
+func TestCancelPaidOrderRequiresRefundPermission(t *testing.T) {
+	tests := []struct {
+		name        string
+		permissions []string
+		paid        bool
+		wantStatus  int
+	}{
+		{"unpaid order", []string{consts.PermissionOrderUpdate}, false, consts.OrderStatusCancelled},
+		{"paid order without refund permission", []string{consts.PermissionOrderUpdate}, true, consts.OrderStatusReceived},
+		{"paid order with refund permission", []string{consts.PermissionOrderUpdate, consts.PermissionOrderRefund}, true, consts.OrderStatusCancelled},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := newTestServer(t)
+			staff := createTestStaff(t, server.DB, "staff@example.com", tt.permissions...)
+			customer := createTestUser(t, server.DB, "customer@example.com")
+
+			order := &models.Order{UserID: customer.ID, Code: "1/ORDER/I/2026", Status: consts.OrderStatusReceived}
+			if tt.paid {
+				order.PaymentStatus = consts.OrderPaymentStatusPaid
+			}
+			if err := server.DB.Create(order).Error; err != nil {
+				t.Fatal(err)
+			}
+
+			router := mux.NewRouter()
+			router.HandleFunc("/admin/orders/{id}/status", server.UpdateOrderStatus).Methods("POST")
+
+			form := url.Values{"status": {strconv.Itoa(consts.OrderStatusCancelled)}, "note": {"Stok habis"}}
+			r := httptest.NewRequest(http.MethodPost, "/admin/orders/"+order.ID+"/status", strings.NewReader(form.Encode()))
+			r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+			for _, cookie := range loginCookies(t, staff) {
+				r.AddCookie(cookie)
+			}
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, r)
+
+			orderModel := models.Order{}
+			stored, err := orderModel.FindByID(server.DB, order.ID)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if stored.Status != tt.wantStatus {
+				t.Errorf("status = %d, want %d (redirect %s)", stored.Status, tt.wantStatus, w.Header().Get("Location"))
+			}
+		})
+	}
+}
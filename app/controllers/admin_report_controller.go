@@ -0,0 +1,151 @@
+package controllers
+
+import (
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/gieart87/gotoko/app/models"
+)
+
+const (
+	salesReportDefaultDays = 30
+	salesReportMaxDays     = 366
+	salesReportTopLimit    = 10
+	lowStockLimit          = 20
+	salesDateLayout        = "2006-01-02"
+)
+
+// salesRangeFromQuery membaca ?from=&to= (YYYY-MM-DD, keduanya inklusif). Default 30 hari
+// terakhir; rentang dibatasi satu tahun agar query agregat tetap ringan.
+func salesRangeFromQuery(q url.Values) (models.SalesRange, string, string) {
+	now := time.Now()
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.Local)
+
+	to, err := time.ParseInLocation(salesDateLayout, q.Get("to"), time.Local)
+	if err != nil {
+		to = today
+	}
+	from, err := time.ParseInLocation(salesDateLayout, q.Get("from"), time.Local)
+	if err != nil || from.After(to) {
+		from = to.AddDate(0, 0, -(salesReportDefaultDays - 1))
+	}
+	if to.Sub(from) > salesReportMaxDays*24*time.Hour {
+		from = to.AddDate(0, 0, -salesReportMaxDays)
+	}
+
+	return models.SalesRange{From: from, To: to.AddDate(0, 0, 1)}, from.Format(salesDateLayout), to.Format(salesDateLayout)
+}
+
+func salesPeriodFromQuery(q url.Values) string {
+	switch period := q.Get("period"); period {
+	case models.SalesPeriodWeek, models.SalesPeriodMonth:
+		return period
+	default:
+		return models.SalesPeriodDay
+	}
+}
+
+// salesDashboard mengumpulkan seluruh metrik dashboard penjualan untuk rentang di query string.
+func (server *Server) salesDashboard(r *http.Request) (map[string]interface{}, error) {
+	q := r.URL.Query()
+	salesRange, from, to := salesRangeFromQuery(q)
+	period := salesPeriodFromQuery(q)
+
+	reportModel := models.SalesReport{}
+
+	summary, err := reportModel.Summary(server.DB, salesRange)
+	if err != nil {
+		return nil, err
+	}
+	revenue, err := reportModel.Revenue(server.DB, salesRange, period)
+	if err != nil {
+		return nil, err
+	}
+	topProducts, err := reportModel.TopProducts(server.DB, salesRange, salesReportTopLimit)
+	if err != nil {
+		return nil, err
+	}
+	topCategories, err := reportModel.TopCategories(server.DB, salesRange, salesReportTopLimit)
+	if err != nil {
+		return nil, err
+	}
+	customers, err := reportModel.Customers(server.DB, salesRange)
+	if err != nil {
+		return nil, err
+	}
+	lowStock, err := reportModel.LowStockProducts(server.DB, server.AppConfig.LowStockThreshold, lowStockLimit)
+	if err != nil {
+		return nil, err
+	}
+
+	return map[string]interface{}{
+		"from":              from,
+		"to":                to,
+		"period":            period,
+		"summary":           summary,
+		"revenue":           revenue,
+		"topProducts":       topProducts,
+		"topCategories":     topCategories,
+		"customers":         customers,
+		"lowStock":          lowStock,
+		"lowStockThreshold": server.AppConfig.LowStockThreshold,
+	}, nil
+}
+
+// Endpoint JSON untuk grafik dashboard. Semua menerima ?from=&to=, revenue juga ?period=day|week|month.
+
+func (server *Server) SalesSummaryJSON(w http.ResponseWriter, r *http.Request) {
+	server.salesJSON(w, r, func(reportModel *models.SalesReport, salesRange models.SalesRange) (interface{}, error) {
+		return reportModel.Summary(server.DB, salesRange)
+	})
+}
+
+func (server *Server) SalesRevenueJSON(w http.ResponseWriter, r *http.Request) {
+	period := salesPeriodFromQuery(r.URL.Query())
+	server.salesJSON(w, r, func(reportModel *models.SalesReport, salesRange models.SalesRange) (interface{}, error) {
+		return reportModel.Revenue(server.DB, salesRange, period)
+	})
+}
+
+func (server *Server) SalesTopProductsJSON(w http.ResponseWriter, r *http.Request) {
+	server.salesJSON(w, r, func(reportModel *models.SalesReport, salesRange models.SalesRange) (interface{}, error) {
+		return reportModel.TopProducts(server.DB, salesRange, salesReportTopLimit)
+	})
+}
+
+func (server *Server) SalesTopCategoriesJSON(w http.ResponseWriter, r *http.Request) {
+	server.salesJSON(w, r, func(reportModel *models.SalesReport, salesRange models.SalesRange) (interface{}, error) {
+		return reportModel.TopCategories(server.DB, salesRange, salesReportTopLimit)
+	})
+}
+
+func (server *Server) SalesCustomersJSON(w http.ResponseWriter, r *http.Request) {
+	server.salesJSON(w, r, func(reportModel *models.SalesReport, salesRange models.SalesRange) (interface{}, error) {
+		return reportModel.Customers(server.DB, salesRange)
+	})
+}
+
+func (server *Server) LowStockJSON(w http.ResponseWriter, r *http.Request) {
+	server.salesJSON(w, r, func(reportModel *models.SalesReport, salesRange models.SalesRange) (interface{}, error) {
+		return reportModel.LowStockProducts(server.DB, server.AppConfig.LowStockThreshold, lowStockLimit)
+	})
+}
+
+func (server *Server) salesJSON(w http.ResponseWriter, r *http.Request, load func(reportModel *models.SalesReport, salesRange models.SalesRange) (interface{}, error)) {
+	render := newRender()
+	salesRange, from, to := salesRangeFromQuery(r.URL.Query())
+
+	reportModel := models.SalesReport{}
+	data, err := load(&reportModel, salesRange)
+	if err != nil {
+		_ = render.JSON(w, http.StatusInternalServerError, map[string]string{"error": "Gagal memuat laporan penjualan"})
+		return
+	}
+
+	_ = render.JSON(w, http.StatusOK, map[string]interface{}{
+		"from": from,
+		"to":   to,
+		"data": data,
+	})
+}
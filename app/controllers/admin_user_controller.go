@@ -0,0 +1,210 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/gieart87/gotoko/app/core/session/auth"
+	"github.com/gieart87/gotoko/app/models"
+	"github.com/gorilla/mux"
+)
+
+const (
+	adminUsersPerPage    = 20
+	adminUserOrdersLimit = 20
+	loginAttemptsLimit   = 50
+)
+
+// AdminUsers menampilkan daftar user dengan pencarian (?q=), filter role (?role=),
+// status (?status=active|suspended) dan paginasi.
+func (server *Server) AdminUsers(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+
+	page, _ := strconv.Atoi(q.Get("page"))
+	if page <= 0 {
+		page = 1
+	}
+
+	filter := models.UserFilter{
+		Query:  q.Get("q"),
+		RoleID: q.Get("role"),
+		Status: q.Get("status"),
+	}
+
+	userModel := models.User{}
+	users, totalRows, err := userModel.SearchUsers(server.DB, filter, adminUsersPerPage, page)
+	if err != nil {
+		http.Error(w, "Gagal memuat user", http.StatusInternalServerError)
+		return
+	}
+
+	roleModel := models.Role{}
+	roles, err := roleModel.GetRoles(server.DB)
+	if err != nil {
+		http.Error(w, "Gagal memuat role", http.StatusInternalServerError)
+		return
+	}
+
+	pagination, _ := GetPaginationLinks(server.AppConfig, PaginationParams{
+		Path:        "admin/users",
+		Query:       q,
+		TotalRows:   int32(totalRows),
+		PerPage:     int32(adminUsersPerPage),
+		CurrentPage: int32(page),
+	})
+
+	_ = adminRender().HTML(w, http.StatusOK, "pages/admin_users", map[string]interface{}{
+		"user":       auth.CurrentUser(server.DB, w, r),
+		"users":      users,
+		"roles":      roles,
+		"filter":     filter,
+		"total":      totalRows,
+		"pagination": pagination,
+		"Message":    q.Get("message"),
+		"Error":      q.Get("error"),
+	})
+}
+
+// AdminUserDetail menampilkan profil user beserta order dan alamatnya.
+func (server *Server) AdminUserDetail(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+
+	userModel := models.User{}
+	target, err := userModel.FindByID(server.DB, vars["id"])
+	if err != nil {
+		http.Redirect(w, r, "/admin/users?error=User+tidak+ditemukan", http.StatusSeeOther)
+		return
+	}
+
+	orderModel := models.Order{}
+	orders, err := orderModel.GetUserOrders(server.DB, target.ID, adminUserOrdersLimit)
+	if err != nil {
+		http.Error(w, "Gagal memuat order user", http.StatusInternalServerError)
+		return
+	}
+
+	addressModel := models.Address{}
+	addresses, err := addressModel.GetUserAddresses(server.DB, target.ID)
+	if err != nil {
+		http.Error(w, "Gagal memuat alamat user", http.StatusInternalServerError)
+		return
+	}
+
+	roleModel := models.Role{}
+	roles, err := roleModel.GetRoles(server.DB)
+	if err != nil {
+		http.Error(w, "Gagal memuat role", http.StatusInternalServerError)
+		return
+	}
+
+	attemptModel := models.LoginAttempt{}
+	loginAttempts, err := attemptModel.GetRecentLoginAttempts(server.DB, target.ID, loginAttemptsLimit)
+	if err != nil {
+		http.Error(w, "Gagal memuat riwayat login", http.StatusInternalServerError)
+		return
+	}
+
+	identityModel := models.UserIdentity{}
+	identities, err := identityModel.GetUserIdentities(server.DB, target.ID)
+	if err != nil {
+		http.Error(w, "Gagal memuat akun login eksternal", http.StatusInternalServerError)
+		return
+	}
+
+	_ = adminRender().HTML(w, http.StatusOK, "pages/admin_user_detail", map[string]interface{}{
+		"identities":    identities,
+		"user":          auth.CurrentUser(server.DB, w, r),
+		"target":        target,
+		"orders":        orders,
+		"addresses":     addresses,
+		"roles":         roles,
+		"loginAttempts": loginAttempts,
+		"Message":       r.URL.Query().Get("message"),
+		"Error":         r.URL.Query().Get("error"),
+	})
+}
+
+// AdminLockedUsers menampilkan akun yang terkunci karena login gagal beserta percobaan login terakhir.
+func (server *Server) AdminLockedUsers(w http.ResponseWriter, r *http.Request) {
+	userModel := models.User{}
+	users, err := userModel.GetLockedUsers(server.DB)
+	if err != nil {
+		http.Error(w, "Gagal memuat user", http.StatusInternalServerError)
+		return
+	}
+
+	attemptModel := models.LoginAttempt{}
+	attempts, err := attemptModel.GetRecentLoginAttempts(server.DB, "", loginAttemptsLimit)
+	if err != nil {
+		http.Error(w, "Gagal memuat riwayat login", http.StatusInternalServerError)
+		return
+	}
+
+	_ = adminRender().HTML(w, http.StatusOK, "pages/admin_locked_users", map[string]interface{}{
+		"user":          auth.CurrentUser(server.DB, w, r),
+		"users":         users,
+		"loginAttempts": attempts,
+		"Message":       r.URL.Query().Get("message"),
+		"Error":         r.URL.Query().Get("error"),
+	})
+}
+
+// UnlockUser membuka kunci akun dan me-reset hitungan login gagal.
+func (server *Server) UnlockUser(w http.ResponseWriter, r *http.Request) {
+	server.updateUserAccount(w, r, "Kunci+akun+berhasil+dibuka", func(current *models.User, target *models.User) error {
+		return target.ResetFailedLogins(server.DB)
+	})
+}
+
+// ResetUserTwoFactor menghapus 2FA user yang kehilangan perangkat dan kode cadangannya.
+// Admin dan operator wajib mendaftarkan ulang 2FA saat login berikutnya.
+func (server *Server) ResetUserTwoFactor(w http.ResponseWriter, r *http.Request) {
+	server.updateUserAccount(w, r, "2FA+user+berhasil+di-reset", func(current *models.User, target *models.User) error {
+		return target.DisableTwoFactor(server.DB)
+	})
+}
+
+// SuspendUser menonaktifkan akun; sesi user tersebut ikut berakhir pada request berikutnya.
+func (server *Server) SuspendUser(w http.ResponseWriter, r *http.Request) {
+	server.updateUserAccount(w, r, "Akun+berhasil+dinonaktifkan", func(current *models.User, target *models.User) error {
+		if current != nil && current.ID == target.ID {
+			return errors.New("tidak bisa menonaktifkan akun sendiri")
+		}
+
+		return target.Suspend(server.DB)
+	})
+}
+
+func (server *Server) ActivateUser(w http.ResponseWriter, r *http.Request) {
+	server.updateUserAccount(w, r, "Akun+berhasil+diaktifkan", func(current *models.User, target *models.User) error {
+		return target.Activate(server.DB)
+	})
+}
+
+// ForceUserPasswordReset mewajibkan user mengganti password setelah login berikutnya.
+func (server *Server) ForceUserPasswordReset(w http.ResponseWriter, r *http.Request) {
+	server.updateUserAccount(w, r, "User+wajib+mengganti+password+saat+login+berikutnya", func(current *models.User, target *models.User) error {
+		return target.ForcePasswordReset(server.DB)
+	})
+}
+
+func (server *Server) updateUserAccount(w http.ResponseWriter, r *http.Request, message string, update func(current *models.User, target *models.User) error) {
+	vars := mux.Vars(r)
+
+	userModel := models.User{}
+	target, err := userModel.FindByID(server.DB, vars["id"])
+	if err != nil {
+		http.Redirect(w, r, "/admin/users?error=User+tidak+ditemukan", http.StatusSeeOther)
+		return
+	}
+
+	detailURL := "/admin/users/" + target.ID
+	if err := update(auth.CurrentUser(server.DB, w, r), target); err != nil {
+		http.Redirect(w, r, detailURL+"?error="+url.QueryEscape(err.Error()), http.StatusSeeOther)
+		return
+	}
+
+	http.Redirect(w, r, detailURL+"?message="+message, http.StatusSeeOther)
+}
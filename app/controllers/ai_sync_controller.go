@@ -0,0 +1,142 @@
+package controllers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/gieart87/gotoko/app/core/ai"
+	"github.com/gieart87/gotoko/app/models"
+	"gorm.io/gorm"
+)
+
+const aiOutboxBatchSize = 50
+
+func (server *Server) initializeAI(aiConfig ai.Config) {
+	server.AI = ai.NewClient(aiConfig)
+}
+
+// startAIOutboxWorker mengirim event outbox ke service AI secara berkala di background.
+func (server *Server) startAIOutboxWorker(interval time.Duration) {
+	if interval <= 0 {
+		interval = 10 * time.Second
+	}
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for range ticker.C {
+			if _, err := server.processAIOutbox(context.Background()); err != nil {
+				log.Printf("Gagal memproses outbox AI: %v", err)
+			}
+		}
+	}()
+}
+
+// processAIOutbox mengirim event yang sudah jatuh tempo dan mengembalikan jumlah event terkirim.
+// Selama circuit breaker terbuka event tidak dikirim dan jumlah percobaannya tidak bertambah.
+func (server *Server) processAIOutbox(ctx context.Context) (int, error) {
+	eventModel := models.AIOutboxEvent{}
+	sent := 0
+
+	for {
+		if !server.AI.Available() {
+			return sent, nil
+		}
+
+		events, err := eventModel.DueAIEvents(server.DB, aiOutboxBatchSize)
+		if err != nil || len(events) == 0 {
+			return sent, err
+		}
+
+		for i := range events {
+			event := &events[i]
+
+			if err := server.sendAIEvent(ctx, event); err != nil {
+				if markErr := event.MarkFailed(server.DB, err, !errors.Is(err, ai.ErrCircuitOpen)); markErr != nil {
+					return sent, markErr
+				}
+
+				// Hentikan batch agar urutan event tetap terjaga; sisanya dilepas dan dicoba pada putaran berikutnya
+				return sent, models.ReleaseAIEvents(server.DB, events[i+1:])
+			}
+
+			if err := event.MarkProcessed(server.DB); err != nil {
+				return sent, err
+			}
+			sent++
+		}
+	}
+}
+
+func (server *Server) sendAIEvent(ctx context.Context, event *models.AIOutboxEvent) error {
+	switch event.Action {
+	case models.AIEventUpsert:
+		var product ai.Product
+		if err := json.Unmarshal([]byte(event.Payload), &product); err != nil {
+			return err
+		}
+		return server.AI.UpsertProduct(ctx, product)
+	case models.AIEventDelete:
+		return server.AI.DeleteProduct(ctx, event.ProductID)
+	default:
+		return fmt.Errorf("unknown AI outbox action %q", event.Action)
+	}
+}
+
+// enqueueAIUpserts mencatat event upsert untuk produk yang baru dibuat/diubah.
+// Dipanggil di dalam transaksi yang sama dengan perubahan produk.
+func enqueueAIUpserts(tx *gorm.DB, productIDs ...string) error {
+	if len(productIDs) == 0 {
+		return nil
+	}
+
+	var products []models.Product
+	if err := tx.Where("id IN ?", productIDs).Find(&products).Error; err != nil {
+		return err
+	}
+
+	for _, product := range products {
+		if err := models.EnqueueAIEvent(tx, product.ID, models.AIEventUpsert, aiProduct(product)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func enqueueAIDelete(tx *gorm.DB, productID string) error {
+	return models.EnqueueAIEvent(tx, productID, models.AIEventDelete, map[string]string{"id": productID})
+}
+
+func aiProduct(product models.Product) ai.Product {
+	return ai.Product{
+		ID:   product.ID,
+		Name: product.Name,
+		Desc: product.Description,
+	}
+}
+
+// syncAllProductsToAI mengirim ulang seluruh katalog, misalnya setelah service AI di-reset.
+func (server *Server) syncAllProductsToAI(ctx context.Context) error {
+	var products []models.Product
+	if err := server.DB.Find(&products).Error; err != nil {
+		return err
+	}
+
+	payload := make([]ai.Product, len(products))
+	for i, product := range products {
+		payload[i] = aiProduct(product)
+	}
+
+	if err := server.AI.SyncProducts(ctx, payload); err != nil {
+		return err
+	}
+
+	fmt.Printf("%d products synced to AI service.\n", len(payload))
+	return nil
+}
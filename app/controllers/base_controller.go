@@ -1,13 +1,24 @@
 package controllers
 
 import (
+	"context"
 	"fmt"
 	"log"
 	"math"
 	"net/http"
+	"net/url"
 	"os"
-
+	"strconv"
+	"time"
+
+	"github.com/gieart87/gotoko/app/consts"
+	"github.com/gieart87/gotoko/app/core/ai"
+	"github.com/gieart87/gotoko/app/core/mail"
+	"github.com/gieart87/gotoko/app/core/search"
+	"github.com/gieart87/gotoko/app/core/sso"
+	"github.com/gieart87/gotoko/app/core/storage"
 	"github.com/gieart87/gotoko/app/models"
+	"github.com/gieart87/gotoko/app/utils"
 	"github.com/gieart87/gotoko/database/seeders"
 	"github.com/gorilla/mux"
 	"github.com/gorilla/sessions"
@@ -21,6 +32,11 @@ type Server struct {
 	DB        *gorm.DB
 	Router    *mux.Router
 	AppConfig *AppConfig
+	Storage     storage.Storage
+	SearchIndex *search.Index
+	AI          *ai.Client
+	Mailer      mail.Mailer
+	SSO         *sso.Registry
 }
 
 type AppConfig struct {
@@ -28,6 +44,24 @@ type AppConfig struct {
 	AppEnv  string
 	AppPort string
 	AppURL  string
+
+	// Jika true, user yang belum verifikasi email tidak bisa login (checkout selalu dibatasi)
+	RequireVerifiedLogin bool
+
+	// Pembatasan login: akun dikunci selama LoginLockout setelah LoginMaxFailures kali gagal,
+	// dan IP diblokir setelah LoginIPMaxFailures kali gagal dalam LoginIPWindow
+	LoginMaxFailures   int
+	LoginLockout       time.Duration
+	LoginIPMaxFailures int
+	LoginIPWindow      time.Duration
+
+	// Pakai X-Forwarded-For / X-Real-IP sebagai IP klien (hanya jika di belakang reverse proxy).
+	// TrustedProxyHops adalah jumlah proxy terpercaya yang menambahkan X-Forwarded-For.
+	TrustProxyHeaders bool
+	TrustedProxyHops  int
+
+	// Produk dengan stok <= LowStockThreshold muncul di peringatan stok menipis dashboard
+	LowStockThreshold int
 }
 
 type DBConfig struct {
@@ -57,6 +91,7 @@ type PaginationLinks struct {
 
 type PaginationParams struct {
 	Path        string
+	Query       url.Values
 	TotalRows   int32
 	PerPage     int32
 	CurrentPage int32
@@ -68,10 +103,16 @@ var (
 )
 
 
-func (server *Server) Initialize(appConfig AppConfig, dbConfig DBConfig) {
+func (server *Server) Initialize(appConfig AppConfig, dbConfig DBConfig, storageConfig storage.Config, aiConfig ai.Config, mailConfig mail.Config, ssoConfigs []sso.Config) {
 	fmt.Println("Welcome to " + appConfig.AppName)
 
 	server.initializeDB(dbConfig)
+	server.initializeStorage(storageConfig)
+	server.initializeSearchIndex()
+	server.initializeAI(aiConfig)
+	server.startAIOutboxWorker(aiConfig.OutboxInterval)
+	server.initializeMailer(mailConfig)
+	server.initializeSSO(ssoConfigs)
 	server.initializeAppConfig(appConfig)
 	server.initializeRoutes()
 }
@@ -111,22 +152,60 @@ func (server *Server) initializeDB(dbConfig DBConfig) {
 	}
 }
 
+func (server *Server) initializeStorage(storageConfig storage.Config) {
+	var err error
+
+	server.Storage, err = storage.New(storageConfig)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	// URL gambar di template mengikuti backend storage yang aktif
+	models.AssetURL = server.Storage.URL
+}
+
+func (server *Server) initializeMailer(mailConfig mail.Config) {
+	var err error
+
+	server.Mailer, err = mail.New(mailConfig)
+	if err != nil {
+		log.Fatal(err)
+	}
+}
+
 func (server *Server) initializeAppConfig(appconfig AppConfig) {
 	server.AppConfig = &appconfig
 }
 
 func (server *Server) dbMigrate() {
+	// Harus sebelum AutoMigrate: hanya berjalan selama kolom email_verified_at belum dibuat
+	if err := models.MigrateUserEmailVerification(server.DB); err != nil {
+		log.Fatal(err)
+	}
+
 	for _, model := range models.RegisterModels() {
 		err := server.DB.Debug().AutoMigrate(model.Model)
 		if err != nil {
 			log.Fatal(err)
 		}
 	}
+
+	if err := models.MigrateProductSearch(server.DB); err != nil {
+		log.Fatal(err)
+	}
+
+	if err := models.MigrateOrderPaidAt(server.DB); err != nil {
+		log.Fatal(err)
+	}
 	fmt.Println("Database migrated successfully.")
 }
 
-func (server *Server) InitCommands(config AppConfig, dbConfig DBConfig) {
+func (server *Server) InitCommands(config AppConfig, dbConfig DBConfig, storageConfig storage.Config, aiConfig ai.Config, mailConfig mail.Config) {
 	server.initializeDB(dbConfig)
+	server.initializeStorage(storageConfig)
+	server.initializeAI(aiConfig)
+	server.initializeMailer(mailConfig)
+	server.initializeAppConfig(config)
 
 	cmdApp := cli.NewApp()
 	cmdApp.Commands = []cli.Command{
@@ -147,6 +226,164 @@ func (server *Server) InitCommands(config AppConfig, dbConfig DBConfig) {
 				return nil
 			},
 		},
+		{
+			Name:  "role:seed",
+			Usage: "Create the default permissions and the admin, operator and customer roles",
+			Action: func(c *cli.Context) error {
+				err := seeders.SeedRoles(server.DB)
+				if err != nil {
+					log.Fatal(err)
+				}
+				fmt.Println("Roles and permissions seeded.")
+				return nil
+			},
+		},
+		{
+			Name:  "user:create",
+			Usage: "Create a user with the given role, e.g. user:create --email=admin@example.com --role=admin",
+			Flags: []cli.Flag{
+				cli.StringFlag{Name: "email", Usage: "email of the new user"},
+				cli.StringFlag{Name: "first-name", Usage: "first name", Value: "Admin"},
+				cli.StringFlag{Name: "last-name", Usage: "last name", Value: "User"},
+				cli.StringFlag{Name: "role", Usage: "role name (see role:seed)", Value: consts.RoleCustomer},
+				cli.StringFlag{Name: "password", Usage: "password; a random one is generated and printed when empty"},
+				cli.BoolFlag{Name: "password-stdin", Usage: "read the password from the first line of stdin"},
+			},
+			Action: func(c *cli.Context) error {
+				password, generated, err := commandPassword(c)
+				if err != nil {
+					return cli.NewExitError(err.Error(), 1)
+				}
+
+				return server.createUserFromCLI(c.String("email"), c.String("first-name"), c.String("last-name"), c.String("role"), password, generated)
+			},
+		},
+		{
+			Name:  "user:set-role",
+			Usage: "Change the role of an existing user, e.g. user:set-role --email=admin@example.com --role=admin",
+			Flags: []cli.Flag{
+				cli.StringFlag{Name: "email", Usage: "email of the user"},
+				cli.StringFlag{Name: "role", Usage: "role name (see role:seed)"},
+			},
+			Action: func(c *cli.Context) error {
+				return server.setUserRoleFromCLI(c.String("email"), c.String("role"))
+			},
+		},
+		{
+			Name:  "user:reset-password",
+			Usage: "Set a new password for a user",
+			Flags: []cli.Flag{
+				cli.StringFlag{Name: "email", Usage: "email of the user"},
+				cli.StringFlag{Name: "password", Usage: "new password; a random one is generated and printed when empty"},
+				cli.BoolFlag{Name: "password-stdin", Usage: "read the password from the first line of stdin"},
+				cli.BoolFlag{Name: "force-change", Usage: "require the user to change the password after logging in"},
+			},
+			Action: func(c *cli.Context) error {
+				password, generated, err := commandPassword(c)
+				if err != nil {
+					return cli.NewExitError(err.Error(), 1)
+				}
+
+				// Password acak hanya sementara, user wajib menggantinya setelah login
+				return server.resetPasswordFromCLI(c.String("email"), password, generated || c.Bool("force-change"), generated)
+			},
+		},
+		{
+			Name:  "products:import",
+			Usage: "Import products from a CSV or XLSX file (upsert by SKU)",
+			Flags: []cli.Flag{
+				cli.StringFlag{Name: "file", Usage: "path to the .csv or .xlsx file"},
+				cli.StringFlag{Name: "user", Usage: "email of the user owning newly created products"},
+				cli.BoolFlag{Name: "dry-run", Usage: "validate the file without saving anything"},
+			},
+			Action: func(c *cli.Context) error {
+				if c.String("file") == "" {
+					return cli.NewExitError("--file is required", 1)
+				}
+
+				return server.importProductsFromFile(c.String("file"), c.String("user"), c.Bool("dry-run"))
+			},
+		},
+		{
+			Name:  "reports:generate",
+			Usage: "Write finance reports (sales-daily, tax, payments, stock-valuation) for a period; defaults to yesterday",
+			Flags: []cli.Flag{
+				cli.StringSliceFlag{Name: "report", Usage: "report name, repeatable; all reports when empty"},
+				cli.StringFlag{Name: "from", Usage: "first day of the period (YYYY-MM-DD)"},
+				cli.StringFlag{Name: "to", Usage: "last day of the period (YYYY-MM-DD), defaults to --from"},
+				cli.StringFlag{Name: "format", Usage: "csv or xlsx", Value: utils.FormatCSV},
+				cli.StringFlag{Name: "dir", Usage: "output directory", Value: defaultReportDir},
+			},
+			Action: func(c *cli.Context) error {
+				err := server.generateReports(c.StringSlice("report"), c.String("from"), c.String("to"), c.String("format"), c.String("dir"))
+				if err != nil {
+					return cli.NewExitError(err.Error(), 1)
+				}
+				return nil
+			},
+		},
+		{
+			Name:  "images:backfill",
+			Usage: "Generate extra large, large, medium and small variants for existing product images",
+			Action: func(c *cli.Context) error {
+				err := server.backfillImageVariants()
+				if err != nil {
+					log.Fatal(err)
+				}
+				return nil
+			},
+		},
+		{
+			Name:  "search:reindex",
+			Usage: "Refresh the category names stored in the product full-text index",
+			Action: func(c *cli.Context) error {
+				productModel := models.Product{}
+				err := productModel.RefreshSearchCategories(server.DB)
+				if err != nil {
+					log.Fatal(err)
+				}
+				fmt.Println("Product search index refreshed.")
+				return nil
+			},
+		},
+		{
+			Name:  "ai:sync",
+			Usage: "Send the whole product catalog to the AI search service",
+			Action: func(c *cli.Context) error {
+				err := server.syncAllProductsToAI(context.Background())
+				if err != nil {
+					log.Fatal(err)
+				}
+				return nil
+			},
+		},
+		{
+			Name:  "ai:outbox",
+			Usage: "Deliver pending product events in the AI outbox",
+			Flags: []cli.Flag{
+				cli.BoolFlag{Name: "retry-dead", Usage: "requeue events that exceeded the maximum attempts"},
+			},
+			Action: func(c *cli.Context) error {
+				eventModel := models.AIOutboxEvent{}
+				if c.Bool("retry-dead") {
+					requeued, err := eventModel.RetryDeadAIEvents(server.DB)
+					if err != nil {
+						log.Fatal(err)
+					}
+					fmt.Printf("%d dead events requeued.\n", requeued)
+				}
+
+				sent, err := server.processAIOutbox(context.Background())
+				if err != nil {
+					log.Fatal(err)
+				}
+
+				pending, _ := eventModel.PendingAIEventCount(server.DB)
+				dead, _ := eventModel.DeadAIEventCount(server.DB)
+				fmt.Printf("%d events delivered, %d still pending, %d dead.\n", sent, pending, dead)
+				return nil
+			},
+		},
 	}
 
 	err := cmdApp.Run(os.Args)
@@ -179,7 +416,7 @@ func GetPaginationLinks(config *AppConfig, params PaginationParams) (PaginationL
 	for i := int32(1); i <= totalPages; i++ {
 		links = append(links, PageLink{
 			Page:          i,
-			Url:           fmt.Sprintf("/%s?page=%d", params.Path, i),
+			Url:           pageURL(params, i),
 			IsCurrentPage: i == currentPage,
 		})
 	}
@@ -196,8 +433,8 @@ func GetPaginationLinks(config *AppConfig, params PaginationParams) (PaginationL
 	}
 
 	// Buat URL dari nomor halaman yang sudah dihitung
-	prevPageURL := fmt.Sprintf("/%s?page=%d", params.Path, prevPageNum)
-	nextPageURL := fmt.Sprintf("/%s?page=%d", params.Path, nextPageNum)
+	prevPageURL := pageURL(params, prevPageNum)
+	nextPageURL := pageURL(params, nextPageNum)
 
 	return PaginationLinks{
 		CurrentPage: currentPage,
@@ -209,6 +446,17 @@ func GetPaginationLinks(config *AppConfig, params PaginationParams) (PaginationL
 	}, nil
 }
 
+// pageURL membuat URL halaman dengan tetap membawa query lain (misalnya ?q= pencarian).
+func pageURL(params PaginationParams, page int32) string {
+	query := url.Values{}
+	for key, values := range params.Query {
+		query[key] = values
+	}
+	query.Set("page", strconv.Itoa(int(page)))
+
+	return fmt.Sprintf("/%s?%s", params.Path, query.Encode())
+}
+
 func (server *Server) GetProvinces() ([]models.Province, error) {
 	var provinces []models.Province
 
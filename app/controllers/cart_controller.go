@@ -25,7 +25,7 @@ func ClearCart(db *gorm.DB, cartID string) error {
 func GetShoppingCart(db *gorm.DB, cartID string) (*models.Cart, error) {
 	var cart models.Cart
 
-	err := db.Preload("CartItems.Product.ProductImages").Where("id = ?", cartID).First(&cart).Error
+	err := db.Preload("CartItems.Product.ProductImages", models.OrderProductImages).Where("id = ?", cartID).First(&cart).Error
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			cart.ID = cartID
@@ -46,8 +46,32 @@ func GetShoppingCart(db *gorm.DB, cartID string) (*models.Cart, error) {
 	return &cart, nil
 }
 
+// Provinsi statis
+var provinces = []models.Province{
+	{ID: "1", Name: "DKI Jakarta"},
+	{ID: "2", Name: "Jawa Barat"},
+	{ID: "3", Name: "Jawa Tengah"},
+	{ID: "4", Name: "Jawa Timur"},
+	{ID: "5", Name: "Banten"},
+	{ID: "6", Name: "Yogyakarta"},
+	{ID: "7", Name: "Bali"},
+	{ID: "8", Name: "Sumatera Utara"},
+}
+
+// Kota per provinsi, dipakai di form ongkir keranjang dan form alamat akun
+var cityMap = map[string][]string{
+	"DKI Jakarta":    {"Jakarta Selatan", "Jakarta Pusat", "Jakarta Barat", "Jakarta Timur", "Jakarta Utara"},
+	"Jawa Barat":     {"Bandung", "Bekasi", "Bogor", "Depok", "Cimahi"},
+	"Banten":         {"Serang", "Tangerang", "Cilegon", "Tangerang Selatan", "Pandeglang"},
+	"Jawa Tengah":    {"Semarang", "Solo", "Surakarta", "Magelang", "Salatiga"},
+	"Jawa Timur":     {"Surabaya", "Malang", "Batu", "Sidoarjo", "Gresik"},
+	"Yogyakarta":     {"Yogyakarta", "Sleman", "Bantul", "Kulon Progo", "Gunung Kidul"},
+	"Bali":           {"Denpasar", "Badung", "Tabanan", "Gianyar", "Buleleng"},
+	"Sumatera Utara": {"Medan", "Binjai", "Pematangsiantar", "Tebing Tinggi", "Padangsidempuan"},
+}
+
 func (server *Server) GetCart(w http.ResponseWriter, r *http.Request) {
-	render := render.New(render.Options{
+	render := newRender(render.Options{
 		Layout:     "layout",
 		Extensions: []string{".html", ".tmpl"},
 	})
@@ -65,37 +89,28 @@ func (server *Server) GetCart(w http.ResponseWriter, r *http.Request) {
 	// ✅ Gunakan CartItems yang sudah di-preload
 	items := cart.CartItems
 
-	// Provinsi statis
-	provinces := []models.Province{
-		{ID: "1", Name: "DKI Jakarta"},
-		{ID: "2", Name: "Jawa Barat"},
-		{ID: "3", Name: "Jawa Tengah"},
-		{ID: "4", Name: "Jawa Timur"},
-		{ID: "5", Name: "Banten"},
-		{ID: "6", Name: "Yogyakarta"},
-		{ID: "7", Name: "Bali"},
-		{ID: "8", Name: "Sumatera Utara"},
-	}
-
-	// Kota per provinsi
-	cityMap := map[string][]string{
-		"DKI Jakarta":    {"Jakarta Selatan", "Jakarta Pusat", "Jakarta Barat", "Jakarta Timur", "Jakarta Utara"},
-		"Jawa Barat":     {"Bandung", "Bekasi", "Bogor", "Depok", "Cimahi"},
-		"Banten":         {"Serang", "Tangerang", "Cilegon", "Tangerang Selatan", "Pandeglang"},
-		"Jawa Tengah":    {"Semarang", "Solo", "Surakarta", "Magelang", "Salatiga"},
-		"Jawa Timur":     {"Surabaya", "Malang", "Batu", "Sidoarjo", "Gresik"},
-		"Yogyakarta":     {"Yogyakarta", "Sleman", "Bantul", "Kulon Progo", "Gunung Kidul"},
-		"Bali":           {"Denpasar", "Badung", "Tabanan", "Gianyar", "Buleleng"},
-		"Sumatera Utara": {"Medan", "Binjai", "Pematangsiantar", "Tebing Tinggi", "Padangsidempuan"},
-	}
-
 	// Service pengiriman
 	services := []string{"REG", "OKE", "YES"}
 
 	message := r.URL.Query().Get("message")
 	errorMsg := r.URL.Query().Get("error")
 
+	// Alamat tersimpan untuk mengisi otomatis form alamat pengiriman (?address_id= atau alamat utama)
+	var addresses []models.Address
+	var selectedAddress *models.Address
+	if user != nil {
+		addressModel := models.Address{}
+		addresses, _ = addressModel.GetUserAddresses(server.DB, user.ID)
+		if addressID := r.URL.Query().Get("address_id"); addressID != "" {
+			selectedAddress, _ = addressModel.FindUserAddress(server.DB, user.ID, addressID)
+		} else if len(addresses) > 0 {
+			selectedAddress = &addresses[0]
+		}
+	}
+
 	_ = render.HTML(w, http.StatusOK, "cart", map[string]interface{}{
+		"addresses":       addresses,
+		"selectedAddress": selectedAddress,
 		"cart":      cart,
 		"items":     items,
 		"provinces": provinces,
@@ -385,6 +400,14 @@ func (server *Server) AddItemToCart(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// Konversi pencarian: produk ini sebelumnya dibuka dari hasil pencarian
+	if searchQueryID, ok := auth.GetSearchClick(r, productID); ok {
+		searchQueryModel := models.SearchQuery{}
+		if err := searchQueryModel.RecordAddToCart(server.DB, searchQueryID); err != nil {
+			log.Printf("⚠ Gagal mencatat konversi pencarian: %v", err)
+		}
+	}
+
 	// Redirect dengan pesan di URL
 http.Redirect(w, r, "/carts?message=Item+berhasil+ditambahkan+ke+keranjang!", http.StatusSeeOther)
 }
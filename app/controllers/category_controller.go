@@ -0,0 +1,217 @@
+package controllers
+
+import (
+	"net/http"
+	"net/url"
+
+	"github.com/gieart87/gotoko/app/core/session/auth"
+	"github.com/gieart87/gotoko/app/models"
+	"github.com/gorilla/mux"
+)
+
+// ===== SECTION =====
+
+func (server *Server) AdminSections(w http.ResponseWriter, r *http.Request) {
+	sectionModel := models.Section{}
+	sections, err := sectionModel.GetSections(server.DB)
+	if err != nil {
+		http.Error(w, "Gagal memuat section", http.StatusInternalServerError)
+		return
+	}
+
+	_ = adminRender().HTML(w, http.StatusOK, "pages/admin_sections", map[string]interface{}{
+		"user":     auth.CurrentUser(server.DB, w, r),
+		"sections": sections,
+		"Message":  r.URL.Query().Get("message"),
+		"Error":    r.URL.Query().Get("error"),
+	})
+}
+
+func (server *Server) StoreSection(w http.ResponseWriter, r *http.Request) {
+	name := r.FormValue("name")
+	if name == "" {
+		http.Redirect(w, r, "/admin/sections?error=Nama+section+wajib+diisi", http.StatusSeeOther)
+		return
+	}
+
+	sectionModel := models.Section{}
+	if _, err := sectionModel.CreateSection(server.DB, name); err != nil {
+		http.Redirect(w, r, "/admin/sections?error="+url.QueryEscape(err.Error()), http.StatusSeeOther)
+		return
+	}
+
+	http.Redirect(w, r, "/admin/sections?message=Section+berhasil+ditambahkan", http.StatusSeeOther)
+}
+
+func (server *Server) UpdateSection(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+
+	name := r.FormValue("name")
+	if name == "" {
+		http.Redirect(w, r, "/admin/sections?error=Nama+section+wajib+diisi", http.StatusSeeOther)
+		return
+	}
+
+	sectionModel := models.Section{}
+	section, err := sectionModel.FindByID(server.DB, vars["id"])
+	if err != nil {
+		http.Redirect(w, r, "/admin/sections?error=Section+tidak+ditemukan", http.StatusSeeOther)
+		return
+	}
+
+	if _, err := section.UpdateSection(server.DB, name); err != nil {
+		http.Redirect(w, r, "/admin/sections?error="+url.QueryEscape(err.Error()), http.StatusSeeOther)
+		return
+	}
+
+	http.Redirect(w, r, "/admin/sections?message=Section+berhasil+diperbarui", http.StatusSeeOther)
+}
+
+func (server *Server) DeleteSection(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+
+	sectionModel := models.Section{}
+	section, err := sectionModel.FindByID(server.DB, vars["id"])
+	if err != nil {
+		http.Redirect(w, r, "/admin/sections?error=Section+tidak+ditemukan", http.StatusSeeOther)
+		return
+	}
+
+	if err := section.DeleteSection(server.DB); err != nil {
+		http.Redirect(w, r, "/admin/sections?error="+url.QueryEscape(err.Error()), http.StatusSeeOther)
+		return
+	}
+
+	http.Redirect(w, r, "/admin/sections?message=Section+berhasil+dihapus", http.StatusSeeOther)
+}
+
+// ===== CATEGORY =====
+
+func (server *Server) AdminCategories(w http.ResponseWriter, r *http.Request) {
+	categoryModel := models.Category{}
+	tree, err := categoryModel.GetCategoryTree(server.DB)
+	if err != nil {
+		http.Error(w, "Gagal memuat kategori", http.StatusInternalServerError)
+		return
+	}
+
+	_ = adminRender().HTML(w, http.StatusOK, "pages/admin_categories", map[string]interface{}{
+		"user":       auth.CurrentUser(server.DB, w, r),
+		"categories": tree,
+		"Message":    r.URL.Query().Get("message"),
+		"Error":      r.URL.Query().Get("error"),
+	})
+}
+
+func (server *Server) CreateCategoryPage(w http.ResponseWriter, r *http.Request) {
+	server.renderCategoryForm(w, r, &models.Category{})
+}
+
+func (server *Server) StoreCategory(w http.ResponseWriter, r *http.Request) {
+	categoryModel := models.Category{}
+	_, err := categoryModel.CreateCategory(server.DB, &models.Category{
+		Name:      r.FormValue("name"),
+		SectionID: r.FormValue("section_id"),
+		ParentID:  r.FormValue("parent_id"),
+	})
+	if err != nil {
+		http.Redirect(w, r, "/admin/categories/create?error="+url.QueryEscape(err.Error()), http.StatusSeeOther)
+		return
+	}
+
+	http.Redirect(w, r, "/admin/categories?message=Kategori+berhasil+ditambahkan", http.StatusSeeOther)
+}
+
+func (server *Server) EditCategoryPage(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+
+	categoryModel := models.Category{}
+	category, err := categoryModel.FindByID(server.DB, vars["id"])
+	if err != nil {
+		http.Redirect(w, r, "/admin/categories?error=Kategori+tidak+ditemukan", http.StatusSeeOther)
+		return
+	}
+
+	server.renderCategoryForm(w, r, category)
+}
+
+func (server *Server) UpdateCategory(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+
+	categoryModel := models.Category{}
+	category, err := categoryModel.FindByID(server.DB, vars["id"])
+	if err != nil {
+		http.Redirect(w, r, "/admin/categories?error=Kategori+tidak+ditemukan", http.StatusSeeOther)
+		return
+	}
+
+	_, err = category.UpdateCategory(server.DB, &models.Category{
+		Name:      r.FormValue("name"),
+		SectionID: r.FormValue("section_id"),
+		ParentID:  r.FormValue("parent_id"),
+	})
+	if err != nil {
+		http.Redirect(w, r, "/admin/categories/edit/"+category.ID+"?error="+url.QueryEscape(err.Error()), http.StatusSeeOther)
+		return
+	}
+	server.refreshSearchIndex()
+
+	http.Redirect(w, r, "/admin/categories?message=Kategori+berhasil+diperbarui", http.StatusSeeOther)
+}
+
+func (server *Server) DeleteCategory(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+
+	categoryModel := models.Category{}
+	category, err := categoryModel.FindByID(server.DB, vars["id"])
+	if err != nil {
+		http.Redirect(w, r, "/admin/categories?error=Kategori+tidak+ditemukan", http.StatusSeeOther)
+		return
+	}
+
+	if err := category.DeleteCategory(server.DB); err != nil {
+		http.Redirect(w, r, "/admin/categories?error="+url.QueryEscape(err.Error()), http.StatusSeeOther)
+		return
+	}
+	server.refreshSearchIndex()
+
+	http.Redirect(w, r, "/admin/categories?message=Kategori+berhasil+dihapus", http.StatusSeeOther)
+}
+
+func (server *Server) renderCategoryForm(w http.ResponseWriter, r *http.Request, category *models.Category) {
+	sectionModel := models.Section{}
+	sections, err := sectionModel.GetSections(server.DB)
+	if err != nil {
+		http.Error(w, "Gagal memuat section", http.StatusInternalServerError)
+		return
+	}
+
+	categoryModel := models.Category{}
+	categories, err := categoryModel.GetCategories(server.DB)
+	if err != nil {
+		http.Error(w, "Gagal memuat kategori", http.StatusInternalServerError)
+		return
+	}
+
+	_ = adminRender().HTML(w, http.StatusOK, "pages/admin_category_form", map[string]interface{}{
+		"user":       auth.CurrentUser(server.DB, w, r),
+		"category":   category,
+		"sections":   sections,
+		"categories": categories,
+		"Error":      r.URL.Query().Get("error"),
+	})
+}
+
+// getFormCategories mengambil kategori yang dipilih pada form produk.
+// Field "category_ids" boleh dikirim berulang untuk memilih banyak kategori.
+func (server *Server) getFormCategories(r *http.Request) ([]models.Category, error) {
+	_ = r.FormValue("category_ids") // memastikan r.Form sudah di-parse
+
+	categoryIDs := r.Form["category_ids"]
+	if categoryID := r.FormValue("category_id"); categoryID != "" {
+		categoryIDs = append(categoryIDs, categoryID)
+	}
+
+	categoryModel := models.Category{}
+	return categoryModel.FindByIDs(server.DB, categoryIDs)
+}
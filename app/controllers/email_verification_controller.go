@@ -0,0 +1,162 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/gieart87/gotoko/app/core/mail"
+	"github.com/gieart87/gotoko/app/core/session/auth"
+	"github.com/gieart87/gotoko/app/models"
+	"github.com/unrolled/render"
+	"gorm.io/gorm"
+)
+
+const (
+	emailVerificationTTL = 24 * time.Hour
+
+	// Batas kirim ulang email verifikasi: jeda minimal antar email dan jumlah maksimal per jam
+	verificationResendInterval = time.Minute
+	verificationResendPerHour  = 5
+
+	verificationSentMsg = "Link verifikasi sudah dikirim, silakan cek email Anda"
+)
+
+var errVerificationRateLimited = errors.New("terlalu sering meminta email verifikasi, coba lagi beberapa saat lagi")
+
+// EmailVerification menampilkan status verifikasi email dan form kirim ulang link verifikasi.
+func (server *Server) EmailVerification(w http.ResponseWriter, r *http.Request) {
+	render := newRender(render.Options{
+		Layout:     "layout",
+		Extensions: []string{".html", ".tmpl"},
+	})
+
+	_ = render.HTML(w, http.StatusOK, "verify_email", map[string]interface{}{
+		"user":    auth.CurrentUser(server.DB, w, r),
+		"Email":   r.URL.Query().Get("email"),
+		"Error":   r.URL.Query().Get("error"),
+		"Message": r.URL.Query().Get("message"),
+	})
+}
+
+// VerifyEmail memproses link verifikasi dari email (?token=).
+func (server *Server) VerifyEmail(w http.ResponseWriter, r *http.Request) {
+	token := r.URL.Query().Get("token")
+
+	err := server.DB.Transaction(func(tx *gorm.DB) error {
+		tokenModel := models.UserToken{}
+		userToken, err := tokenModel.ConsumeUserToken(tx, models.UserTokenEmailVerification, token)
+		if err != nil {
+			return err
+		}
+
+		userModel := models.User{}
+		user, err := userModel.FindByID(tx, userToken.UserID)
+		if err != nil {
+			return err
+		}
+
+		return user.MarkEmailVerified(tx)
+	})
+	if err != nil {
+		http.Redirect(w, r, "/email/verification?error="+url.QueryEscape(models.ErrInvalidUserToken.Error()), http.StatusSeeOther)
+		return
+	}
+
+	if auth.CurrentUser(server.DB, w, r) == nil {
+		http.Redirect(w, r, "/login?message=Email+berhasil+diverifikasi,+silakan+login", http.StatusSeeOther)
+		return
+	}
+
+	http.Redirect(w, r, "/?message=Email+berhasil+diverifikasi", http.StatusSeeOther)
+}
+
+// ResendVerificationEmail mengirim ulang link verifikasi ke user yang sedang login, atau ke
+// email dari form jika login dibatasi untuk akun terverifikasi. Untuk email dari form respon
+// selalu sama (termasuk saat dibatasi) dan email dikirim di background, sehingga isi maupun
+// waktu respon tidak bisa dipakai mengecek email yang terdaftar.
+func (server *Server) ResendVerificationEmail(w http.ResponseWriter, r *http.Request) {
+	user := auth.CurrentUser(server.DB, w, r)
+
+	if user == nil {
+		email := strings.TrimSpace(r.FormValue("email"))
+		if email == "" {
+			http.Redirect(w, r, "/email/verification?error=Email+wajib+diisi", http.StatusSeeOther)
+			return
+		}
+
+		userModel := models.User{}
+		if user, _ = userModel.FindByEmail(server.DB, email); user != nil && !user.IsEmailVerified() && !user.IsSuspended() {
+			go func(user *models.User) {
+				if err := server.sendVerificationEmail(context.Background(), user); err != nil {
+					log.Printf("Gagal mengirim email verifikasi ke %s: %v", user.Email, err)
+				}
+			}(user)
+		}
+
+		http.Redirect(w, r, "/email/verification?message="+url.QueryEscape(verificationSentMsg), http.StatusSeeOther)
+		return
+	}
+
+	if user.IsEmailVerified() {
+		http.Redirect(w, r, "/email/verification?message=Email+Anda+sudah+terverifikasi", http.StatusSeeOther)
+		return
+	}
+
+	err := server.sendVerificationEmail(r.Context(), user)
+	if errors.Is(err, errVerificationRateLimited) {
+		http.Redirect(w, r, "/email/verification?error="+url.QueryEscape(err.Error()), http.StatusSeeOther)
+		return
+	}
+	if err != nil {
+		log.Printf("Gagal mengirim email verifikasi ke %s: %v", user.Email, err)
+		http.Redirect(w, r, "/email/verification?error=Gagal+mengirim+email+verifikasi", http.StatusSeeOther)
+		return
+	}
+
+	http.Redirect(w, r, "/email/verification?message="+url.QueryEscape(verificationSentMsg), http.StatusSeeOther)
+}
+
+// sendVerificationEmail membuat token verifikasi baru (token lama otomatis batal) dan mengirimkannya.
+func (server *Server) sendVerificationEmail(ctx context.Context, user *models.User) error {
+	tokenModel := models.UserToken{}
+
+	recent, err := tokenModel.CountRecentUserTokens(server.DB, user.ID, models.UserTokenEmailVerification, time.Now().Add(-verificationResendInterval))
+	if err != nil {
+		return err
+	}
+	hourly, err := tokenModel.CountRecentUserTokens(server.DB, user.ID, models.UserTokenEmailVerification, time.Now().Add(-time.Hour))
+	if err != nil {
+		return err
+	}
+	if recent > 0 || hourly >= verificationResendPerHour {
+		return errVerificationRateLimited
+	}
+
+	token, err := tokenModel.CreateUserToken(server.DB, user.ID, models.UserTokenEmailVerification, emailVerificationTTL)
+	if err != nil {
+		return err
+	}
+
+	link := server.AppConfig.AppURL + "/email/verify?token=" + url.QueryEscape(token)
+	body := fmt.Sprintf("Halo %s,\n\n"+
+		"Terima kasih sudah mendaftar di %s. Buka link berikut untuk memverifikasi email Anda "+
+		"(berlaku %d jam):\n\n"+
+		"%s\n\n"+
+		"Abaikan email ini jika Anda tidak merasa mendaftar.\n",
+		user.FirstName, server.AppConfig.AppName, int(emailVerificationTTL.Hours()), link)
+
+	ctx, cancel := context.WithTimeout(ctx, mailSendTimeout)
+	defer cancel()
+
+	return server.Mailer.Send(ctx, mail.Message{
+		To:      user.Email,
+		Subject: "Verifikasi email " + server.AppConfig.AppName,
+		Body:    body,
+	})
+}
@@ -0,0 +1,56 @@
+package controllers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gieart87/gotoko/app/models"
+)
+
+func postResendVerification(server *Server, email string) *httptest.ResponseRecorder {
+	form := url.Values{"email": {email}}
+	r := httptest.NewRequest(http.MethodPost, "/email/verification/resend", strings.NewReader(form.Encode()))
+	r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	w := httptest.NewRecorder()
+	server.ResendVerificationEmail(w, r)
+
+	return w
+}
+
+func TestResendVerificationEmailAnonymousResponseIsUniform(t *testing.T) {
+	server := newTestServer(t)
+	user := createTestUser(t, server.DB, "unverified@example.com")
+
+	first := postResendVerification(server, user.Email)
+	select {
+	case message := <-testMailer(server).sent:
+		if message.To != user.Email {
+			t.Fatalf("mail sent to %s, want %s", message.To, user.Email)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("verification email was not sent")
+	}
+
+	// Permintaan kedua kena rate limit, email ketiga tidak terdaftar: respon harus sama
+	limited := postResendVerification(server, user.Email)
+	unknown := postResendVerification(server, "nobody@example.com")
+
+	for name, w := range map[string]*httptest.ResponseRecorder{"rate limited": limited, "unknown email": unknown} {
+		if w.Code != first.Code || w.Header().Get("Location") != first.Header().Get("Location") {
+			t.Errorf("%s: got %d %s, want %d %s", name, w.Code, w.Header().Get("Location"), first.Code, first.Header().Get("Location"))
+		}
+	}
+
+	tokenModel := models.UserToken{}
+	count, err := tokenModel.CountRecentUserTokens(server.DB, user.ID, models.UserTokenEmailVerification, time.Now().Add(-time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if count != 1 {
+		t.Errorf("verification tokens = %d, want 1", count)
+	}
+}
@@ -0,0 +1,104 @@
+package controllers
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gieart87/gotoko/app/core/mail"
+	"github.com/gieart87/gotoko/app/core/session/auth"
+	"github.com/gieart87/gotoko/app/models"
+	"github.com/google/uuid"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// newTestServer membuat Server dengan database SQLite in-memory yang sudah dimigrasi.
+func newTestServer(t *testing.T) *Server {
+	t.Helper()
+
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{Logger: logger.Discard})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Satu koneksi agar semua query memakai database in-memory yang sama
+	sqlDB, err := db.DB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	sqlDB.SetMaxOpenConns(1)
+	t.Cleanup(func() { sqlDB.Close() })
+
+	for _, model := range models.RegisterModels() {
+		// Province memakai default uuid_generate_v4() khusus PostgreSQL
+		if _, ok := model.Model.(models.Province); ok {
+			continue
+		}
+		if err := db.AutoMigrate(model.Model); err != nil {
+			t.Fatalf("migrate %T: %v", model.Model, err)
+		}
+	}
+
+	return &Server{
+		DB:     db,
+		Mailer: newRecordingMailer(),
+		AppConfig: &AppConfig{
+			AppName:            "test",
+			AppURL:             "http://localhost",
+			LoginMaxFailures:   10,
+			LoginIPMaxFailures: 50,
+		},
+	}
+}
+
+func createTestUser(t *testing.T, db *gorm.DB, email string) *models.User {
+	t.Helper()
+
+	user := &models.User{
+		ID:            uuid.New().String(),
+		FirstName:     "Test",
+		LastName:      "User",
+		Email:         email,
+		Password:      "x",
+		RememberToken: "token-" + email,
+	}
+	if err := db.Create(user).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	return user
+}
+
+// loginCookies mengembalikan cookie sesi untuk user, seolah-olah user sudah login.
+func loginCookies(t *testing.T, user *models.User) []*http.Cookie {
+	t.Helper()
+
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	if err := auth.SetSessionUser(w, r, user); err != nil {
+		t.Fatal(err)
+	}
+
+	return w.Result().Cookies()
+}
+
+// recordingMailer menyimpan email yang dikirim ke channel agar test bisa menunggu pengiriman async.
+type recordingMailer struct {
+	sent chan mail.Message
+}
+
+func newRecordingMailer() *recordingMailer {
+	return &recordingMailer{sent: make(chan mail.Message, 10)}
+}
+
+func (m *recordingMailer) Send(_ context.Context, message mail.Message) error {
+	m.sent <- message
+	return nil
+}
+
+func testMailer(server *Server) *recordingMailer {
+	return server.Mailer.(*recordingMailer)
+}
@@ -8,7 +8,7 @@ import (
 )
 
 func (server *Server) Home(w http.ResponseWriter, r *http.Request) {
-	render := render.New(render.Options{
+	render := newRender(render.Options{
 		Layout: "layout",
 		Extensions : []string{".html", ".tmpl"},
 	})
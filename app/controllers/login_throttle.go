@@ -0,0 +1,102 @@
+package controllers
+
+import (
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gieart87/gotoko/app/models"
+)
+
+func (server *Server) newLoginAttempt(r *http.Request, email string) *models.LoginAttempt {
+	return &models.LoginAttempt{
+		Email:     strings.ToLower(strings.TrimSpace(email)),
+		IPAddress: server.clientIP(r),
+		UserAgent: r.UserAgent(),
+	}
+}
+
+// clientIP mengambil IP klien. Header proxy hanya dipercaya jika TrustProxyHeaders aktif,
+// karena header tersebut bisa diisi sembarang oleh klien.
+func (server *Server) clientIP(r *http.Request) string {
+	if server.AppConfig.TrustProxyHeaders {
+		if forwarded := r.Header.Values("X-Forwarded-For"); len(forwarded) > 0 {
+			return forwardedClientIP(forwarded, server.AppConfig.TrustedProxyHops)
+		}
+		if realIP := r.Header.Get("X-Real-IP"); realIP != "" {
+			return strings.TrimSpace(realIP)
+		}
+	}
+
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+
+	return host
+}
+
+// forwardedClientIP mengambil IP dari X-Forwarded-For. Setiap proxy menambahkan IP di kanan,
+// sedangkan bagian kiri bisa diisi klien, jadi yang dipakai adalah entri ke-hops dari kanan
+// (entri yang ditambahkan proxy terpercaya terluar).
+func forwardedClientIP(headers []string, hops int) string {
+	var entries []string
+	for _, header := range headers {
+		for _, entry := range strings.Split(header, ",") {
+			if entry = strings.TrimSpace(entry); entry != "" {
+				entries = append(entries, entry)
+			}
+		}
+	}
+	if len(entries) == 0 {
+		return ""
+	}
+
+	if hops < 1 {
+		hops = 1
+	}
+	if hops > len(entries) {
+		hops = len(entries)
+	}
+
+	return entries[len(entries)-hops]
+}
+
+func (server *Server) loginIPBlocked(ip string) (bool, error) {
+	if server.AppConfig.LoginIPMaxFailures <= 0 {
+		return false, nil
+	}
+
+	attemptModel := models.LoginAttempt{}
+	failures, err := attemptModel.CountFailedByIP(server.DB, ip, time.Now().Add(-server.AppConfig.LoginIPWindow))
+	if err != nil {
+		return false, err
+	}
+
+	return failures >= int64(server.AppConfig.LoginIPMaxFailures), nil
+}
+
+// recordLoginAttempt mencatat percobaan login; kegagalan mencatat hanya ditulis ke log
+// agar tidak menghalangi user login.
+func (server *Server) recordLoginAttempt(attempt *models.LoginAttempt, user *models.User, reason string) {
+	if user != nil {
+		attempt.UserID = user.ID
+	}
+	attempt.Reason = reason
+
+	attemptModel := models.LoginAttempt{}
+	if err := attemptModel.RecordLoginAttempt(server.DB, attempt); err != nil {
+		log.Printf("Gagal mencatat percobaan login %s: %v", attempt.Email, err)
+	}
+}
+
+func loginRetryMessage(wait time.Duration) string {
+	if wait >= time.Minute {
+		return fmt.Sprintf("Akun terkunci sementara karena terlalu banyak login gagal, coba lagi dalam %d menit", int(wait.Minutes()+0.999))
+	}
+
+	return fmt.Sprintf("Terlalu banyak login gagal, coba lagi dalam %d detik", int(wait.Seconds()+0.999))
+}
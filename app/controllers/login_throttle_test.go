@@ -0,0 +1,61 @@
+package controllers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestClientIP(t *testing.T) {
+	tests := []struct {
+		name      string
+		trust     bool
+		hops      int
+		forwarded []string
+		want      string
+	}{
+		{"proxy headers not trusted", false, 1, []string{"203.0.113.9"}, "10.0.0.1"},
+		{"single proxy", true, 1, []string{"203.0.113.9"}, "203.0.113.9"},
+		{"client-supplied prefix is ignored", true, 1, []string{"198.51.100.1, 203.0.113.9"}, "203.0.113.9"},
+		{"client-supplied header line is ignored", true, 1, []string{"198.51.100.1", "203.0.113.9"}, "203.0.113.9"},
+		{"two trusted proxies", true, 2, []string{"198.51.100.1, 203.0.113.9, 10.0.0.2"}, "203.0.113.9"},
+		{"fewer entries than hops", true, 3, []string{"203.0.113.9"}, "203.0.113.9"},
+		{"no header", true, 1, nil, "10.0.0.1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := &Server{AppConfig: &AppConfig{TrustProxyHeaders: tt.trust, TrustedProxyHops: tt.hops}}
+
+			r := httptest.NewRequest(http.MethodPost, "/login", nil)
+			r.RemoteAddr = "10.0.0.1:54321"
+			for _, value := range tt.forwarded {
+				r.Header.Add("X-Forwarded-For", value)
+			}
+
+			if got := server.clientIP(r); got != tt.want {
+				t.Errorf("clientIP() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSpoofedForwardedForCannotChangeRecordedIP(t *testing.T) {
+	server := newTestServer(t)
+	server.AppConfig.TrustProxyHeaders = true
+	server.AppConfig.TrustedProxyHops = 1
+
+	// Klien mengirim XFF palsu berbeda di setiap request, proxy menambahkan IP aslinya
+	var ips []string
+	for _, spoofed := range []string{"198.51.100.1", "198.51.100.2", "198.51.100.3"} {
+		r := httptest.NewRequest(http.MethodPost, "/login", nil)
+		r.Header.Set("X-Forwarded-For", spoofed+", 203.0.113.9")
+		ips = append(ips, server.newLoginAttempt(r, "victim@example.com").IPAddress)
+	}
+
+	for _, ip := range ips {
+		if ip != "203.0.113.9" {
+			t.Fatalf("recorded IPs = %v, want all 203.0.113.9", ips)
+		}
+	}
+}
@@ -0,0 +1,38 @@
+package controllers
+
+import (
+	"net/http"
+
+	"github.com/gieart87/gotoko/app/core/session/auth"
+	"github.com/gieart87/gotoko/app/models"
+	"github.com/gorilla/mux"
+)
+
+// findAuthorizedOrder memuat order {id} jika user yang login adalah pemiliknya atau staf
+// dengan permission yang diminta. Semua endpoint order milik pelanggan (ShowOrder,
+// OrderInvoice, CancelOrder, dst.) harus memuat order lewat fungsi ini.
+//
+// Order milik user lain dijawab 404, sama seperti order yang tidak ada, agar keberadaan
+// ID order tidak bisa ditebak. Jika ok bernilai false response sudah ditulis.
+func (server *Server) findAuthorizedOrder(w http.ResponseWriter, r *http.Request, permission string) (*models.User, *models.Order, bool) {
+	user := auth.CurrentUser(server.DB, w, r)
+	if user == nil {
+		http.Redirect(w, r, "/login?error=Anda+Perlu+Login", http.StatusSeeOther)
+		return nil, nil, false
+	}
+
+	orderID := mux.Vars(r)["id"]
+	if orderID == "" {
+		http.NotFound(w, r)
+		return nil, nil, false
+	}
+
+	orderModel := models.Order{}
+	order, err := orderModel.FindByID(server.DB, orderID)
+	if err != nil || !order.AccessibleBy(user, permission) {
+		http.NotFound(w, r)
+		return nil, nil, false
+	}
+
+	return user, order, true
+}
@@ -0,0 +1,141 @@
+package controllers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gieart87/gotoko/app/consts"
+	"github.com/gieart87/gotoko/app/models"
+	"github.com/gorilla/mux"
+)
+
+func TestShowOrderHidesOtherUsersOrders(t *testing.T) {
+	server := newTestServer(t)
+
+	owner := createTestUser(t, server.DB, "owner@example.com")
+	other := createTestUser(t, server.DB, "other@example.com")
+
+	order := &models.Order{UserID: owner.ID, Code: "1/ORDER/I/2026"}
+	if err := server.DB.Create(order).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	router := newOrderTestRouter(server)
+
+	tests := []struct {
+		name   string
+		method string
+		path   string
+	}{
+		{"other user's order", http.MethodGet, "/orders/" + order.ID},
+		{"unknown order", http.MethodGet, "/orders/does-not-exist"},
+		{"other user's invoice", http.MethodGet, "/orders/" + order.ID + "/invoice"},
+		{"unknown invoice", http.MethodGet, "/orders/does-not-exist/invoice"},
+		{"cancel other user's order", http.MethodPost, "/orders/" + order.ID + "/cancel"},
+		{"cancel unknown order", http.MethodPost, "/orders/does-not-exist/cancel"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(tt.method, tt.path, nil)
+			for _, cookie := range loginCookies(t, other) {
+				r.AddCookie(cookie)
+			}
+			w := httptest.NewRecorder()
+
+			router.ServeHTTP(w, r)
+
+			if w.Code != http.StatusNotFound {
+				t.Fatalf("status = %d, want %d", w.Code, http.StatusNotFound)
+			}
+		})
+	}
+
+	orderModel := models.Order{}
+	stored, err := orderModel.FindByID(server.DB, order.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stored.Status != consts.OrderStatusPending {
+		t.Errorf("status = %d, other user must not be able to cancel the order", stored.Status)
+	}
+}
+
+func newOrderTestRouter(server *Server) *mux.Router {
+	router := mux.NewRouter()
+	router.HandleFunc("/orders/{id}", server.ShowOrder).Methods("GET")
+	router.HandleFunc("/orders/{id}/invoice", server.OrderInvoice).Methods("GET")
+	router.HandleFunc("/orders/{id}/cancel", server.CancelOrder).Methods("POST")
+
+	return router
+}
+
+func TestCancelOrderByOwner(t *testing.T) {
+	tests := []struct {
+		name          string
+		paymentStatus string
+		wantStatus    int
+	}{
+		{"unpaid order", consts.OrderPaymentStatusUnpaid, consts.OrderStatusCancelled},
+		{"paid order needs a refund by staff", consts.OrderPaymentStatusPaid, consts.OrderStatusPending},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := newTestServer(t)
+			owner := createTestUser(t, server.DB, "owner@example.com")
+
+			order := &models.Order{UserID: owner.ID, Code: "1/ORDER/I/2026", PaymentStatus: tt.paymentStatus}
+			if err := server.DB.Create(order).Error; err != nil {
+				t.Fatal(err)
+			}
+
+			r := httptest.NewRequest(http.MethodPost, "/orders/"+order.ID+"/cancel", nil)
+			for _, cookie := range loginCookies(t, owner) {
+				r.AddCookie(cookie)
+			}
+			w := httptest.NewRecorder()
+			newOrderTestRouter(server).ServeHTTP(w, r)
+
+			if w.Code != http.StatusSeeOther {
+				t.Fatalf("status = %d, want redirect", w.Code)
+			}
+
+			orderModel := models.Order{}
+			stored, err := orderModel.FindByID(server.DB, order.ID)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if stored.Status != tt.wantStatus {
+				t.Errorf("order status = %d, want %d (redirect %s)", stored.Status, tt.wantStatus, w.Header().Get("Location"))
+			}
+		})
+	}
+}
+
+func TestFindAuthorizedOrderAllowsOwner(t *testing.T) {
+	server := newTestServer(t)
+
+	owner := createTestUser(t, server.DB, "owner@example.com")
+	order := &models.Order{UserID: owner.ID, Code: "1/ORDER/I/2026"}
+	if err := server.DB.Create(order).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	var found *models.Order
+	router := mux.NewRouter()
+	router.HandleFunc("/orders/{id}", func(w http.ResponseWriter, r *http.Request) {
+		_, found, _ = server.findAuthorizedOrder(w, r, consts.PermissionOrderRead)
+	})
+
+	r := httptest.NewRequest(http.MethodGet, "/orders/"+order.ID, nil)
+	for _, cookie := range loginCookies(t, owner) {
+		r.AddCookie(cookie)
+	}
+	router.ServeHTTP(httptest.NewRecorder(), r)
+
+	if found == nil || found.ID != order.ID {
+		t.Fatalf("owner could not load own order, got %+v", found)
+	}
+}
@@ -6,13 +6,13 @@ import (
 	"log"
 	"net/http"
 	"os"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
 	"github.com/gieart87/gotoko/app/consts"
 	"github.com/gieart87/gotoko/app/core/session/auth"
 	"github.com/gieart87/gotoko/app/models"
-	"github.com/gorilla/mux"
 	"github.com/midtrans/midtrans-go"
 	"github.com/midtrans/midtrans-go/snap"
 	"github.com/shopspring/decimal"
@@ -61,6 +61,11 @@ func (server *Server) Checkout(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if !user.IsEmailVerified() {
+		http.Redirect(w, r, "/carts?error=Verifikasi+email+Anda+sebelum+checkout", http.StatusSeeOther)
+		return
+	}
+
 	session, _ := auth.GetSessionUser(r)
 	courier, _ := session.Values["checkout_courier"].(string)
 	if courier == "" {
@@ -79,6 +84,35 @@ func (server *Server) Checkout(w http.ResponseWriter, r *http.Request) {
 	city, _ := session.Values["checkout_city"].(string)
 	cost, _ := session.Values["checkout_shipping_cost"].(int)
 
+	shippingAddress := &ShippingAddress{
+		FirstName:  r.FormValue("first_name"),
+		LastName:   r.FormValue("last_name"),
+		Address1:   r.FormValue("address1"),
+		Address2:   r.FormValue("address2"),
+		Phone:      r.FormValue("phone"),
+		Email:      r.FormValue("email"),
+		PostCode:   r.FormValue("post_code"),
+		CityID:     city,
+		ProvinceID: province,
+	}
+
+	// Alamat tersimpan dipakai jika dipilih; ongkir harus sudah dihitung untuk kota alamat tersebut
+	if addressID := r.FormValue("address_id"); addressID != "" {
+		addressModel := models.Address{}
+		address, err := addressModel.FindUserAddress(server.DB, user.ID, addressID)
+		if err != nil {
+			http.Redirect(w, r, "/carts?error=Alamat+tidak+ditemukan", http.StatusSeeOther)
+			return
+		}
+
+		if address.ProvinceID != province || address.CityID != city {
+			http.Redirect(w, r, "/carts?address_id="+address.ID+"&error=Hitung+ulang+ongkir+untuk+alamat+yang+dipilih", http.StatusSeeOther)
+			return
+		}
+
+		shippingAddress = shippingAddressFromAddress(address, user)
+	}
+
 	checkoutReq := &CheckoutRequest{
 		Cart: cart,
 		ShippingFee: &ShippingFee{
@@ -86,17 +120,7 @@ func (server *Server) Checkout(w http.ResponseWriter, r *http.Request) {
 			PackageName: "REG",
 			Fee:         float64(cost),
 		},
-		ShippingAddress: &ShippingAddress{
-			FirstName:  r.FormValue("first_name"),
-			LastName:   r.FormValue("last_name"),
-			Address1:   r.FormValue("address1"),
-			Address2:   r.FormValue("address2"),
-			Phone:      r.FormValue("phone"),
-			Email:      r.FormValue("email"),
-			PostCode:   r.FormValue("post_code"),
-			CityID:     city,
-			ProvinceID: province,
-		},
+		ShippingAddress: shippingAddress,
 	}
 
 	order, err := server.SaveOrder(user, checkoutReq)
@@ -110,36 +134,93 @@ func (server *Server) Checkout(w http.ResponseWriter, r *http.Request) {
 	http.Redirect(w, r, "/orders/"+order.ID, http.StatusSeeOther)
 }
 
+func shippingAddressFromAddress(address *models.Address, user *models.User) *ShippingAddress {
+	firstName, lastName := address.Name, ""
+	if parts := strings.SplitN(strings.TrimSpace(address.Name), " ", 2); len(parts) == 2 {
+		firstName, lastName = parts[0], parts[1]
+	}
+
+	email := address.Email
+	if email == "" {
+		email = user.Email
+	}
+
+	return &ShippingAddress{
+		FirstName:  firstName,
+		LastName:   lastName,
+		Address1:   address.Address1,
+		Address2:   address.Address2,
+		Phone:      address.Phone,
+		Email:      email,
+		PostCode:   address.PostCode,
+		CityID:     address.CityID,
+		ProvinceID: address.ProvinceID,
+	}
+}
+
 func (server *Server) ShowOrder(w http.ResponseWriter, r *http.Request) {
-	render := render.New(render.Options{
+	render := newRender(render.Options{
 		Layout:     "layout",
 		Extensions: []string{".html", ".tmpl"},
 	})
 
-	vars := mux.Vars(r)
-	if vars["id"] == "" {
-		http.Redirect(w, r, "/products", http.StatusSeeOther)
+	user, order, ok := server.findAuthorizedOrder(w, r, consts.PermissionOrderRead)
+	if !ok {
 		return
 	}
 
-	var order models.Order
-	if err := server.DB.
-		Preload("OrderCustomer").
-		Preload("OrderItems").
-		Preload("OrderItems.Product").
-		Where("id = ?", vars["id"]).
-		First(&order).Error; err != nil {
-		http.Redirect(w, r, "/products", http.StatusSeeOther)
+	render.HTML(w, http.StatusOK, "show_order", map[string]interface{}{
+		"order":   order,
+		"user":    user,
+		"success": r.URL.Query().Get("success"),
+		"Error":   r.URL.Query().Get("error"),
+		"Message": r.URL.Query().Get("message"),
+	})
+}
+
+// OrderInvoice mengunduh invoice order sebagai halaman HTML siap cetak.
+func (server *Server) OrderInvoice(w http.ResponseWriter, r *http.Request) {
+	render := newRender(render.Options{
+		Extensions: []string{".html", ".tmpl"},
+	})
+
+	user, order, ok := server.findAuthorizedOrder(w, r, consts.PermissionOrderRead)
+	if !ok {
 		return
 	}
 
-	render.HTML(w, http.StatusOK, "show_order", map[string]interface{}{
-		"order":   &order,
-		"user":    auth.CurrentUser(server.DB, w, r),
-		"success": r.URL.Query().Get("success"),
+	filename := "invoice-" + strings.ReplaceAll(order.Code, "/", "-") + ".html"
+	w.Header().Set("Content-Disposition", `attachment; filename="`+filename+`"`)
+
+	render.HTML(w, http.StatusOK, "invoice", map[string]interface{}{
+		"order": order,
+		"user":  user,
 	})
 }
 
+// CancelOrder membatalkan order milik pelanggan. Order yang sudah dibayar atau diproses
+// hanya bisa dibatalkan admin karena membutuhkan refund.
+func (server *Server) CancelOrder(w http.ResponseWriter, r *http.Request) {
+	user, order, ok := server.findAuthorizedOrder(w, r, consts.PermissionOrderUpdate)
+	if !ok {
+		return
+	}
+
+	redirectURL := "/orders/" + order.ID
+	if !order.CancellableByCustomer() {
+		http.Redirect(w, r, redirectURL+"?error=Order+tidak+bisa+dibatalkan", http.StatusSeeOther)
+		return
+	}
+
+	if err := order.TransitionStatus(server.DB, consts.OrderStatusCancelled, user, "Dibatalkan oleh pelanggan"); err != nil {
+		log.Printf("Gagal membatalkan order %s: %v", order.ID, err)
+		http.Redirect(w, r, redirectURL+"?error=Gagal+membatalkan+order", http.StatusSeeOther)
+		return
+	}
+
+	http.Redirect(w, r, redirectURL+"?message=Order+berhasil+dibatalkan", http.StatusSeeOther)
+}
+
 func (server *Server) SaveOrder(user *models.User, r *CheckoutRequest) (*models.Order, error) {
 	orderID := uuid.New().String()
 	shippingCost := decimal.NewFromFloat(r.ShippingFee.Fee)
@@ -0,0 +1,187 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/gieart87/gotoko/app/core/mail"
+	"github.com/gieart87/gotoko/app/core/session/auth"
+	"github.com/gieart87/gotoko/app/models"
+	"github.com/unrolled/render"
+	"gorm.io/gorm"
+)
+
+const (
+	passwordResetTTL  = time.Hour
+	mailSendTimeout   = 10 * time.Second
+	forgotPasswordMsg = "Jika email terdaftar, link reset password sudah dikirim"
+
+	// Batas permintaan reset password: jeda minimal dan jumlah per jam untuk satu email,
+	// serta jumlah per jam dari satu IP
+	passwordResetInterval  = time.Minute
+	passwordResetPerHour   = 5
+	passwordResetIPPerHour = 20
+)
+
+func (server *Server) ForgotPassword(w http.ResponseWriter, r *http.Request) {
+	render := newRender(render.Options{
+		Layout:     "layout",
+		Extensions: []string{".html", ".tmpl"},
+	})
+
+	_ = render.HTML(w, http.StatusOK, "forgot_password", map[string]interface{}{
+		"Error":   r.URL.Query().Get("error"),
+		"Message": r.URL.Query().Get("message"),
+	})
+}
+
+// DoForgotPassword mengirim link reset password. Respon selalu sama, baik email terdaftar
+// maupun tidak atau sedang dibatasi, dan email dikirim di background, agar form ini tidak
+// bisa dipakai untuk mengecek email yang terdaftar.
+func (server *Server) DoForgotPassword(w http.ResponseWriter, r *http.Request) {
+	email := strings.TrimSpace(r.FormValue("email"))
+	if email == "" {
+		http.Redirect(w, r, "/password/forgot?error=Email+wajib+diisi", http.StatusSeeOther)
+		return
+	}
+
+	ip := server.clientIP(r)
+	limited, err := server.passwordResetLimited(email, ip)
+	if err != nil {
+		log.Printf("Gagal memeriksa batas reset password %s: %v", email, err)
+	}
+
+	if err == nil && !limited {
+		resetModel := models.PasswordResetRequest{}
+		if err := resetModel.RecordPasswordResetRequest(server.DB, email, ip); err != nil {
+			log.Printf("Gagal mencatat permintaan reset password %s: %v", email, err)
+		}
+
+		userModel := models.User{}
+		if user, err := userModel.FindByEmail(server.DB, email); err == nil && !user.IsSuspended() {
+			go func(user *models.User) {
+				if err := server.sendPasswordResetLink(context.Background(), user); err != nil {
+					log.Printf("Gagal mengirim link reset password ke %s: %v", user.Email, err)
+				}
+			}(user)
+		}
+	}
+
+	http.Redirect(w, r, "/password/forgot?message="+url.QueryEscape(forgotPasswordMsg), http.StatusSeeOther)
+}
+
+// passwordResetLimited bernilai true jika email atau IP sudah melewati batas permintaan reset.
+func (server *Server) passwordResetLimited(email string, ip string) (bool, error) {
+	resetModel := models.PasswordResetRequest{}
+
+	recent, err := resetModel.CountByEmail(server.DB, email, time.Now().Add(-passwordResetInterval))
+	if err != nil {
+		return false, err
+	}
+	hourly, err := resetModel.CountByEmail(server.DB, email, time.Now().Add(-time.Hour))
+	if err != nil {
+		return false, err
+	}
+	fromIP, err := resetModel.CountByIP(server.DB, ip, time.Now().Add(-time.Hour))
+	if err != nil {
+		return false, err
+	}
+
+	return recent > 0 || hourly >= passwordResetPerHour || fromIP >= passwordResetIPPerHour, nil
+}
+
+func (server *Server) sendPasswordResetLink(ctx context.Context, user *models.User) error {
+	tokenModel := models.UserToken{}
+	token, err := tokenModel.CreateUserToken(server.DB, user.ID, models.UserTokenPasswordReset, passwordResetTTL)
+	if err != nil {
+		return err
+	}
+
+	link := server.AppConfig.AppURL + "/password/reset?token=" + url.QueryEscape(token)
+	body := fmt.Sprintf("Halo %s,\n\n"+
+		"Kami menerima permintaan untuk mereset password akun %s Anda.\n"+
+		"Buka link berikut untuk membuat password baru (berlaku %d menit, hanya bisa dipakai sekali):\n\n"+
+		"%s\n\n"+
+		"Abaikan email ini jika Anda tidak meminta reset password.\n",
+		user.FirstName, server.AppConfig.AppName, int(passwordResetTTL.Minutes()), link)
+
+	ctx, cancel := context.WithTimeout(ctx, mailSendTimeout)
+	defer cancel()
+
+	return server.Mailer.Send(ctx, mail.Message{
+		To:      user.Email,
+		Subject: "Reset password " + server.AppConfig.AppName,
+		Body:    body,
+	})
+}
+
+func (server *Server) ResetPassword(w http.ResponseWriter, r *http.Request) {
+	render := newRender(render.Options{
+		Layout:     "layout",
+		Extensions: []string{".html", ".tmpl"},
+	})
+
+	token := r.URL.Query().Get("token")
+
+	tokenModel := models.UserToken{}
+	if _, err := tokenModel.FindValidUserToken(server.DB, models.UserTokenPasswordReset, token); err != nil {
+		http.Redirect(w, r, "/password/forgot?error="+url.QueryEscape(models.ErrInvalidUserToken.Error()), http.StatusSeeOther)
+		return
+	}
+
+	_ = render.HTML(w, http.StatusOK, "reset_password", map[string]interface{}{
+		"Token": token,
+		"Error": r.URL.Query().Get("error"),
+	})
+}
+
+// DoResetPassword mengganti password dengan token dari email. Token dipakai dan password
+// disimpan dalam satu transaksi; semua sesi user yang masih aktif ikut berakhir.
+func (server *Server) DoResetPassword(w http.ResponseWriter, r *http.Request) {
+	token := r.FormValue("token")
+	password := r.FormValue("password")
+	back := "/password/reset?token=" + url.QueryEscape(token) + "&error="
+
+	if len(password) < minPasswordLength {
+		http.Redirect(w, r, back+"Password+minimal+8+karakter", http.StatusSeeOther)
+		return
+	}
+
+	if password != r.FormValue("password_confirmation") {
+		http.Redirect(w, r, back+"Konfirmasi+password+tidak+sama", http.StatusSeeOther)
+		return
+	}
+
+	hashedPassword, err := auth.MakePassword(password)
+	if err != nil {
+		http.Redirect(w, r, back+"Terjadi+kesalahan+saat+membuat+password", http.StatusSeeOther)
+		return
+	}
+
+	err = server.DB.Transaction(func(tx *gorm.DB) error {
+		tokenModel := models.UserToken{}
+		userToken, err := tokenModel.ConsumeUserToken(tx, models.UserTokenPasswordReset, token)
+		if err != nil {
+			return err
+		}
+
+		userModel := models.User{}
+		user, err := userModel.FindByID(tx, userToken.UserID)
+		if err != nil {
+			return err
+		}
+
+		return user.UpdatePassword(tx, hashedPassword)
+	})
+	if err != nil {
+		http.Redirect(w, r, "/password/forgot?error="+url.QueryEscape(models.ErrInvalidUserToken.Error()), http.StatusSeeOther)
+		return
+	}
+
+	http.Redirect(w, r, "/login?message=Password+berhasil+direset,+silakan+login", http.StatusSeeOther)
+}
@@ -0,0 +1,69 @@
+package controllers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+func postForgotPassword(server *Server, email string, remoteAddr string) *httptest.ResponseRecorder {
+	form := url.Values{"email": {email}}
+	r := httptest.NewRequest(http.MethodPost, "/password/forgot", strings.NewReader(form.Encode()))
+	r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	r.RemoteAddr = remoteAddr
+	w := httptest.NewRecorder()
+	server.DoForgotPassword(w, r)
+
+	return w
+}
+
+func TestDoForgotPasswordSendsMailAsynchronously(t *testing.T) {
+	server := newTestServer(t)
+	user := createTestUser(t, server.DB, "forgot@example.com")
+
+	w := postForgotPassword(server, user.Email, "203.0.113.1:1234")
+	if w.Code != http.StatusSeeOther || !strings.Contains(w.Header().Get("Location"), "message=") {
+		t.Fatalf("response = %d %s", w.Code, w.Header().Get("Location"))
+	}
+
+	select {
+	case message := <-testMailer(server).sent:
+		if message.To != user.Email || !strings.Contains(message.Body, "/password/reset?token=") {
+			t.Errorf("unexpected mail %+v", message)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("reset mail was not sent")
+	}
+}
+
+func TestDoForgotPasswordRateLimits(t *testing.T) {
+	server := newTestServer(t)
+	user := createTestUser(t, server.DB, "limited@example.com")
+	mailer := testMailer(server)
+
+	first := postForgotPassword(server, user.Email, "203.0.113.1:1234")
+	<-mailer.sent
+
+	// Permintaan kedua untuk email yang sama dalam jeda satu menit tidak mengirim email,
+	// tetapi responnya tetap sama
+	second := postForgotPassword(server, user.Email, "203.0.113.2:1234")
+	if second.Header().Get("Location") != first.Header().Get("Location") {
+		t.Errorf("limited response %s, want %s", second.Header().Get("Location"), first.Header().Get("Location"))
+	}
+
+	// Satu IP hanya boleh passwordResetIPPerHour permintaan per jam, berapapun emailnya
+	for i := 0; i < passwordResetIPPerHour; i++ {
+		postForgotPassword(server, "unknown"+strings.Repeat("x", i)+"@example.com", "198.51.100.9:1234")
+	}
+	other := createTestUser(t, server.DB, "other@example.com")
+	postForgotPassword(server, other.Email, "198.51.100.9:1234")
+
+	select {
+	case message := <-mailer.sent:
+		t.Errorf("unexpected mail to %s", message.To)
+	case <-time.After(200 * time.Millisecond):
+	}
+}
@@ -91,6 +91,8 @@ func (server *Server) Midtrans(w http.ResponseWriter, r *http.Request) {
 		// Update status order
 		if err := order.MarkAsPaid(server.DB); err != nil {
 			log.Printf("❌ Failed to mark order as paid: %v", err)
+		} else if order.Status == consts.OrderStatusCancelled {
+			log.Printf("⚠️ Order %s sudah dibatalkan tetapi pembayaran diterima, perlu refund", order.ID)
 		} else {
 			log.Printf("✅ Order %s successfully marked as PAID!", order.ID)
 		}
@@ -1,65 +1,84 @@
 package controllers
 
 import (
+	"html/template"
+	"log"
 	"net/http"
+	"net/url"
 	"strconv"
+	"strings"
 
-
-	"github.com/gieart87/gotoko/app/utils"
 	"github.com/gieart87/gotoko/app/core/session/auth"
 	"github.com/gieart87/gotoko/app/models"
 	"github.com/gorilla/mux"
 	"github.com/unrolled/render"
 )
 
-func (server *Server) getRenderer() *render.Render {
-	return render.New(render.Options{
+func (server *Server) getRenderer() *viewRender {
+	return newRender(render.Options{
 		Layout:     "layout",
 		Extensions: []string{".html", ".tmpl"},
 	})
 }
 
 func (server *Server) Products(w http.ResponseWriter, r *http.Request) {
-    render := render.New(render.Options{
+    render := newRender(render.Options{
         Layout:     "layout",
         Extensions: []string{".html", ".tmpl"},
     })
 
     q := r.URL.Query()
-    categorySlug := q.Get("category")
+    filter := models.ProductFilterFromQuery(q)
 
     page, _ := strconv.Atoi(q.Get("page"))
     if page <= 0 { page = 1 }
     perPage := 9
 
     productModel := models.Product{}
-    
-    // GetProducts di model harus sudah menggunakan .Preload("ProductImages")
-    products, totalRows, err := productModel.GetProducts(server.DB, perPage, page, categorySlug)
+
+    products, totalRows, err := productModel.FilterProducts(server.DB, perPage, page, filter)
     if err != nil {
         http.Error(w, "Gagal memuat produk", http.StatusInternalServerError)
         return
     }
 
+    facets, err := productModel.ProductFacets(server.DB, filter)
+    if err != nil {
+        log.Printf("Gagal menghitung facet produk: %v", err)
+    }
+
+    // Filter & urutan yang dipilih ikut dibawa di link paginasi
     pagination, _ := GetPaginationLinks(server.AppConfig, PaginationParams{
         Path:        "products",
+        Query:       q,
         TotalRows:   int32(totalRows),
         PerPage:     int32(perPage),
         CurrentPage: int32(page),
     })
 
+    // Pohon kategori untuk navigasi di sidebar storefront
+    categoryModel := models.Category{}
+    categoryTree, err := categoryModel.GetCategoryTree(server.DB)
+    if err != nil {
+        log.Printf("Gagal memuat pohon kategori: %v", err)
+    }
+
     user := auth.CurrentUser(server.DB, w, r)
 
     _ = render.HTML(w, http.StatusOK, "products", map[string]interface{}{
-        "products":   products, // Slice ini sekarang membawa data .Stock
-        "pagination": pagination,
-        "user":       user,
-        "category":   categorySlug, 
+        "products":     products, // Slice ini sekarang membawa data .Stock
+        "pagination":   pagination,
+        "user":         user,
+        "category":     filter.CategorySlug,
+        "categoryTree": categoryTree,
+        "filter":       filter,
+        "facets":       facets,
+        "sort":         filter.Sort,
     })
 }
 
 func (server *Server) GetProductBySlug(w http.ResponseWriter, r *http.Request) {
-	render := render.New(render.Options{
+	render := newRender(render.Options{
 		Layout: "layout",
 		Extensions: []string{".html", ".tmpl"},
 	})
@@ -75,6 +94,15 @@ func (server *Server) GetProductBySlug(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// Produk dibuka dari hasil pencarian (?sq=), hanya pencarian milik sesi ini yang dicatat
+	if searchQueryID := r.URL.Query().Get("sq"); auth.HasSearchQuery(r, searchQueryID) {
+		searchQueryModel := models.SearchQuery{}
+		if err := searchQueryModel.RecordClick(server.DB, searchQueryID, product.ID); err != nil {
+			log.Printf("Gagal mencatat klik pencarian: %v", err)
+		}
+		auth.SetSearchClick(w, r, searchQueryID, product.ID)
+	}
+
 	user := auth.CurrentUser(server.DB, w, r)
 	_ = render.HTML(w, http.StatusOK, "product", map[string]interface{}{
 		"product": product,
@@ -83,41 +111,101 @@ func (server *Server) GetProductBySlug(w http.ResponseWriter, r *http.Request) {
 }
 
 func (server *Server) SearchProducts(w http.ResponseWriter, r *http.Request) {
-    // Ganti server.getRenderer() dengan ini agar tidak merah:
-    render := render.New(render.Options{
-        Layout:     "layout",
-        Extensions: []string{".html", ".tmpl"},
-    })
-	
+	render := server.getRenderer()
+
 	// 1. Ambil keyword dari URL (?q=obat+pusing)
-	query := r.URL.Query().Get("q")
+	query := strings.TrimSpace(r.URL.Query().Get("q"))
 	if query == "" {
 		http.Redirect(w, r, "/products", http.StatusSeeOther)
 		return
 	}
 
-	// 2. Tanya ke AI Python (FastAPI) via Utils
-	smartKeywords, err := utils.GetSmartSearch(query)
-	
-	var products []models.Product
+	page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+	if page <= 0 {
+		page = 1
+	}
+	perPage := 9
+
+	// 2. Full-text search langsung di database, diurutkan berdasarkan relevansi
 	productModel := models.Product{}
+	source := models.SearchSourceFullText
+	results, totalRows, err := productModel.FullTextSearch(server.DB, query, perPage, page)
+
+	// 3. Jika tidak ada hasil (misalnya salah ketik), cari di index in-memory yang
+	// mendukung awalan kata, salah ketik dan sinonim merek/generik
+	if err == nil && totalRows == 0 {
+		source = models.SearchSourceIndex
+		results, totalRows, err = server.searchIndexResults(query, perPage, page)
+	}
+
+	// Masih kosong: minta saran kata kunci ke AI.
+	// Semua saran dipakai sekaligus dengan operator "or".
+	var smartKeywords []string
+	if err == nil && totalRows == 0 {
+		// Circuit breaker membuat ini langsung gagal selama service AI mati
+		smartKeywords, _ = server.AI.Search(r.Context(), query)
+		if len(smartKeywords) > 0 {
+			source = models.SearchSourceAI
+			results, totalRows, err = productModel.FullTextSearch(server.DB, strings.Join(smartKeywords, " or "), perPage, page)
+		}
+	}
 
-	// 3. Logika Hasil: Jika AI memberikan saran, cari berdasarkan saran AI tersebut
-	if err == nil && len(smartKeywords) > 0 {
-		// Kita ambil kata kunci pertama hasil perbaikan AI untuk cari di DB
-		products, _ = productModel.SearchByKeywords(server.DB, smartKeywords)
-	} else {
-		// Jika AI gagal/tidak ada hasil, lakukan pencarian LIKE standar di DB
-		products, _ = productModel.StandardSearch(server.DB, query)
+	// 4. Index full-text belum tersedia (db:migrate belum dijalankan), pakai pencarian LIKE
+	if err != nil {
+		log.Printf("Full-text search gagal, memakai pencarian standar: %v", err)
+
+		source = models.SearchSourceStandard
+		products, _ := productModel.StandardSearch(server.DB, query)
+		results = make([]models.ProductSearchResult, len(products))
+		for i, product := range products {
+			results[i] = models.ProductSearchResult{
+				Product:       product,
+				NameHighlight: template.HTML(template.HTMLEscapeString(product.Name)),
+			}
+		}
+		totalRows = int64(len(results))
+		perPage = len(results)
+		page = 1
 	}
 
 	user := auth.CurrentUser(server.DB, w, r)
 
-	// 4. Kirim ke template search_results.html
+	// Catat pencarian untuk laporan. Link paginasi membawa ?sq= sehingga
+	// halaman berikutnya tidak tercatat sebagai pencarian baru. ?sq= dari sesi lain diabaikan.
+	searchQueryID := r.URL.Query().Get("sq")
+	if !auth.HasSearchQuery(r, searchQueryID) {
+		searchQueryID = ""
+
+		userID := ""
+		if user != nil {
+			userID = user.ID
+		}
+
+		searchQueryModel := models.SearchQuery{}
+		searchQuery, err := searchQueryModel.LogSearch(server.DB, query, totalRows, source, userID)
+		if err != nil {
+			log.Printf("Gagal mencatat pencarian: %v", err)
+		} else {
+			searchQueryID = searchQuery.ID
+			auth.AddSearchQuery(w, r, searchQueryID)
+		}
+	}
+
+	pagination, _ := GetPaginationLinks(server.AppConfig, PaginationParams{
+		Path:        "products/search",
+		Query:       url.Values{"q": {query}, "sq": {searchQueryID}},
+		TotalRows:   int32(totalRows),
+		PerPage:     int32(perPage),
+		CurrentPage: int32(page),
+	})
+
+	// 5. Kirim ke template search_results.html
 	_ = render.HTML(w, http.StatusOK, "search_results", map[string]interface{}{
-		"products": products,
-		"keyword":  query,
-		"user":     user,
+		"products":       results, // setiap item membawa .NameHighlight dan .SnippetHighlight
+		"keyword":        query,
+		"pagination":     pagination,
+		"user":           user,
+		"searchQueryID":  searchQueryID, // tambahkan ?sq= pada link produk agar klik tercatat
 		"ai_suggestions": smartKeywords, // Tampilkan di UI jika ingin "Maksud anda: ..."
 	})
-}
\ No newline at end of file
+}
@@ -0,0 +1,268 @@
+package controllers
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+
+	"github.com/gieart87/gotoko/app/models"
+	"github.com/gieart87/gotoko/app/utils"
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+)
+
+const uploadDir = "uploads"
+
+// saveProductImage memproses file upload menjadi gambar asli + varian ukuran
+// dan menyimpannya ke storage dengan nama berdasarkan hash isi file.
+func (server *Server) saveProductImage(ctx context.Context, file io.Reader, productID string) (*models.ProductImage, error) {
+	processed, err := utils.ProcessImage(file)
+	if err != nil {
+		return nil, err
+	}
+
+	productImage := &models.ProductImage{
+		ID:        uuid.New().String(),
+		ProductID: productID,
+	}
+
+	originalPath := fmt.Sprintf("%s/%s%s", uploadDir, processed.Hash, processed.Extension)
+	if err := server.putUpload(ctx, originalPath, processed.Original, processed.ContentType); err != nil {
+		return nil, err
+	}
+	productImage.Path = originalPath
+
+	if err := server.writeImageVariants(ctx, productImage, processed); err != nil {
+		server.discardProductImages([]models.ProductImage{*productImage})
+		return nil, err
+	}
+
+	return productImage, nil
+}
+
+// saveUploadedImages memproses semua file pada field "images" (multiple) dan "image".
+// Posisi dimulai dari startPosition; jika produk belum punya gambar utama,
+// gambar pertama yang di-upload dijadikan gambar utama. Form tanpa multipart berarti tidak
+// ada file; multipart yang rusak atau terpotong dikembalikan sebagai error agar tidak diam-diam hilang.
+func (server *Server) saveUploadedImages(r *http.Request, productID string, startPosition int, hasPrimary bool, altText string) ([]models.ProductImage, error) {
+	var productImages []models.ProductImage
+
+	if err := r.ParseMultipartForm(32 << 20); err != nil {
+		if errors.Is(err, http.ErrNotMultipart) {
+			return productImages, nil
+		}
+		return nil, fmt.Errorf("upload gambar gagal dibaca: %w", err)
+	}
+	if r.MultipartForm == nil {
+		return productImages, nil
+	}
+
+	var headers []*multipart.FileHeader
+	headers = append(headers, r.MultipartForm.File["images"]...)
+	headers = append(headers, r.MultipartForm.File["image"]...)
+
+	for i, header := range headers {
+		file, err := header.Open()
+		if err != nil {
+			return nil, err
+		}
+
+		productImage, err := server.saveProductImage(r.Context(), file, productID)
+		file.Close()
+		if err != nil {
+			server.discardProductImages(productImages)
+			return nil, fmt.Errorf("%s: %w", header.Filename, err)
+		}
+
+		productImage.Position = startPosition + i
+		productImage.IsPrimary = !hasPrimary && i == 0
+		productImage.AltText = altText
+		productImages = append(productImages, *productImage)
+	}
+
+	return productImages, nil
+}
+
+// writeImageVariants menulis seluruh varian ukuran ke storage dan mengisi kolomnya pada productImage.
+func (server *Server) writeImageVariants(ctx context.Context, productImage *models.ProductImage, processed *utils.ProcessedImage) error {
+	for _, variant := range utils.ImageVariants {
+		variantPath := fmt.Sprintf("%s/%s-%s%s", uploadDir, processed.Hash, variant.Name, processed.Extension)
+		if err := server.putUpload(ctx, variantPath, processed.Variants[variant.Name], processed.ContentType); err != nil {
+			return err
+		}
+		productImage.SetVariant(variant.Name, variantPath)
+	}
+
+	return nil
+}
+
+func (server *Server) putUpload(ctx context.Context, key string, data []byte, contentType string) error {
+	return server.Storage.Put(ctx, key, bytes.NewReader(data), int64(len(data)), contentType)
+}
+
+// removeProductImageFiles menghapus file asli dan seluruh varian dari storage.
+// Karena nama file berdasarkan hash isi, file yang masih dipakai ProductImage lain tidak dihapus.
+func (server *Server) removeProductImageFiles(ctx context.Context, productImage models.ProductImage) {
+	var count int64
+	server.DB.Model(&models.ProductImage{}).
+		Where("path = ? AND id <> ?", productImage.Path, productImage.ID).
+		Count(&count)
+	if count > 0 {
+		return
+	}
+
+	for _, path := range productImage.Files() {
+		if err := server.Storage.Delete(ctx, path); err != nil {
+			log.Printf("Gagal menghapus file %s: %v", path, err)
+		}
+	}
+}
+
+// discardProductImages menghapus file gambar yang sudah ditulis ke storage tetapi batal
+// disimpan ke database. Context terpisah dipakai agar tetap jalan walau request sudah dibatalkan.
+func (server *Server) discardProductImages(productImages []models.ProductImage) {
+	for _, productImage := range productImages {
+		server.removeProductImageFiles(context.Background(), productImage)
+	}
+}
+
+// backfillImageVariants membuat varian ukuran untuk gambar lama yang kolom
+// ExtraLarge/Large/Medium/Small-nya masih kosong.
+func (server *Server) backfillImageVariants() error {
+	ctx := context.Background()
+
+	var images []models.ProductImage
+	err := server.DB.
+		Where("extra_large = '' OR large = '' OR medium = '' OR small = ''").
+		Or("extra_large IS NULL OR large IS NULL OR medium IS NULL OR small IS NULL").
+		Find(&images).Error
+	if err != nil {
+		return err
+	}
+
+	processedCount := 0
+	for _, productImage := range images {
+		if productImage.Path == "" {
+			continue
+		}
+
+		file, err := server.Storage.Get(ctx, productImage.Path)
+		if err != nil {
+			log.Printf("Lewati gambar %s: %v", productImage.ID, err)
+			continue
+		}
+
+		processed, err := utils.ProcessImage(file)
+		file.Close()
+		if err != nil {
+			log.Printf("Lewati gambar %s: %v", productImage.ID, err)
+			continue
+		}
+
+		// Path asli dipertahankan agar URL lama tetap berlaku
+		if err := server.writeImageVariants(ctx, &productImage, processed); err != nil {
+			return err
+		}
+
+		err = server.DB.Model(&models.ProductImage{}).Where("id = ?", productImage.ID).Updates(map[string]interface{}{
+			"extra_large": productImage.ExtraLarge,
+			"large":       productImage.Large,
+			"medium":      productImage.Medium,
+			"small":       productImage.Small,
+		}).Error
+		if err != nil {
+			return err
+		}
+
+		processedCount++
+	}
+
+	fmt.Printf("%d of %d images processed.\n", processedCount, len(images))
+	return nil
+}
+
+// ReorderProductImages menerima urutan baru dari drag-and-drop di halaman edit produk.
+// Field "image_ids" dikirim berulang sesuai urutan tampilan.
+func (server *Server) ReorderProductImages(w http.ResponseWriter, r *http.Request) {
+	render := newRender()
+	vars := mux.Vars(r)
+
+	if err := r.ParseForm(); err != nil {
+		_ = render.JSON(w, http.StatusBadRequest, map[string]string{"error": "Form tidak valid"})
+		return
+	}
+
+	imageModel := models.ProductImage{}
+	if err := imageModel.Reorder(server.DB, vars["id"], r.Form["image_ids"]); err != nil {
+		_ = render.JSON(w, http.StatusUnprocessableEntity, map[string]string{"error": err.Error()})
+		return
+	}
+
+	_ = render.JSON(w, http.StatusOK, map[string]string{"status": "ok"})
+}
+
+func (server *Server) SetPrimaryProductImage(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	editURL := "/admin/products/edit/" + vars["id"]
+
+	imageModel := models.ProductImage{}
+	productImage, err := imageModel.FindByID(server.DB, vars["id"], vars["image_id"])
+	if err != nil {
+		http.Redirect(w, r, editURL+"?error=Gambar+tidak+ditemukan", http.StatusSeeOther)
+		return
+	}
+
+	if err := productImage.SetPrimary(server.DB); err != nil {
+		http.Redirect(w, r, editURL+"?error="+url.QueryEscape(err.Error()), http.StatusSeeOther)
+		return
+	}
+
+	http.Redirect(w, r, editURL, http.StatusSeeOther)
+}
+
+func (server *Server) UpdateProductImage(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	editURL := "/admin/products/edit/" + vars["id"]
+
+	imageModel := models.ProductImage{}
+	productImage, err := imageModel.FindByID(server.DB, vars["id"], vars["image_id"])
+	if err != nil {
+		http.Redirect(w, r, editURL+"?error=Gambar+tidak+ditemukan", http.StatusSeeOther)
+		return
+	}
+
+	err = server.DB.Model(productImage).Update("alt_text", r.FormValue("alt_text")).Error
+	if err != nil {
+		http.Redirect(w, r, editURL+"?error="+url.QueryEscape(err.Error()), http.StatusSeeOther)
+		return
+	}
+
+	http.Redirect(w, r, editURL, http.StatusSeeOther)
+}
+
+func (server *Server) DeleteProductImage(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	editURL := "/admin/products/edit/" + vars["id"]
+
+	imageModel := models.ProductImage{}
+	productImage, err := imageModel.FindByID(server.DB, vars["id"], vars["image_id"])
+	if err != nil {
+		http.Redirect(w, r, editURL+"?error=Gambar+tidak+ditemukan", http.StatusSeeOther)
+		return
+	}
+
+	if err := productImage.DeleteImage(server.DB); err != nil {
+		http.Redirect(w, r, editURL+"?error="+url.QueryEscape(err.Error()), http.StatusSeeOther)
+		return
+	}
+
+	server.removeProductImageFiles(r.Context(), *productImage)
+
+	http.Redirect(w, r, editURL, http.StatusSeeOther)
+}
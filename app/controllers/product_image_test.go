@@ -0,0 +1,122 @@
+package controllers
+
+import (
+	"bytes"
+	"image"
+	"image/png"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/gieart87/gotoko/app/core/storage"
+	"github.com/gieart87/gotoko/app/models"
+)
+
+func newProductFormRequest(t *testing.T, user *models.User) *http.Request {
+	t.Helper()
+
+	var img bytes.Buffer
+	if err := png.Encode(&img, image.NewRGBA(image.Rect(0, 0, 64, 64))); err != nil {
+		t.Fatal(err)
+	}
+
+	var body bytes.Buffer
+	form := multipart.NewWriter(&body)
+	_ = form.WriteField("name", "Paracetamol 500mg")
+	_ = form.WriteField("price", "10000")
+	_ = form.WriteField("stock", "5")
+	part, err := form.CreateFormFile("images", "paracetamol.png")
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, _ = part.Write(img.Bytes())
+	form.Close()
+
+	r := httptest.NewRequest(http.MethodPost, "/admin/products", &body)
+	r.Header.Set("Content-Type", form.FormDataContentType())
+	for _, cookie := range loginCookies(t, user) {
+		r.AddCookie(cookie)
+	}
+
+	return r
+}
+
+func storedFiles(t *testing.T, root string) []string {
+	t.Helper()
+
+	var files []string
+	_ = filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+		if err == nil && !info.IsDir() {
+			files = append(files, path)
+		}
+		return nil
+	})
+
+	return files
+}
+
+func TestStoreProductKeepsUploadedImages(t *testing.T) {
+	server := newTestServer(t)
+	root := t.TempDir()
+	server.Storage = storage.NewLocalStorage(root, "/uploads")
+	user := createTestUser(t, server.DB, "admin@example.com")
+
+	w := httptest.NewRecorder()
+	server.StoreProduct(w, newProductFormRequest(t, user))
+	if w.Code != http.StatusSeeOther {
+		t.Fatalf("status = %d, want %d: %s", w.Code, http.StatusSeeOther, w.Body.String())
+	}
+
+	if files := storedFiles(t, root); len(files) == 0 {
+		t.Error("uploaded image was not stored")
+	}
+}
+
+func TestStoreProductRemovesImagesWhenTransactionFails(t *testing.T) {
+	server := newTestServer(t)
+	root := t.TempDir()
+	server.Storage = storage.NewLocalStorage(root, "/uploads")
+	user := createTestUser(t, server.DB, "admin@example.com")
+
+	// Outbox AI tidak ada sehingga transaksi simpan produk gagal setelah file ditulis
+	if err := server.DB.Migrator().DropTable(&models.AIOutboxEvent{}); err != nil {
+		t.Fatal(err)
+	}
+
+	w := httptest.NewRecorder()
+	server.StoreProduct(w, newProductFormRequest(t, user))
+	if w.Code != http.StatusInternalServerError {
+		t.Fatalf("status = %d, want %d", w.Code, http.StatusInternalServerError)
+	}
+
+	if files := storedFiles(t, root); len(files) != 0 {
+		t.Errorf("files left in storage after rollback: %v", files)
+	}
+}
+
+func TestSaveUploadedImagesReportsBrokenMultipart(t *testing.T) {
+	server := newTestServer(t)
+	server.Storage = storage.NewLocalStorage(t.TempDir(), "/uploads")
+
+	// Body multipart terpotong sebelum boundary penutup
+	truncated := httptest.NewRequest(http.MethodPost, "/admin/products", strings.NewReader(
+		"--boundary\r\nContent-Disposition: form-data; name=\"images\"; filename=\"a.png\"\r\n\r\n\x89PNG"))
+	truncated.Header.Set("Content-Type", "multipart/form-data; boundary=boundary")
+
+	if _, err := server.saveUploadedImages(truncated, "p1", 0, false, ""); err == nil {
+		t.Error("broken multipart form was accepted without error")
+	}
+
+	// Form biasa tanpa file bukan error
+	plain := httptest.NewRequest(http.MethodPost, "/admin/products", strings.NewReader("name=Paracetamol"))
+	plain.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	productImages, err := server.saveUploadedImages(plain, "p1", 0, false, "")
+	if err != nil || len(productImages) != 0 {
+		t.Errorf("saveUploadedImages(urlencoded) = %v, %v, want no images and no error", productImages, err)
+	}
+}
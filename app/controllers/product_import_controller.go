@@ -0,0 +1,758 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/gieart87/gotoko/app/core/session/auth"
+	"github.com/gieart87/gotoko/app/models"
+	"github.com/gieart87/gotoko/app/utils"
+	"github.com/google/uuid"
+	"github.com/gosimple/slug"
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+)
+
+// Kolom file import/export. Kategori dan gambar dipisah dengan ";".
+// Kategori boleh berupa slug atau nama; gambar boleh berupa URL http(s) atau path file.
+var productImportColumns = []string{"sku", "name", "price", "stock", "weight", "manufacturer", "dosage_form", "categories", "images"}
+
+const importListSeparator = ";"
+
+const importMaxRedirects = 5
+
+var errImportAddressBlocked = errors.New("alamat gambar tidak diizinkan (jaringan internal)")
+
+// importHTTPClient hanya boleh menghubungi alamat publik. Pengecekan dilakukan di dialer
+// setelah DNS di-resolve, sehingga berlaku juga untuk setiap redirect dan tidak bisa
+// diakali dengan DNS rebinding. Proxy sengaja dimatikan agar yang dicek adalah tujuan asli.
+var importHTTPClient = &http.Client{
+	Timeout: 30 * time.Second,
+	Transport: &http.Transport{
+		Proxy: nil,
+		DialContext: (&net.Dialer{
+			Timeout: 10 * time.Second,
+			Control: blockInternalAddress,
+		}).DialContext,
+		TLSHandshakeTimeout:   10 * time.Second,
+		ResponseHeaderTimeout: 15 * time.Second,
+	},
+	CheckRedirect: func(req *http.Request, via []*http.Request) error {
+		if len(via) >= importMaxRedirects {
+			return errors.New("terlalu banyak redirect")
+		}
+		if !isRemoteImage(req.URL.String()) {
+			return errImportAddressBlocked
+		}
+
+		return nil
+	},
+}
+
+// blockInternalAddress menolak koneksi ke loopback, jaringan privat, link-local
+// (termasuk metadata cloud 169.254.169.254), multicast dan alamat kosong.
+func blockInternalAddress(network string, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+
+	ip := net.ParseIP(host)
+	if ip == nil || isInternalIP(ip) {
+		return errImportAddressBlocked
+	}
+
+	return nil
+}
+
+// blockedImportNetworks adalah rentang internal yang tidak dikenali method net.IP:
+// 0.0.0.0/8 ("this network") dan 100.64.0.0/10 (shared address space/CGNAT).
+var blockedImportNetworks = []net.IPNet{
+	{IP: net.IP{0, 0, 0, 0}, Mask: net.CIDRMask(8, 32)},
+	{IP: net.IP{100, 64, 0, 0}, Mask: net.CIDRMask(10, 32)},
+}
+
+func isInternalIP(ip net.IP) bool {
+	if ip.IsLoopback() || ip.IsPrivate() || ip.IsUnspecified() ||
+		ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() || ip.IsInterfaceLocalMulticast() ||
+		ip.IsMulticast() {
+		return true
+	}
+
+	for _, network := range blockedImportNetworks {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+
+	return false
+}
+
+type ProductImportOptions struct {
+	DryRun   bool
+	UserID   string
+	ImageDir string // folder untuk path gambar relatif (dipakai oleh CLI)
+}
+
+type ProductImportRow struct {
+	Line          int
+	Action        string // "create" atau "update"
+	Sku           string
+	Name          string
+	Price         decimal.Decimal
+	Stock         *int
+	Weight        *decimal.Decimal
+	Manufacturer  *string
+	DosageForm    *string
+	Categories    []models.Category
+	HasCategories bool
+	Images        []string
+	Errors        []string
+
+	productID string
+	existing  *models.Product
+	newImages []models.ProductImage
+}
+
+type ProductImportResult struct {
+	DryRun    bool
+	Imported  bool
+	Rows      []ProductImportRow
+	Created   int
+	Updated   int
+	ErrorRows int
+	Errors    []string // error yang tidak terkait baris tertentu, mis. header tidak lengkap
+}
+
+func (result *ProductImportResult) HasErrors() bool {
+	return result.ErrorRows > 0 || len(result.Errors) > 0
+}
+
+// importProducts memvalidasi seluruh baris terlebih dahulu. Import hanya dijalankan
+// jika semua baris valid, sehingga file yang salah tidak menghasilkan data setengah jadi.
+func (server *Server) importProducts(ctx context.Context, rows [][]string, options ProductImportOptions) (*ProductImportResult, error) {
+	result := &ProductImportResult{DryRun: options.DryRun}
+
+	if len(rows) == 0 {
+		result.Errors = append(result.Errors, "file kosong")
+		return result, nil
+	}
+
+	columns := map[string]int{}
+	for i, header := range rows[0] {
+		columns[strings.ToLower(strings.TrimSpace(header))] = i
+	}
+
+	for _, required := range []string{"sku", "name", "price"} {
+		if _, ok := columns[required]; !ok {
+			result.Errors = append(result.Errors, "kolom "+required+" wajib ada")
+		}
+	}
+	if len(result.Errors) > 0 {
+		return result, nil
+	}
+
+	categoriesByKey, err := server.importCategoryLookup()
+	if err != nil {
+		return nil, err
+	}
+
+	seenSku := map[string]int{}
+	for i, record := range rows[1:] {
+		if isBlankRecord(record) {
+			continue
+		}
+
+		row := server.parseImportRow(i+2, record, columns, categoriesByKey)
+		if line, ok := seenSku[strings.ToLower(row.Sku)]; ok && row.Sku != "" {
+			row.Errors = append(row.Errors, fmt.Sprintf("SKU duplikat dengan baris %d", line))
+		}
+		seenSku[strings.ToLower(row.Sku)] = row.Line
+
+		result.Rows = append(result.Rows, row)
+	}
+
+	result.countRows()
+	if options.DryRun || result.HasErrors() {
+		return result, nil
+	}
+
+	if options.UserID == "" {
+		result.Errors = append(result.Errors, "user pemilik produk tidak diketahui")
+		return result, nil
+	}
+
+	// Gambar diproses sebelum transaksi database; jika ada yang gagal, import dibatalkan
+	if !server.prepareImportImages(ctx, result, options) {
+		server.discardImportImages(ctx, result)
+		result.countRows()
+		return result, nil
+	}
+
+	err = server.DB.Transaction(func(tx *gorm.DB) error {
+		productIDs := make([]string, 0, len(result.Rows))
+		for i := range result.Rows {
+			if err := saveImportRow(tx, &result.Rows[i], options.UserID); err != nil {
+				return fmt.Errorf("baris %d: %w", result.Rows[i].Line, err)
+			}
+			productIDs = append(productIDs, result.Rows[i].productID)
+		}
+
+		productModel := models.Product{}
+		if err := productModel.RefreshSearchCategories(tx, productIDs...); err != nil {
+			return err
+		}
+
+		return enqueueAIUpserts(tx, productIDs...)
+	})
+	if err != nil {
+		server.discardImportImages(ctx, result)
+		return nil, err
+	}
+
+	productIDs := make([]string, len(result.Rows))
+	for i, row := range result.Rows {
+		productIDs[i] = row.productID
+	}
+	server.indexProducts(productIDs...)
+
+	result.Imported = true
+	return result, nil
+}
+
+func (result *ProductImportResult) countRows() {
+	result.Created, result.Updated, result.ErrorRows = 0, 0, 0
+	for _, row := range result.Rows {
+		switch {
+		case len(row.Errors) > 0:
+			result.ErrorRows++
+		case row.Action == "create":
+			result.Created++
+		default:
+			result.Updated++
+		}
+	}
+}
+
+func isBlankRecord(record []string) bool {
+	for _, value := range record {
+		if strings.TrimSpace(value) != "" {
+			return false
+		}
+	}
+
+	return true
+}
+
+// importCategoryLookup memetakan slug dan nama (lowercase) kategori ke datanya.
+func (server *Server) importCategoryLookup() (map[string]models.Category, error) {
+	var categories []models.Category
+	if err := server.DB.Find(&categories).Error; err != nil {
+		return nil, err
+	}
+
+	lookup := make(map[string]models.Category, len(categories)*2)
+	for _, category := range categories {
+		lookup[strings.ToLower(category.Name)] = category
+		lookup[strings.ToLower(category.Slug)] = category
+	}
+
+	return lookup, nil
+}
+
+func (server *Server) parseImportRow(line int, record []string, columns map[string]int, categoriesByKey map[string]models.Category) ProductImportRow {
+	value := func(column string) (string, bool) {
+		index, ok := columns[column]
+		if !ok || index >= len(record) {
+			return "", false
+		}
+		return strings.TrimSpace(record[index]), true
+	}
+
+	row := ProductImportRow{Line: line, Action: "create"}
+
+	row.Sku, _ = value("sku")
+	if row.Sku == "" {
+		row.Errors = append(row.Errors, "SKU wajib diisi")
+	}
+
+	row.Name, _ = value("name")
+	if row.Name == "" {
+		row.Errors = append(row.Errors, "nama wajib diisi")
+	}
+
+	priceStr, _ := value("price")
+	price, err := decimal.NewFromString(priceStr)
+	if err != nil || price.IsNegative() {
+		row.Errors = append(row.Errors, "harga tidak valid: "+priceStr)
+	}
+	row.Price = price
+
+	if stockStr, ok := value("stock"); ok && stockStr != "" {
+		stock, err := strconv.Atoi(stockStr)
+		if err != nil || stock < 0 {
+			row.Errors = append(row.Errors, "stok tidak valid: "+stockStr)
+		}
+		row.Stock = &stock
+	}
+
+	if weightStr, ok := value("weight"); ok && weightStr != "" {
+		weight, err := decimal.NewFromString(weightStr)
+		if err != nil || weight.IsNegative() {
+			row.Errors = append(row.Errors, "berat tidak valid: "+weightStr)
+		}
+		row.Weight = &weight
+	}
+
+	if manufacturer, ok := value("manufacturer"); ok && manufacturer != "" {
+		row.Manufacturer = &manufacturer
+	}
+
+	if dosageForm, ok := value("dosage_form"); ok && dosageForm != "" {
+		dosageForm = strings.ToLower(dosageForm)
+		row.DosageForm = &dosageForm
+	}
+
+	if categoriesStr, ok := value("categories"); ok && categoriesStr != "" {
+		row.HasCategories = true
+		for _, key := range splitImportList(categoriesStr) {
+			category, found := categoriesByKey[strings.ToLower(key)]
+			if !found {
+				row.Errors = append(row.Errors, "kategori tidak ditemukan: "+key)
+				continue
+			}
+			row.Categories = append(row.Categories, category)
+		}
+	}
+
+	if imagesStr, ok := value("images"); ok && imagesStr != "" {
+		for _, source := range splitImportList(imagesStr) {
+			if isRemoteImage(source) {
+				if _, err := url.ParseRequestURI(source); err != nil {
+					row.Errors = append(row.Errors, "URL gambar tidak valid: "+source)
+					continue
+				}
+			}
+			row.Images = append(row.Images, source)
+		}
+	}
+
+	if row.Sku != "" {
+		productModel := models.Product{}
+		existing, err := productModel.FindBySku(server.DB, row.Sku)
+		if err == nil {
+			row.Action = "update"
+			row.existing = existing
+			row.productID = existing.ID
+		} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+			row.Errors = append(row.Errors, "gagal memeriksa SKU: "+err.Error())
+		}
+	}
+
+	if row.productID == "" {
+		row.productID = uuid.New().String()
+	}
+
+	return row
+}
+
+func splitImportList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, importListSeparator) {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+
+	return items
+}
+
+func isRemoteImage(source string) bool {
+	return strings.HasPrefix(source, "http://") || strings.HasPrefix(source, "https://")
+}
+
+func (server *Server) prepareImportImages(ctx context.Context, result *ProductImportResult, options ProductImportOptions) bool {
+	ok := true
+
+	for i := range result.Rows {
+		row := &result.Rows[i]
+
+		existingPaths := map[string]bool{}
+		position := 0
+		hasPrimary := false
+		if row.existing != nil {
+			for _, image := range row.existing.ProductImages {
+				existingPaths[image.Path] = true
+				if image.Position >= position {
+					position = image.Position + 1
+				}
+			}
+			hasPrimary = row.existing.PrimaryImage() != nil
+		}
+
+		for _, source := range row.Images {
+			// Path hasil export yang sudah terpasang di produk ini tidak perlu diproses lagi
+			if !isRemoteImage(source) && existingPaths[strings.TrimPrefix(source, "/")] {
+				continue
+			}
+
+			productImage, err := server.importImage(ctx, source, row.productID, options.ImageDir)
+			if err != nil {
+				row.Errors = append(row.Errors, fmt.Sprintf("gambar %s: %v", source, err))
+				ok = false
+				continue
+			}
+
+			// Gambar yang sama (hash sama) tidak ditambahkan dua kali saat update
+			if existingPaths[productImage.Path] {
+				continue
+			}
+			existingPaths[productImage.Path] = true
+
+			productImage.Position = position
+			productImage.IsPrimary = !hasPrimary
+			productImage.AltText = row.Name
+			position++
+			hasPrimary = true
+
+			row.newImages = append(row.newImages, *productImage)
+		}
+	}
+
+	return ok
+}
+
+func (server *Server) importImage(ctx context.Context, source string, productID string, imageDir string) (*models.ProductImage, error) {
+	var reader io.ReadCloser
+
+	if isRemoteImage(source) {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, source, nil)
+		if err != nil {
+			return nil, err
+		}
+
+		resp, err := importHTTPClient.Do(req)
+		if err != nil {
+			return nil, err
+		}
+
+		if resp.StatusCode != http.StatusOK {
+			resp.Body.Close()
+			return nil, fmt.Errorf("HTTP %d", resp.StatusCode)
+		}
+		if resp.ContentLength > utils.MaxImageSize {
+			resp.Body.Close()
+			return nil, utils.ErrImageTooLarge
+		}
+		// Body dibatasi di sini juga, tidak hanya di ProcessImage
+		reader = struct {
+			io.Reader
+			io.Closer
+		}{io.LimitReader(resp.Body, utils.MaxImageSize+1), resp.Body}
+	} else {
+		// Path hasil export menunjuk ke file yang sudah diproses: file dan variannya dipakai ulang.
+		// Memproses ulang akan meng-encode ulang gambar sehingga hash dan path-nya berubah.
+		productImageModel := models.ProductImage{}
+		if stored, err := productImageModel.FindByPath(server.DB, strings.TrimPrefix(source, "/")); err == nil {
+			return &models.ProductImage{
+				ID:         uuid.New().String(),
+				ProductID:  productID,
+				Path:       stored.Path,
+				ExtraLarge: stored.ExtraLarge,
+				Large:      stored.Large,
+				Medium:     stored.Medium,
+				Small:      stored.Small,
+			}, nil
+		}
+
+		// Path dicari di storage terlebih dahulu (mis. "uploads/abc.jpg"), lalu di imageDir
+		file, err := server.Storage.Get(ctx, source)
+		if err != nil && imageDir != "" {
+			file, err = os.Open(filepath.Join(imageDir, filepath.Clean("/"+source)))
+		}
+		if err != nil {
+			return nil, errors.New("file tidak ditemukan")
+		}
+		reader = file
+	}
+	defer reader.Close()
+
+	return server.saveProductImage(ctx, reader, productID)
+}
+
+// discardImportImages menghapus file gambar yang sudah terlanjur disimpan ketika import dibatalkan.
+func (server *Server) discardImportImages(ctx context.Context, result *ProductImportResult) {
+	for i := range result.Rows {
+		for _, productImage := range result.Rows[i].newImages {
+			server.removeProductImageFiles(ctx, productImage)
+		}
+		result.Rows[i].newImages = nil
+	}
+}
+
+func saveImportRow(tx *gorm.DB, row *ProductImportRow, userID string) error {
+	if row.existing == nil {
+		product := models.Product{
+			ID:            row.productID,
+			UserID:        userID,
+			Sku:           row.Sku,
+			Name:          row.Name,
+			Slug:          slug.Make(row.Name),
+			Price:         row.Price,
+			Status:        1,
+			Categories:    row.Categories,
+			ProductImages: row.newImages,
+			CreatedAt:     time.Now(),
+			UpdatedAt:     time.Now(),
+		}
+		if row.Stock != nil {
+			product.Stock = *row.Stock
+		}
+		if row.Weight != nil {
+			product.Weight = *row.Weight
+		}
+		if row.Manufacturer != nil {
+			product.Manufacturer = *row.Manufacturer
+		}
+		if row.DosageForm != nil {
+			product.DosageForm = *row.DosageForm
+		}
+
+		return tx.Create(&product).Error
+	}
+
+	// Kolom opsional yang kosong tidak mengubah data produk yang sudah ada
+	updates := map[string]interface{}{
+		"name":       row.Name,
+		"slug":       slug.Make(row.Name),
+		"price":      row.Price,
+		"updated_at": time.Now(),
+	}
+	if row.Stock != nil {
+		updates["stock"] = *row.Stock
+	}
+	if row.Weight != nil {
+		updates["weight"] = *row.Weight
+	}
+	if row.Manufacturer != nil {
+		updates["manufacturer"] = *row.Manufacturer
+	}
+	if row.DosageForm != nil {
+		updates["dosage_form"] = *row.DosageForm
+	}
+
+	if err := tx.Model(&models.Product{}).Where("id = ?", row.existing.ID).Updates(updates).Error; err != nil {
+		return err
+	}
+
+	if row.HasCategories {
+		if err := tx.Model(row.existing).Association("Categories").Replace(row.Categories); err != nil {
+			return err
+		}
+	}
+
+	for i := range row.newImages {
+		if err := tx.Create(&row.newImages[i]).Error; err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// productExportRows menghasilkan baris dengan kolom yang sama seperti file import,
+// sehingga hasil export bisa langsung di-import kembali.
+func (server *Server) productExportRows() ([][]string, error) {
+	var products []models.Product
+	err := server.DB.
+		Preload("Categories").
+		Preload("ProductImages", models.OrderProductImages).
+		Order("created_at desc").
+		Find(&products).Error
+	if err != nil {
+		return nil, err
+	}
+
+	rows := [][]string{productImportColumns}
+	for _, product := range products {
+		var categories, images []string
+		for _, category := range product.Categories {
+			categories = append(categories, category.Slug)
+		}
+		for _, image := range product.ProductImages {
+			images = append(images, image.Path)
+		}
+
+		rows = append(rows, []string{
+			product.Sku,
+			product.Name,
+			product.Price.String(),
+			strconv.Itoa(product.Stock),
+			product.Weight.String(),
+			product.Manufacturer,
+			product.DosageForm,
+			strings.Join(categories, importListSeparator),
+			strings.Join(images, importListSeparator),
+		})
+	}
+
+	return rows, nil
+}
+
+func (server *Server) ImportProductsPage(w http.ResponseWriter, r *http.Request) {
+	_ = adminRender().HTML(w, http.StatusOK, "pages/admin_product_import", map[string]interface{}{
+		"user":    auth.CurrentUser(server.DB, w, r),
+		"columns": productImportColumns,
+	})
+}
+
+func (server *Server) ImportProducts(w http.ResponseWriter, r *http.Request) {
+	user := auth.CurrentUser(server.DB, w, r)
+	if user == nil {
+		http.Redirect(w, r, "/login", http.StatusSeeOther)
+		return
+	}
+
+	renderPage := func(status int, data map[string]interface{}) {
+		data["user"] = user
+		data["columns"] = productImportColumns
+		_ = adminRender().HTML(w, status, "pages/admin_product_import", data)
+	}
+
+	file, header, err := r.FormFile("file")
+	if err != nil {
+		renderPage(http.StatusBadRequest, map[string]interface{}{"Error": "File wajib di-upload"})
+		return
+	}
+	defer file.Close()
+
+	format, err := utils.SpreadsheetFormat(header.Filename)
+	if err != nil {
+		renderPage(http.StatusBadRequest, map[string]interface{}{"Error": err.Error()})
+		return
+	}
+
+	rows, err := utils.ReadSpreadsheet(file, format)
+	if err != nil {
+		renderPage(http.StatusBadRequest, map[string]interface{}{"Error": "Gagal membaca file: " + err.Error()})
+		return
+	}
+
+	result, err := server.importProducts(r.Context(), rows, ProductImportOptions{
+		DryRun: r.FormValue("dry_run") != "",
+		UserID: user.ID,
+	})
+	if err != nil {
+		log.Printf("Import produk gagal: %v", err)
+		renderPage(http.StatusInternalServerError, map[string]interface{}{"Error": "Import gagal: " + err.Error()})
+		return
+	}
+
+	status := http.StatusOK
+	if result.HasErrors() {
+		status = http.StatusUnprocessableEntity
+	}
+
+	renderPage(status, map[string]interface{}{"result": result})
+}
+
+func (server *Server) ExportProducts(w http.ResponseWriter, r *http.Request) {
+	format := r.URL.Query().Get("format")
+	if format == "" {
+		format = utils.FormatCSV
+	}
+	if format != utils.FormatCSV && format != utils.FormatXLSX {
+		http.Error(w, utils.ErrUnsupportedFormat.Error(), http.StatusBadRequest)
+		return
+	}
+
+	rows, err := server.productExportRows()
+	if err != nil {
+		http.Error(w, "Gagal export produk: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	filename := fmt.Sprintf("products-%s.%s", time.Now().Format("20060102-150405"), format)
+	w.Header().Set("Content-Type", utils.SpreadsheetContentType(format))
+	w.Header().Set("Content-Disposition", `attachment; filename="`+filename+`"`)
+
+	if err := utils.WriteSpreadsheet(w, format, "Products", rows); err != nil {
+		log.Printf("Gagal menulis export produk: %v", err)
+	}
+}
+
+// importProductsFromFile dipakai oleh command products:import.
+func (server *Server) importProductsFromFile(path string, userEmail string, dryRun bool) error {
+	format, err := utils.SpreadsheetFormat(path)
+	if err != nil {
+		return err
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	rows, err := utils.ReadSpreadsheet(file, format)
+	if err != nil {
+		return err
+	}
+
+	options := ProductImportOptions{
+		DryRun:   dryRun,
+		ImageDir: filepath.Dir(path),
+	}
+
+	if !dryRun {
+		if userEmail == "" {
+			return errors.New("--user is required unless --dry-run is set")
+		}
+
+		userModel := models.User{}
+		user, err := userModel.FindByEmail(server.DB, userEmail)
+		if err != nil {
+			return fmt.Errorf("user %s not found", userEmail)
+		}
+		options.UserID = user.ID
+	}
+
+	result, err := server.importProducts(context.Background(), rows, options)
+	if err != nil {
+		return err
+	}
+
+	for _, message := range result.Errors {
+		fmt.Println("error:", message)
+	}
+	for _, row := range result.Rows {
+		if len(row.Errors) > 0 {
+			fmt.Printf("line %d (%s): %s\n", row.Line, row.Sku, strings.Join(row.Errors, "; "))
+			continue
+		}
+		fmt.Printf("line %d (%s): %s\n", row.Line, row.Sku, row.Action)
+	}
+
+	fmt.Printf("%d to create, %d to update, %d with errors.\n", result.Created, result.Updated, result.ErrorRows)
+
+	if result.HasErrors() {
+		return errors.New("import aborted, no products were changed")
+	}
+	if result.Imported {
+		fmt.Println("Products imported successfully.")
+	}
+
+	return nil
+}
@@ -0,0 +1,114 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gieart87/gotoko/app/models"
+	"github.com/shopspring/decimal"
+)
+
+func TestIsInternalIP(t *testing.T) {
+	tests := []struct {
+		ip   string
+		want bool
+	}{
+		{"127.0.0.1", true},
+		{"::1", true},
+		{"10.1.2.3", true},
+		{"172.16.0.1", true},
+		{"192.168.1.1", true},
+		{"169.254.169.254", true},
+		{"fe80::1", true},
+		{"fd00::1", true},
+		{"0.0.0.0", true},
+		{"::ffff:127.0.0.1", true},
+		{"0.1.2.3", true},
+		{"100.64.0.1", true},
+		{"100.127.255.254", true},
+		{"::ffff:100.100.100.200", true},
+		{"100.63.255.255", false},
+		{"100.128.0.1", false},
+		{"8.8.8.8", false},
+		{"2606:4700:4700::1111", false},
+	}
+
+	for _, tt := range tests {
+		if got := isInternalIP(net.ParseIP(tt.ip)); got != tt.want {
+			t.Errorf("isInternalIP(%s) = %v, want %v", tt.ip, got, tt.want)
+		}
+	}
+}
+
+func TestImportImageBlocksInternalAddresses(t *testing.T) {
+	server := newTestServer(t)
+
+	internal := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Error("internal server must not be reached")
+	}))
+	defer internal.Close()
+
+	_, err := server.importImage(context.Background(), internal.URL+"/image.png", "product-1", "")
+	if !errors.Is(err, errImportAddressBlocked) {
+		t.Fatalf("err = %v, want %v", err, errImportAddressBlocked)
+	}
+}
+
+func TestExportThenImportDoesNotDuplicateImages(t *testing.T) {
+	server := newTestServer(t)
+	user := createTestUser(t, server.DB, "admin@example.com")
+
+	product := &models.Product{
+		ID:     "product-1",
+		UserID: user.ID,
+		Sku:    "SKU-1",
+		Name:   "Paracetamol 500mg",
+		Slug:   "paracetamol-500mg",
+		Price:  decimal.NewFromInt(10000),
+		ProductImages: []models.ProductImage{{
+			ID:        "image-1",
+			Path:      "uploads/abc.jpg",
+			Small:     "uploads/abc-small.jpg",
+			IsPrimary: true,
+		}},
+	}
+	if err := server.DB.Create(product).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	rows, err := server.productExportRows()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Baris kedua memakai gambar yang sama untuk produk baru
+	copied := append([]string(nil), rows[1]...)
+	copied[0], copied[1] = "SKU-2", "Paracetamol 500mg Strip"
+	rows = append(rows, copied)
+
+	result, err := server.importProducts(context.Background(), rows, ProductImportOptions{UserID: user.ID})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.HasErrors() || !result.Imported {
+		t.Fatalf("import failed: %+v", result)
+	}
+
+	var count int64
+	server.DB.Model(&models.ProductImage{}).Where("product_id = ?", product.ID).Count(&count)
+	if count != 1 {
+		t.Errorf("images of re-imported product = %d, want 1", count)
+	}
+
+	var created models.Product
+	if err := server.DB.Preload("ProductImages").Where("sku = ?", "SKU-2").First(&created).Error; err != nil {
+		t.Fatal(err)
+	}
+	if len(created.ProductImages) != 1 || created.ProductImages[0].Path != "uploads/abc.jpg" || created.ProductImages[0].Small != "uploads/abc-small.jpg" {
+		t.Errorf("new product images = %+v, want the stored files reused", created.ProductImages)
+	}
+}
@@ -0,0 +1,300 @@
+package controllers
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gieart87/gotoko/app/core/session/auth"
+	"github.com/gieart87/gotoko/app/models"
+	"github.com/gieart87/gotoko/app/utils"
+	"github.com/shopspring/decimal"
+)
+
+const defaultReportDir = "storage/reports"
+
+// reportDefinition adalah satu laporan keuangan yang bisa di-export sebagai CSV/XLSX,
+// baik dari halaman admin maupun command reports:generate. NumericColumns ditulis sebagai
+// sel angka di XLSX.
+type reportDefinition struct {
+	Name           string
+	Title          string
+	Sheet          string
+	NumericColumns []int
+	Rows           func(server *Server, salesRange models.SalesRange) ([][]string, error)
+}
+
+var reportDefinitions = []reportDefinition{
+	{Name: "sales-daily", Title: "Penjualan per hari (tanggal pembayaran)", Sheet: "Sales", NumericColumns: []int{1, 2, 3, 4, 5, 6}, Rows: (*Server).salesDailyRows},
+	{Name: "tax", Title: "Pajak terkumpul per order (tanggal pembayaran)", Sheet: "Tax", NumericColumns: []int{2, 3, 4, 5, 6}, Rows: (*Server).taxRows},
+	{Name: "payments", Title: "Pembayaran per metode (tanggal pembayaran)", Sheet: "Payments", NumericColumns: []int{1, 2}, Rows: (*Server).paymentRows},
+	{Name: "stock-valuation", Title: "Nilai stok (posisi saat ini)", Sheet: "Stock", NumericColumns: []int{2, 3, 4}, Rows: (*Server).stockValuationRows},
+}
+
+func findReportDefinition(name string) (reportDefinition, bool) {
+	for _, definition := range reportDefinitions {
+		if definition.Name == name {
+			return definition, true
+		}
+	}
+
+	return reportDefinition{}, false
+}
+
+func (server *Server) salesDailyRows(salesRange models.SalesRange) ([][]string, error) {
+	reportModel := models.SalesReport{}
+	days, err := reportModel.DailySales(server.DB, salesRange)
+	if err != nil {
+		return nil, err
+	}
+
+	rows := [][]string{{"paid_date", "orders", "base_total", "discount", "tax", "shipping", "grand_total"}}
+	var orders int64
+	baseTotal, discount, tax, shipping, grandTotal := decimal.Zero, decimal.Zero, decimal.Zero, decimal.Zero, decimal.Zero
+	for _, day := range days {
+		rows = append(rows, []string{
+			day.Date,
+			strconv.FormatInt(day.Orders, 10),
+			day.BaseTotal.StringFixed(2),
+			day.DiscountAmount.StringFixed(2),
+			day.TaxAmount.StringFixed(2),
+			day.ShippingCost.StringFixed(2),
+			day.GrandTotal.StringFixed(2),
+		})
+
+		orders += day.Orders
+		baseTotal = baseTotal.Add(day.BaseTotal)
+		discount = discount.Add(day.DiscountAmount)
+		tax = tax.Add(day.TaxAmount)
+		shipping = shipping.Add(day.ShippingCost)
+		grandTotal = grandTotal.Add(day.GrandTotal)
+	}
+
+	rows = append(rows, []string{
+		"TOTAL",
+		strconv.FormatInt(orders, 10),
+		baseTotal.StringFixed(2),
+		discount.StringFixed(2),
+		tax.StringFixed(2),
+		shipping.StringFixed(2),
+		grandTotal.StringFixed(2),
+	})
+
+	return rows, nil
+}
+
+func (server *Server) taxRows(salesRange models.SalesRange) ([][]string, error) {
+	reportModel := models.SalesReport{}
+	orders, err := reportModel.TaxedOrders(server.DB, salesRange)
+	if err != nil {
+		return nil, err
+	}
+
+	rows := [][]string{{"paid_date", "order_code", "base_total", "discount", "tax_percent", "tax_amount", "grand_total"}}
+	tax := decimal.Zero
+	for _, order := range orders {
+		rows = append(rows, []string{
+			order.PaidAt.Time.Format("2006-01-02"),
+			order.Code,
+			order.BaseTotalPrice.StringFixed(2),
+			order.DiscountAmount.StringFixed(2),
+			order.TaxPercent.StringFixed(2),
+			order.TaxAmount.StringFixed(2),
+			order.GrandTotal.StringFixed(2),
+		})
+		tax = tax.Add(order.TaxAmount)
+	}
+
+	rows = append(rows, []string{"TOTAL", "", "", "", "", tax.StringFixed(2), ""})
+
+	return rows, nil
+}
+
+func (server *Server) paymentRows(salesRange models.SalesRange) ([][]string, error) {
+	reportModel := models.SalesReport{}
+	payments, err := reportModel.PaymentsByType(server.DB, salesRange)
+	if err != nil {
+		return nil, err
+	}
+
+	rows := [][]string{{"payment_type", "payments", "amount"}}
+	var count int64
+	amount := decimal.Zero
+	for _, payment := range payments {
+		rows = append(rows, []string{
+			payment.PaymentType,
+			strconv.FormatInt(payment.Payments, 10),
+			payment.Amount.StringFixed(2),
+		})
+		count += payment.Payments
+		amount = amount.Add(payment.Amount)
+	}
+
+	rows = append(rows, []string{"TOTAL", strconv.FormatInt(count, 10), amount.StringFixed(2)})
+
+	return rows, nil
+}
+
+// stockValuationRows tidak bergantung pada rentang tanggal: selalu posisi stok saat ini.
+func (server *Server) stockValuationRows(_ models.SalesRange) ([][]string, error) {
+	reportModel := models.SalesReport{}
+	products, err := reportModel.StockValuation(server.DB)
+	if err != nil {
+		return nil, err
+	}
+
+	rows := [][]string{{"sku", "name", "stock", "price", "value"}}
+	total := decimal.Zero
+	for _, product := range products {
+		value := product.Price.Mul(decimal.NewFromInt(int64(product.Stock)))
+		rows = append(rows, []string{
+			product.Sku,
+			product.Name,
+			strconv.Itoa(product.Stock),
+			product.Price.StringFixed(2),
+			value.StringFixed(2),
+		})
+		total = total.Add(value)
+	}
+
+	rows = append(rows, []string{"TOTAL", "", "", "", total.StringFixed(2)})
+
+	return rows, nil
+}
+
+func reportFilename(name string, from string, to string, format string) string {
+	return fmt.Sprintf("%s_%s_%s.%s", name, from, to, format)
+}
+
+// AdminReports menampilkan daftar laporan yang bisa di-export.
+func (server *Server) AdminReports(w http.ResponseWriter, r *http.Request) {
+	_, from, to := salesRangeFromQuery(r.URL.Query())
+
+	_ = adminRender().HTML(w, http.StatusOK, "pages/admin_reports", map[string]interface{}{
+		"user":    auth.CurrentUser(server.DB, w, r),
+		"reports": reportDefinitions,
+		"from":    from,
+		"to":      to,
+	})
+}
+
+// ExportReport mengunduh satu laporan: ?report=sales-daily&format=csv|xlsx&from=&to=
+func (server *Server) ExportReport(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+
+	definition, ok := findReportDefinition(q.Get("report"))
+	if !ok {
+		http.Error(w, "Laporan tidak ditemukan", http.StatusNotFound)
+		return
+	}
+
+	format := q.Get("format")
+	if format == "" {
+		format = utils.FormatCSV
+	}
+	if format != utils.FormatCSV && format != utils.FormatXLSX {
+		http.Error(w, utils.ErrUnsupportedFormat.Error(), http.StatusBadRequest)
+		return
+	}
+
+	salesRange, from, to := salesRangeFromQuery(q)
+	rows, err := definition.Rows(server, salesRange)
+	if err != nil {
+		http.Error(w, "Gagal membuat laporan: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", utils.SpreadsheetContentType(format))
+	w.Header().Set("Content-Disposition", `attachment; filename="`+reportFilename(definition.Name, from, to, format)+`"`)
+
+	if err := utils.WriteSpreadsheet(w, format, definition.Sheet, rows, definition.NumericColumns...); err != nil {
+		log.Printf("Gagal menulis laporan %s: %v", definition.Name, err)
+	}
+}
+
+// generateReports dipakai oleh command reports:generate. names kosong berarti semua laporan;
+// from/to kosong berarti kemarin, sehingga command bisa dijadwalkan harian lewat cron.
+func (server *Server) generateReports(names []string, from string, to string, format string, dir string) error {
+	if format != utils.FormatCSV && format != utils.FormatXLSX {
+		return utils.ErrUnsupportedFormat
+	}
+
+	yesterday := time.Now().AddDate(0, 0, -1).Format(salesDateLayout)
+	if from == "" {
+		from = yesterday
+	}
+	if to == "" {
+		to = from
+	}
+	fromDate, err := time.Parse(salesDateLayout, from)
+	if err != nil {
+		return fmt.Errorf("invalid --from %q, expected YYYY-MM-DD", from)
+	}
+	toDate, err := time.Parse(salesDateLayout, to)
+	if err != nil {
+		return fmt.Errorf("invalid --to %q, expected YYYY-MM-DD", to)
+	}
+	if fromDate.After(toDate) {
+		return fmt.Errorf("--from must not be after --to")
+	}
+	if toDate.Sub(fromDate) > salesReportMaxDays*24*time.Hour {
+		return fmt.Errorf("period is limited to %d days", salesReportMaxDays)
+	}
+
+	salesRange, from, to := salesRangeFromQuery(url.Values{"from": {from}, "to": {to}})
+
+	definitions := reportDefinitions
+	if len(names) > 0 {
+		definitions = nil
+		for _, name := range names {
+			definition, ok := findReportDefinition(strings.TrimSpace(name))
+			if !ok {
+				return fmt.Errorf("unknown report %q", name)
+			}
+			definitions = append(definitions, definition)
+		}
+	}
+
+	if dir == "" {
+		dir = defaultReportDir
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+
+	for _, definition := range definitions {
+		rows, err := definition.Rows(server, salesRange)
+		if err != nil {
+			return fmt.Errorf("%s: %w", definition.Name, err)
+		}
+
+		path := filepath.Join(dir, reportFilename(definition.Name, from, to, format))
+		if err := writeReportFile(path, format, definition, rows); err != nil {
+			return fmt.Errorf("%s: %w", definition.Name, err)
+		}
+
+		fmt.Printf("%s written.\n", path)
+	}
+
+	return nil
+}
+
+func writeReportFile(path string, format string, definition reportDefinition, rows [][]string) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+
+	if err := utils.WriteSpreadsheet(file, format, definition.Sheet, rows, definition.NumericColumns...); err != nil {
+		file.Close()
+		return err
+	}
+
+	return file.Close()
+}
@@ -0,0 +1,109 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/gieart87/gotoko/app/consts"
+	"github.com/gieart87/gotoko/app/core/session/auth"
+	"github.com/gieart87/gotoko/app/models"
+	"github.com/gorilla/mux"
+)
+
+// AdminUserRoles menampilkan daftar user beserta pilihan role-nya, dengan pencarian dan
+// paginasi yang sama seperti halaman daftar user.
+func (server *Server) AdminUserRoles(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+
+	page, _ := strconv.Atoi(q.Get("page"))
+	if page <= 0 {
+		page = 1
+	}
+
+	filter := models.UserFilter{
+		Query:  q.Get("q"),
+		RoleID: q.Get("role"),
+		Status: q.Get("status"),
+	}
+
+	userModel := models.User{}
+	users, totalRows, err := userModel.SearchUsers(server.DB, filter, adminUsersPerPage, page)
+	if err != nil {
+		http.Error(w, "Gagal memuat user", http.StatusInternalServerError)
+		return
+	}
+
+	roleModel := models.Role{}
+	roles, err := roleModel.GetRoles(server.DB)
+	if err != nil {
+		http.Error(w, "Gagal memuat role", http.StatusInternalServerError)
+		return
+	}
+
+	pagination, _ := GetPaginationLinks(server.AppConfig, PaginationParams{
+		Path:        "admin/users/roles",
+		Query:       q,
+		TotalRows:   int32(totalRows),
+		PerPage:     int32(adminUsersPerPage),
+		CurrentPage: int32(page),
+	})
+
+	_ = adminRender().HTML(w, http.StatusOK, "pages/admin_user_roles", map[string]interface{}{
+		"user":       auth.CurrentUser(server.DB, w, r),
+		"users":      users,
+		"roles":      roles,
+		"filter":     filter,
+		"total":      totalRows,
+		"pagination": pagination,
+		"Message":    q.Get("message"),
+		"Error":      q.Get("error"),
+	})
+}
+
+func (server *Server) AssignUserRole(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+
+	// Form role juga ada di halaman detail user (?from=detail), kembali ke halaman asal
+	back := "/admin/users/roles"
+	if r.FormValue("from") == "detail" {
+		back = "/admin/users/" + url.PathEscape(vars["id"])
+	}
+
+	userModel := models.User{}
+	target, err := userModel.FindByID(server.DB, vars["id"])
+	if err != nil {
+		http.Redirect(w, r, back+"?error=User+tidak+ditemukan", http.StatusSeeOther)
+		return
+	}
+
+	roleModel := models.Role{}
+	role, err := roleModel.FindByID(server.DB, r.FormValue("role_id"))
+	if err != nil {
+		http.Redirect(w, r, back+"?error=Role+tidak+ditemukan", http.StatusSeeOther)
+		return
+	}
+
+	if err := server.checkRoleChange(auth.CurrentUser(server.DB, w, r), target, role); err != nil {
+		http.Redirect(w, r, back+"?error="+url.QueryEscape(err.Error()), http.StatusSeeOther)
+		return
+	}
+
+	if err := target.AssignRole(server.DB, role.ID); err != nil {
+		http.Redirect(w, r, back+"?error="+url.QueryEscape(err.Error()), http.StatusSeeOther)
+		return
+	}
+
+	http.Redirect(w, r, back+"?message=Role+berhasil+diperbarui", http.StatusSeeOther)
+}
+
+// checkRoleChange mencegah admin mencabut akses pengaturan role miliknya sendiri,
+// agar selalu ada user yang bisa mengatur role.
+func (server *Server) checkRoleChange(current *models.User, target *models.User, role *models.Role) error {
+	if current != nil && current.ID == target.ID && !role.HasPermission(consts.PermissionRoleManage) {
+		return errors.New("tidak bisa mencabut akses pengaturan role milik sendiri")
+	}
+
+	return nil
+}
@@ -10,38 +10,124 @@ import (
 
 func (server *Server) initializeRoutes() {
 	server.Router = mux.NewRouter()
+
+	// Semua request POST wajib membawa token CSRF dari sesi, kecuali webhook Midtrans
+	// yang dipanggil langsung oleh server Midtrans
+	server.Router.Use(func(next http.Handler) http.Handler {
+		return middlewares.CSRFMiddleware(next, "/payments/midtrans")
+	})
 	server.Router.HandleFunc("/", server.Home).Methods("GET")
 
 	server.Router.HandleFunc("/login", server.Login).Methods("GET")
 	server.Router.HandleFunc("/login", server.DoLogin).Methods("POST")
+	server.Router.HandleFunc("/login/2fa", server.TwoFactorChallenge).Methods("GET")
+	server.Router.HandleFunc("/login/2fa", server.DoTwoFactorChallenge).Methods("POST")
+	server.Router.HandleFunc("/auth/{provider}", server.SSOLogin).Methods("GET")
+	server.Router.HandleFunc("/auth/{provider}/callback", server.SSOCallback).Methods("GET")
 	server.Router.HandleFunc("/register", server.Register).Methods("GET")
 	server.Router.HandleFunc("/register", server.DoRegister).Methods("POST")
-	server.Router.HandleFunc("/logout", server.Logout).Methods("GET")
+	server.Router.HandleFunc("/logout", server.Logout).Methods("POST")
+	server.Router.HandleFunc("/email/verification", server.EmailVerification).Methods("GET")
+	server.Router.HandleFunc("/email/verification/resend", server.ResendVerificationEmail).Methods("POST")
+	server.Router.HandleFunc("/email/verify", server.VerifyEmail).Methods("GET")
+	server.Router.HandleFunc("/password/forgot", server.ForgotPassword).Methods("GET")
+	server.Router.HandleFunc("/password/forgot", server.DoForgotPassword).Methods("POST")
+	server.Router.HandleFunc("/password/reset", server.ResetPassword).Methods("GET")
+	server.Router.HandleFunc("/password/reset", server.DoResetPassword).Methods("POST")
+	server.Router.HandleFunc("/password/change", middlewares.AuthMiddleware(server.ChangePassword, server.DB)).Methods("GET")
+	server.Router.HandleFunc("/password/change", middlewares.AuthMiddleware(server.DoChangePassword, server.DB)).Methods("POST")
+	server.Router.HandleFunc("/account", middlewares.AuthMiddleware(server.Account, server.DB)).Methods("GET")
+	server.Router.HandleFunc("/account/profile", middlewares.AuthMiddleware(server.UpdateAccountProfile, server.DB)).Methods("POST")
+	server.Router.HandleFunc("/account/addresses", middlewares.AuthMiddleware(server.AccountAddresses, server.DB)).Methods("GET")
+	server.Router.HandleFunc("/account/addresses", middlewares.AuthMiddleware(server.CreateAccountAddress, server.DB)).Methods("POST")
+	server.Router.HandleFunc("/account/addresses/{id}/update", middlewares.AuthMiddleware(server.UpdateAccountAddress, server.DB)).Methods("POST")
+	server.Router.HandleFunc("/account/addresses/{id}/delete", middlewares.AuthMiddleware(server.DeleteAccountAddress, server.DB)).Methods("POST")
+	server.Router.HandleFunc("/account/addresses/{id}/default", middlewares.AuthMiddleware(server.SetDefaultAccountAddress, server.DB)).Methods("POST")
+	server.Router.HandleFunc("/account/orders", middlewares.AuthMiddleware(server.AccountOrders, server.DB)).Methods("GET")
+	server.Router.HandleFunc("/account/2fa", middlewares.AuthMiddleware(server.TwoFactorSettings, server.DB)).Methods("GET")
+	server.Router.HandleFunc("/account/2fa/enable", middlewares.AuthMiddleware(server.EnableTwoFactor, server.DB)).Methods("POST")
+	server.Router.HandleFunc("/account/2fa/recovery-codes", middlewares.AuthMiddleware(server.RegenerateRecoveryCodes, server.DB)).Methods("POST")
+	server.Router.HandleFunc("/account/2fa/disable", middlewares.AuthMiddleware(server.DisableTwoFactor, server.DB)).Methods("POST")
 
 	server.Router.HandleFunc("/products/search", server.SearchProducts).Methods("GET")
-	server.Router.HandleFunc("/products", middlewares.AuthMiddleware(server.Products)).Methods("GET")
+	server.Router.HandleFunc("/products/autocomplete", server.Autocomplete).Methods("GET")
+	server.Router.HandleFunc("/products", middlewares.AuthMiddleware(server.Products, server.DB)).Methods("GET")
 	server.Router.HandleFunc("/products/{slug}", server.GetProductBySlug).Methods("GET")
 
 	server.Router.HandleFunc("/carts", server.GetCart).Methods("GET")
 	server.Router.HandleFunc("/carts", server.AddItemToCart).Methods("POST")
 	server.Router.HandleFunc("/carts/update", server.UpdateCart).Methods("POST")
-	server.Router.HandleFunc("/carts/remove/{id}", server.RemoveItemByID).Methods("GET")
+	server.Router.HandleFunc("/carts/remove/{id}", server.RemoveItemByID).Methods("POST")
 	server.Router.HandleFunc("/carts/shipping", server.CalculateShipping).Methods("POST")
-	server.Router.HandleFunc("/orders/checkout", middlewares.AuthMiddleware(server.Checkout)).Methods("POST")
-	server.Router.HandleFunc("/orders/{id}", middlewares.AuthMiddleware(server.ShowOrder)).Methods("GET")
+	server.Router.HandleFunc("/orders/checkout", middlewares.AuthMiddleware(server.Checkout, server.DB)).Methods("POST")
+	server.Router.HandleFunc("/orders/{id}", middlewares.AuthMiddleware(server.ShowOrder, server.DB)).Methods("GET")
+	server.Router.HandleFunc("/orders/{id}/invoice", middlewares.AuthMiddleware(server.OrderInvoice, server.DB)).Methods("GET")
+	server.Router.HandleFunc("/orders/{id}/cancel", middlewares.AuthMiddleware(server.CancelOrder, server.DB)).Methods("POST")
 	server.Router.HandleFunc("/payments/midtrans", server.Midtrans).Methods("POST")
-	server.Router.HandleFunc("/admin/dashboard", middlewares.AuthMiddleware(middlewares.RoleMiddleware(server.AdminDashboard, server.DB, consts.RoleAdmin, consts.RoleOperator))).Methods("GET")
-	server.Router.HandleFunc("/admin/products", server.AdminProducts).Methods("GET")
-	server.Router.HandleFunc("/admin/products/create", server.CreateProductPage).Methods("GET")
-	server.Router.HandleFunc("/admin/products/store", server.StoreProduct).Methods("POST")
-
-	server.Router.HandleFunc("/admin/products/edit/{id}", server.EditProductPage).Methods("GET")
-	server.Router.HandleFunc("/admin/products/update/{id}", server.UpdateProduct).Methods("POST")
-	server.Router.HandleFunc("/admin/products/delete/{id}", server.DeleteProduct).Methods("POST")
-	server.Router.HandleFunc("/admin/order-dashboard", server.OrderDashboard).Methods("GET")
-	server.Router.HandleFunc("/admin/customers", server.ListCustomers).Methods("GET")
-	server.Router.HandleFunc("/admin/order-items", server.ListOrderItems).Methods("GET")
-	server.Router.HandleFunc("/admin/orders", server.ListOrders).Methods("GET")
+
+	// Semua route admin membutuhkan login dan permission; tanpa permission dibalas 403
+	can := func(permission string, next http.HandlerFunc) http.HandlerFunc {
+		return middlewares.PermissionMiddleware(next, server.DB, permission)
+	}
+
+	server.Router.HandleFunc("/admin/dashboard", can(consts.PermissionDashboardView, server.AdminDashboard)).Methods("GET")
+	server.Router.HandleFunc("/admin/products", can(consts.PermissionProductRead, server.AdminProducts)).Methods("GET")
+	server.Router.HandleFunc("/admin/products/create", can(consts.PermissionProductWrite, server.CreateProductPage)).Methods("GET")
+	server.Router.HandleFunc("/admin/products/store", can(consts.PermissionProductWrite, server.StoreProduct)).Methods("POST")
+	server.Router.HandleFunc("/admin/products/import", can(consts.PermissionProductImport, server.ImportProductsPage)).Methods("GET")
+	server.Router.HandleFunc("/admin/products/import", can(consts.PermissionProductImport, server.ImportProducts)).Methods("POST")
+	server.Router.HandleFunc("/admin/products/export", can(consts.PermissionProductImport, server.ExportProducts)).Methods("GET")
+
+	server.Router.HandleFunc("/admin/products/edit/{id}", can(consts.PermissionProductWrite, server.EditProductPage)).Methods("GET")
+	server.Router.HandleFunc("/admin/products/update/{id}", can(consts.PermissionProductWrite, server.UpdateProduct)).Methods("POST")
+	server.Router.HandleFunc("/admin/products/delete/{id}", can(consts.PermissionProductDelete, server.DeleteProduct)).Methods("POST")
+	server.Router.HandleFunc("/admin/products/{id}/images/reorder", can(consts.PermissionProductWrite, server.ReorderProductImages)).Methods("POST")
+	server.Router.HandleFunc("/admin/products/{id}/images/{image_id}/primary", can(consts.PermissionProductWrite, server.SetPrimaryProductImage)).Methods("POST")
+	server.Router.HandleFunc("/admin/products/{id}/images/{image_id}/update", can(consts.PermissionProductWrite, server.UpdateProductImage)).Methods("POST")
+	server.Router.HandleFunc("/admin/products/{id}/images/{image_id}/delete", can(consts.PermissionProductWrite, server.DeleteProductImage)).Methods("POST")
+
+	server.Router.HandleFunc("/admin/reports", can(consts.PermissionReportView, server.AdminReports)).Methods("GET")
+	server.Router.HandleFunc("/admin/reports/export", can(consts.PermissionReportView, server.ExportReport)).Methods("GET")
+	server.Router.HandleFunc("/admin/reports/sales/summary", can(consts.PermissionReportView, server.SalesSummaryJSON)).Methods("GET")
+	server.Router.HandleFunc("/admin/reports/sales/revenue", can(consts.PermissionReportView, server.SalesRevenueJSON)).Methods("GET")
+	server.Router.HandleFunc("/admin/reports/sales/top-products", can(consts.PermissionReportView, server.SalesTopProductsJSON)).Methods("GET")
+	server.Router.HandleFunc("/admin/reports/sales/top-categories", can(consts.PermissionReportView, server.SalesTopCategoriesJSON)).Methods("GET")
+	server.Router.HandleFunc("/admin/reports/sales/customers", can(consts.PermissionReportView, server.SalesCustomersJSON)).Methods("GET")
+	server.Router.HandleFunc("/admin/reports/low-stock", can(consts.PermissionReportView, server.LowStockJSON)).Methods("GET")
+	server.Router.HandleFunc("/admin/reports/search", can(consts.PermissionSearchManage, server.AdminSearchReport)).Methods("GET")
+	server.Router.HandleFunc("/admin/synonyms", can(consts.PermissionSearchManage, server.AdminSynonyms)).Methods("GET")
+	server.Router.HandleFunc("/admin/synonyms/store", can(consts.PermissionSearchManage, server.StoreSynonym)).Methods("POST")
+	server.Router.HandleFunc("/admin/synonyms/delete/{id}", can(consts.PermissionSearchManage, server.DeleteSynonym)).Methods("POST")
+	server.Router.HandleFunc("/admin/sections", can(consts.PermissionCategoryWrite, server.AdminSections)).Methods("GET")
+	server.Router.HandleFunc("/admin/sections/store", can(consts.PermissionCategoryWrite, server.StoreSection)).Methods("POST")
+	server.Router.HandleFunc("/admin/sections/update/{id}", can(consts.PermissionCategoryWrite, server.UpdateSection)).Methods("POST")
+	server.Router.HandleFunc("/admin/sections/delete/{id}", can(consts.PermissionCategoryWrite, server.DeleteSection)).Methods("POST")
+
+	server.Router.HandleFunc("/admin/categories", can(consts.PermissionCategoryWrite, server.AdminCategories)).Methods("GET")
+	server.Router.HandleFunc("/admin/categories/create", can(consts.PermissionCategoryWrite, server.CreateCategoryPage)).Methods("GET")
+	server.Router.HandleFunc("/admin/categories/store", can(consts.PermissionCategoryWrite, server.StoreCategory)).Methods("POST")
+	server.Router.HandleFunc("/admin/categories/edit/{id}", can(consts.PermissionCategoryWrite, server.EditCategoryPage)).Methods("GET")
+	server.Router.HandleFunc("/admin/categories/update/{id}", can(consts.PermissionCategoryWrite, server.UpdateCategory)).Methods("POST")
+	server.Router.HandleFunc("/admin/categories/delete/{id}", can(consts.PermissionCategoryWrite, server.DeleteCategory)).Methods("POST")
+
+	server.Router.HandleFunc("/admin/order-dashboard", can(consts.PermissionOrderRead, server.OrderDashboard)).Methods("GET")
+	server.Router.HandleFunc("/admin/customers", can(consts.PermissionCustomerRead, server.ListCustomers)).Methods("GET")
+	server.Router.HandleFunc("/admin/order-items", can(consts.PermissionOrderRead, server.ListOrderItems)).Methods("GET")
+	server.Router.HandleFunc("/admin/orders", can(consts.PermissionOrderRead, server.ListOrders)).Methods("GET")
+	server.Router.HandleFunc("/admin/orders/{id}", can(consts.PermissionOrderRead, server.AdminOrderDetail)).Methods("GET")
+	server.Router.HandleFunc("/admin/orders/{id}/status", can(consts.PermissionOrderUpdate, server.UpdateOrderStatus)).Methods("POST")
+	server.Router.HandleFunc("/admin/orders/{id}/note", can(consts.PermissionOrderUpdate, server.UpdateOrderNote)).Methods("POST")
+
+	server.Router.HandleFunc("/admin/users/roles", can(consts.PermissionRoleManage, server.AdminUserRoles)).Methods("GET")
+	server.Router.HandleFunc("/admin/users/{id}/role", can(consts.PermissionRoleManage, server.AssignUserRole)).Methods("POST")
+	server.Router.HandleFunc("/admin/users", can(consts.PermissionUserManage, server.AdminUsers)).Methods("GET")
+	server.Router.HandleFunc("/admin/users/locked", can(consts.PermissionUserManage, server.AdminLockedUsers)).Methods("GET")
+	server.Router.HandleFunc("/admin/users/{id}/unlock", can(consts.PermissionUserManage, server.UnlockUser)).Methods("POST")
+	server.Router.HandleFunc("/admin/users/{id}", can(consts.PermissionUserManage, server.AdminUserDetail)).Methods("GET")
+	server.Router.HandleFunc("/admin/users/{id}/suspend", can(consts.PermissionUserManage, server.SuspendUser)).Methods("POST")
+	server.Router.HandleFunc("/admin/users/{id}/activate", can(consts.PermissionUserManage, server.ActivateUser)).Methods("POST")
+	server.Router.HandleFunc("/admin/users/{id}/reset-password", can(consts.PermissionUserManage, server.ForceUserPasswordReset)).Methods("POST")
+	server.Router.HandleFunc("/admin/users/{id}/reset-2fa", can(consts.PermissionUserManage, server.ResetUserTwoFactor)).Methods("POST")
 	
 
 staticDir := http.Dir("./assets")
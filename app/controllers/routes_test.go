@@ -0,0 +1,45 @@
+package controllers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestMustResetPasswordIsRedirectedToPasswordChange(t *testing.T) {
+	server := newTestServer(t)
+	server.initializeRoutes()
+
+	user := createTestUser(t, server.DB, "reset@example.com")
+	if err := server.DB.Model(user).Update("must_reset_password", true).Error; err != nil {
+		t.Fatal(err)
+	}
+	cookies := loginCookies(t, user)
+
+	tests := []struct {
+		path     string
+		redirect bool
+	}{
+		{"/account", true},
+		{"/account/orders", true},
+		{"/orders/some-order", true},
+		{"/password/change", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, tt.path, nil)
+			for _, cookie := range cookies {
+				r.AddCookie(cookie)
+			}
+			w := httptest.NewRecorder()
+			server.Router.ServeHTTP(w, r)
+
+			redirected := w.Code == http.StatusSeeOther && w.Header().Get("Location") == "/password/change?error=Silakan+ganti+password+Anda"
+			if redirected != tt.redirect {
+				t.Errorf("GET %s = %d %s, redirect to /password/change = %v, want %v",
+					tt.path, w.Code, w.Header().Get("Location"), redirected, tt.redirect)
+			}
+		})
+	}
+}
@@ -0,0 +1,67 @@
+package controllers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gieart87/gotoko/app/core/session/auth"
+	"github.com/gieart87/gotoko/app/models"
+	"github.com/gorilla/mux"
+	"github.com/shopspring/decimal"
+)
+
+func TestProductClickOnlyRecordsOwnSearchQueries(t *testing.T) {
+	server := newTestServer(t)
+	user := createTestUser(t, server.DB, "admin@example.com")
+
+	product := &models.Product{ID: "product-1", UserID: user.ID, Name: "Paracetamol", Slug: "paracetamol", Price: decimal.NewFromInt(10000)}
+	if err := server.DB.Create(product).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	searchQueryModel := models.SearchQuery{}
+	own, err := searchQueryModel.LogSearch(server.DB, "paracetamol", 1, models.SearchSourceStandard, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	foreign, err := searchQueryModel.LogSearch(server.DB, "paracetamol", 1, models.SearchSourceStandard, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Sesi yang pernah membuat pencarian own
+	w := httptest.NewRecorder()
+	auth.AddSearchQuery(w, httptest.NewRequest(http.MethodGet, "/products/search", nil), own.ID)
+	cookies := w.Result().Cookies()
+
+	router := mux.NewRouter()
+	router.HandleFunc("/products/{slug}", server.GetProductBySlug).Methods("GET")
+
+	tests := []struct {
+		name        string
+		searchQuery *models.SearchQuery
+		wantClicked bool
+	}{
+		{"own search query", own, true},
+		{"search query of another session", foreign, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/products/paracetamol?sq="+tt.searchQuery.ID, nil)
+			for _, cookie := range cookies {
+				r.AddCookie(cookie)
+			}
+			router.ServeHTTP(httptest.NewRecorder(), r)
+
+			var stored models.SearchQuery
+			if err := server.DB.First(&stored, "id = ?", tt.searchQuery.ID).Error; err != nil {
+				t.Fatal(err)
+			}
+			if clicked := stored.ClickedAt != nil; clicked != tt.wantClicked {
+				t.Errorf("clicked = %v, want %v", clicked, tt.wantClicked)
+			}
+		})
+	}
+}
@@ -0,0 +1,225 @@
+package controllers
+
+import (
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/gieart87/gotoko/app/core/search"
+	"github.com/gieart87/gotoko/app/core/session/auth"
+	"github.com/gieart87/gotoko/app/models"
+	"github.com/gorilla/mux"
+)
+
+const autocompleteLimit = 8
+
+// initializeSearchIndex membangun index pencarian in-memory dari seluruh produk,
+// sehingga pencarian tetap berjalan walaupun service AI Python mati.
+func (server *Server) initializeSearchIndex() {
+	server.SearchIndex = search.NewIndex()
+
+	if err := server.rebuildSearchIndex(); err != nil {
+		log.Printf("Gagal membangun index pencarian: %v", err)
+	}
+
+	if err := server.reloadSynonyms(); err != nil {
+		log.Printf("Gagal memuat sinonim: %v", err)
+	}
+}
+
+func (server *Server) rebuildSearchIndex() error {
+	var products []models.Product
+	if err := server.DB.Preload("Categories").Find(&products).Error; err != nil {
+		return err
+	}
+
+	docs := make([]search.Document, len(products))
+	for i, product := range products {
+		docs[i] = searchDocument(product)
+	}
+	server.SearchIndex.Replace(docs)
+
+	log.Printf("Index pencarian dibangun: %d produk", len(docs))
+	return nil
+}
+
+// refreshSearchIndex membangun ulang index, misalnya setelah nama kategori berubah.
+func (server *Server) refreshSearchIndex() {
+	if server.SearchIndex == nil {
+		return
+	}
+
+	if err := server.rebuildSearchIndex(); err != nil {
+		log.Printf("Gagal membangun index pencarian: %v", err)
+	}
+}
+
+func (server *Server) reloadSynonyms() error {
+	synonymModel := models.Synonym{}
+	synonyms, err := synonymModel.GetSynonyms(server.DB)
+	if err != nil {
+		return err
+	}
+
+	groups := make([][]string, len(synonyms))
+	for i, synonym := range synonyms {
+		groups[i] = synonym.Group()
+	}
+	server.SearchIndex.SetSynonyms(groups)
+
+	return nil
+}
+
+// indexProducts memperbarui dokumen index untuk produk yang baru disimpan.
+// Tidak melakukan apa-apa jika index belum dibuat (misalnya saat dijalankan dari CLI).
+func (server *Server) indexProducts(productIDs ...string) {
+	if server.SearchIndex == nil || len(productIDs) == 0 {
+		return
+	}
+
+	var products []models.Product
+	if err := server.DB.Preload("Categories").Where("id IN ?", productIDs).Find(&products).Error; err != nil {
+		log.Printf("Gagal memperbarui index pencarian: %v", err)
+		return
+	}
+
+	for _, product := range products {
+		server.SearchIndex.Add(searchDocument(product))
+	}
+}
+
+func (server *Server) unindexProduct(productID string) {
+	if server.SearchIndex == nil {
+		return
+	}
+
+	server.SearchIndex.Remove(productID)
+}
+
+func searchDocument(product models.Product) search.Document {
+	categories := make([]string, len(product.Categories))
+	for i, category := range product.Categories {
+		categories[i] = category.Name
+	}
+
+	return search.Document{
+		ID:          product.ID,
+		Name:        product.Name,
+		Slug:        product.Slug,
+		Sku:         product.Sku,
+		Categories:  categories,
+		Description: product.ShortDescription + " " + product.Description,
+	}
+}
+
+// searchIndexResults mencari di index in-memory lalu memuat produknya dari database
+// untuk halaman yang diminta.
+func (server *Server) searchIndexResults(query string, perPage int, page int) ([]models.ProductSearchResult, int64, error) {
+	if server.SearchIndex == nil {
+		return nil, 0, nil
+	}
+
+	hits := server.SearchIndex.Search(query, 0)
+	total := int64(len(hits))
+
+	start := (page - 1) * perPage
+	if start >= len(hits) {
+		return nil, total, nil
+	}
+	end := start + perPage
+	if end > len(hits) {
+		end = len(hits)
+	}
+
+	ids := make([]string, 0, end-start)
+	var terms []string
+	for _, hit := range hits[start:end] {
+		ids = append(ids, hit.Document.ID)
+		terms = append(terms, hit.Terms...)
+	}
+
+	productModel := models.Product{}
+	results, err := productModel.SearchResultsByIDs(server.DB, ids, terms)
+
+	return results, total, err
+}
+
+// Autocomplete mengembalikan saran produk (JSON) untuk kotak pencarian.
+func (server *Server) Autocomplete(w http.ResponseWriter, r *http.Request) {
+	render := newRender()
+
+	query := strings.TrimSpace(r.URL.Query().Get("q"))
+	suggestions := []map[string]string{}
+
+	if query != "" && server.SearchIndex != nil {
+		for _, hit := range server.SearchIndex.Search(query, autocompleteLimit) {
+			suggestions = append(suggestions, map[string]string{
+				"id":   hit.Document.ID,
+				"name": hit.Document.Name,
+				"sku":  hit.Document.Sku,
+				"url":  "/products/" + hit.Document.Slug,
+			})
+		}
+	}
+
+	_ = render.JSON(w, http.StatusOK, map[string]interface{}{
+		"query":       query,
+		"suggestions": suggestions,
+	})
+}
+
+// ===== SINONIM =====
+
+func (server *Server) AdminSynonyms(w http.ResponseWriter, r *http.Request) {
+	synonymModel := models.Synonym{}
+	synonyms, err := synonymModel.GetSynonyms(server.DB)
+	if err != nil {
+		http.Error(w, "Gagal memuat sinonim", http.StatusInternalServerError)
+		return
+	}
+
+	_ = adminRender().HTML(w, http.StatusOK, "pages/admin_synonyms", map[string]interface{}{
+		"user":     auth.CurrentUser(server.DB, w, r),
+		"synonyms": synonyms,
+		"Message":  r.URL.Query().Get("message"),
+		"Error":    r.URL.Query().Get("error"),
+	})
+}
+
+func (server *Server) StoreSynonym(w http.ResponseWriter, r *http.Request) {
+	synonymModel := models.Synonym{}
+	_, err := synonymModel.SaveSynonym(server.DB, r.FormValue("term"), r.FormValue("terms"))
+	if err != nil {
+		http.Redirect(w, r, "/admin/synonyms?error="+url.QueryEscape(err.Error()), http.StatusSeeOther)
+		return
+	}
+
+	if err := server.reloadSynonyms(); err != nil {
+		log.Printf("Gagal memuat sinonim: %v", err)
+	}
+
+	http.Redirect(w, r, "/admin/synonyms?message=Sinonim+berhasil+disimpan", http.StatusSeeOther)
+}
+
+func (server *Server) DeleteSynonym(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+
+	synonymModel := models.Synonym{}
+	synonym, err := synonymModel.FindByID(server.DB, vars["id"])
+	if err != nil {
+		http.Redirect(w, r, "/admin/synonyms?error=Sinonim+tidak+ditemukan", http.StatusSeeOther)
+		return
+	}
+
+	if err := synonym.DeleteSynonym(server.DB); err != nil {
+		http.Redirect(w, r, "/admin/synonyms?error="+url.QueryEscape(err.Error()), http.StatusSeeOther)
+		return
+	}
+
+	if err := server.reloadSynonyms(); err != nil {
+		log.Printf("Gagal memuat sinonim: %v", err)
+	}
+
+	http.Redirect(w, r, "/admin/synonyms?message=Sinonim+berhasil+dihapus", http.StatusSeeOther)
+}
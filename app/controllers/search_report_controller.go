@@ -0,0 +1,42 @@
+package controllers
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gieart87/gotoko/app/core/session/auth"
+	"github.com/gieart87/gotoko/app/models"
+)
+
+const searchReportLimit = 50
+
+// AdminSearchReport menampilkan query terpopuler, query tanpa hasil dan konversi per query
+// (?days=30) sebagai bahan untuk melengkapi katalog dan sinonim.
+func (server *Server) AdminSearchReport(w http.ResponseWriter, r *http.Request) {
+	days, _ := strconv.Atoi(r.URL.Query().Get("days"))
+	if days <= 0 {
+		days = 30
+	}
+	since := time.Now().AddDate(0, 0, -days)
+
+	searchQueryModel := models.SearchQuery{}
+	topQueries, err := searchQueryModel.TopSearchQueries(server.DB, since, searchReportLimit)
+	if err != nil {
+		http.Error(w, "Gagal memuat laporan pencarian", http.StatusInternalServerError)
+		return
+	}
+
+	zeroResultQueries, err := searchQueryModel.ZeroResultQueries(server.DB, since, searchReportLimit)
+	if err != nil {
+		http.Error(w, "Gagal memuat laporan pencarian", http.StatusInternalServerError)
+		return
+	}
+
+	_ = adminRender().HTML(w, http.StatusOK, "pages/admin_search_report", map[string]interface{}{
+		"user":              auth.CurrentUser(server.DB, w, r),
+		"days":              days,
+		"topQueries":        topQueries,
+		"zeroResultQueries": zeroResultQueries,
+	})
+}
@@ -0,0 +1,211 @@
+package controllers
+
+import (
+	"crypto/subtle"
+	"errors"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/gieart87/gotoko/app/consts"
+	"github.com/gieart87/gotoko/app/core/session/auth"
+	"github.com/gieart87/gotoko/app/core/sso"
+	"github.com/gieart87/gotoko/app/models"
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	"gorm.io/gorm"
+)
+
+var errSSOEmailNotVerified = errors.New("email dari provider belum terverifikasi")
+
+func (server *Server) initializeSSO(configs []sso.Config) {
+	server.SSO = sso.NewRegistry(configs)
+}
+
+// SSOLogin mengarahkan user ke halaman login provider OIDC (/auth/{provider}).
+func (server *Server) SSOLogin(w http.ResponseWriter, r *http.Request) {
+	provider, err := server.SSO.Get(mux.Vars(r)["provider"])
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+
+	request, err := sso.NewAuthRequest(provider.Name())
+	if err == nil {
+		err = auth.SetSSORequest(w, r, request)
+	}
+	if err != nil {
+		http.Redirect(w, r, "/login?error=Gagal+memulai+login", http.StatusSeeOther)
+		return
+	}
+
+	authURL, err := provider.AuthCodeURL(r.Context(), request)
+	if err != nil {
+		log.Printf("Gagal menghubungi provider %s: %v", provider.Name(), err)
+		http.Redirect(w, r, "/login?error="+url.QueryEscape(provider.DisplayName()+" sedang tidak tersedia"), http.StatusSeeOther)
+		return
+	}
+
+	http.Redirect(w, r, authURL, http.StatusFound)
+}
+
+// SSOCallback menerima redirect dari provider, memverifikasi ID token lalu login-kan user.
+// Langkah 2FA, akun nonaktif dan penguncian akun tetap berlaku seperti login biasa.
+func (server *Server) SSOCallback(w http.ResponseWriter, r *http.Request) {
+	provider, err := server.SSO.Get(mux.Vars(r)["provider"])
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+
+	request, ok := auth.PopSSORequest(w, r)
+	state := r.URL.Query().Get("state")
+	if !ok || request.Provider != provider.Name() || subtle.ConstantTimeCompare([]byte(state), []byte(request.State)) != 1 {
+		http.Redirect(w, r, "/login?error=Sesi+login+tidak+valid,+silakan+coba+lagi", http.StatusSeeOther)
+		return
+	}
+
+	if r.URL.Query().Get("error") != "" {
+		http.Redirect(w, r, "/login?error=Login+dibatalkan", http.StatusSeeOther)
+		return
+	}
+
+	identity, err := provider.Exchange(r.Context(), r.URL.Query().Get("code"), request)
+	if err != nil {
+		log.Printf("Login %s gagal: %v", provider.Name(), err)
+		http.Redirect(w, r, "/login?error="+url.QueryEscape("Login dengan "+provider.DisplayName()+" gagal"), http.StatusSeeOther)
+		return
+	}
+
+	user, err := server.userFromIdentity(identity)
+	if err != nil {
+		message := "Login dengan " + provider.DisplayName() + " gagal"
+		if errors.Is(err, errSSOEmailNotVerified) {
+			message = "Email akun " + provider.DisplayName() + " Anda belum terverifikasi"
+		} else {
+			log.Printf("Gagal menghubungkan identitas %s/%s: %v", identity.Provider, identity.Subject, err)
+		}
+		http.Redirect(w, r, "/login?error="+url.QueryEscape(message), http.StatusSeeOther)
+		return
+	}
+
+	attempt := server.newLoginAttempt(r, user.Email)
+	if user.IsSuspended() {
+		server.recordLoginAttempt(attempt, user, models.LoginSuspended)
+		http.Redirect(w, r, "/login?error=Akun+Anda+dinonaktifkan", http.StatusSeeOther)
+		return
+	}
+
+	if user.IsLocked() {
+		server.recordLoginAttempt(attempt, user, models.LoginLocked)
+		http.Redirect(w, r, "/login?error="+url.QueryEscape(loginRetryMessage(user.LoginRetryAfter(time.Now()))), http.StatusSeeOther)
+		return
+	}
+
+	if user.HasTwoFactor() {
+		if err := auth.SetPendingTwoFactor(w, r, user.ID); err != nil {
+			http.Redirect(w, r, "/login?error=Gagal menyimpan sesi baru", http.StatusSeeOther)
+			return
+		}
+		http.Redirect(w, r, "/login/2fa", http.StatusSeeOther)
+		return
+	}
+
+	server.completeLogin(w, r, user, attempt)
+}
+
+// userFromIdentity mencari user untuk identitas eksternal:
+//  1. identitas yang sudah pernah terhubung,
+//  2. user dengan email yang sama, hanya jika provider menyatakan email sudah terverifikasi,
+//  3. user baru tanpa password.
+//
+// Akun lokal yang emailnya belum terverifikasi bisa saja didaftarkan orang lain dengan email
+// korban. Saat akun seperti ini dihubungkan, password, 2FA dan semua sesi lamanya dicabut.
+func (server *Server) userFromIdentity(identity *sso.Identity) (*models.User, error) {
+	var user *models.User
+
+	err := server.DB.Transaction(func(tx *gorm.DB) error {
+		userModel := models.User{}
+		identityModel := models.UserIdentity{}
+
+		linked, err := identityModel.FindByProviderSubject(tx, identity.Provider, identity.Subject)
+		if err == nil {
+			user, err = userModel.FindByID(tx, linked.UserID)
+			if err != nil {
+				return err
+			}
+			return linked.Touch(tx, identity.Email)
+		}
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		}
+
+		if identity.Email == "" || !identity.EmailVerified {
+			return errSSOEmailNotVerified
+		}
+
+		user, err = userModel.FindByEmail(tx, identity.Email)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			user, err = server.createSSOUser(tx, identity)
+		}
+		if err != nil {
+			return err
+		}
+
+		if !user.IsEmailVerified() {
+			if err := user.UpdatePassword(tx, ""); err != nil {
+				return err
+			}
+			if user.HasTwoFactor() {
+				if err := user.DisableTwoFactor(tx); err != nil {
+					return err
+				}
+			}
+			if err := user.MarkEmailVerified(tx); err != nil {
+				return err
+			}
+		}
+
+		_, err = identityModel.LinkIdentity(tx, user.ID, identity.Provider, identity.Subject, identity.Email)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return user, nil
+}
+
+func (server *Server) createSSOUser(tx *gorm.DB, identity *sso.Identity) (*models.User, error) {
+	firstName, lastName := identity.GivenName, identity.FamilyName
+	if firstName == "" {
+		firstName = identity.Name
+	}
+	if firstName == "" {
+		firstName = strings.Split(identity.Email, "@")[0]
+	}
+
+	now := time.Now()
+	params := &models.User{
+		ID:              uuid.New().String(),
+		FirstName:       firstName,
+		LastName:        lastName,
+		Email:           identity.Email,
+		EmailVerifiedAt: &now,
+	}
+
+	roleModel := models.Role{}
+	if role, err := roleModel.FindByName(tx, consts.RoleCustomer); err == nil {
+		params.RoleID = role.ID
+	}
+
+	userModel := models.User{}
+	user, err := userModel.CreateUser(tx, params)
+	if err != nil {
+		return nil, err
+	}
+
+	return userModel.FindByID(tx, user.ID)
+}
@@ -0,0 +1,350 @@
+package controllers
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gieart87/gotoko/app/core/session/auth"
+	"github.com/gieart87/gotoko/app/core/sso"
+	"github.com/gieart87/gotoko/app/models"
+)
+
+// fakeOIDCProvider adalah provider OIDC minimal (discovery, JWKS, token endpoint) yang
+// menandatangani ID token dengan kunci RSA milik test.
+type fakeOIDCProvider struct {
+	*httptest.Server
+	key *rsa.PrivateKey
+
+	mu     sync.Mutex
+	claims map[string]interface{}
+}
+
+func newFakeOIDCProvider(t *testing.T) *fakeOIDCProvider {
+	t.Helper()
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	provider := &fakeOIDCProvider{key: key}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		writeTestJSON(w, map[string]interface{}{
+			"issuer":                                provider.URL,
+			"authorization_endpoint":                provider.URL + "/authorize",
+			"token_endpoint":                        provider.URL + "/token",
+			"jwks_uri":                              provider.URL + "/jwks",
+			"response_types_supported":              []string{"code"},
+			"subject_types_supported":               []string{"public"},
+			"id_token_signing_alg_values_supported": []string{"RS256"},
+		})
+	})
+	mux.HandleFunc("/jwks", func(w http.ResponseWriter, r *http.Request) {
+		writeTestJSON(w, map[string]interface{}{
+			"keys": []map[string]string{{
+				"kty": "RSA",
+				"kid": "test-key",
+				"use": "sig",
+				"alg": "RS256",
+				"n":   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+				"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+			}},
+		})
+	})
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		provider.mu.Lock()
+		claims := provider.claims
+		provider.mu.Unlock()
+
+		idToken, err := provider.sign(claims)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		writeTestJSON(w, map[string]interface{}{
+			"access_token": "access-token",
+			"token_type":   "Bearer",
+			"expires_in":   3600,
+			"id_token":     idToken,
+		})
+	})
+	provider.Server = httptest.NewServer(mux)
+	t.Cleanup(provider.Close)
+
+	return provider
+}
+
+func writeTestJSON(w http.ResponseWriter, value interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(value)
+}
+
+// setClaims menentukan isi ID token berikutnya; iss, aud, iat dan exp diisi otomatis.
+func (p *fakeOIDCProvider) setClaims(claims map[string]interface{}) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.claims = map[string]interface{}{
+		"iss": p.URL,
+		"aud": "test-client",
+		"iat": time.Now().Unix(),
+		"exp": time.Now().Add(time.Hour).Unix(),
+	}
+	for key, value := range claims {
+		p.claims[key] = value
+	}
+}
+
+func (p *fakeOIDCProvider) sign(claims map[string]interface{}) (string, error) {
+	header, err := json.Marshal(map[string]string{"alg": "RS256", "typ": "JWT", "kid": "test-key"})
+	if err != nil {
+		return "", err
+	}
+	payload, err := json.Marshal(claims)
+	if err != nil {
+		return "", err
+	}
+
+	signingInput := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(payload)
+	digest := sha256.Sum256([]byte(signingInput))
+	signature, err := rsa.SignPKCS1v15(rand.Reader, p.key, crypto.SHA256, digest[:])
+	if err != nil {
+		return "", err
+	}
+
+	return signingInput + "." + base64.RawURLEncoding.EncodeToString(signature), nil
+}
+
+func newSSOTestServer(t *testing.T) (*Server, *fakeOIDCProvider) {
+	t.Helper()
+
+	provider := newFakeOIDCProvider(t)
+	server := newTestServer(t)
+	server.initializeSSO([]sso.Config{{
+		Name:         "stub",
+		IssuerURL:    provider.URL,
+		ClientID:     "test-client",
+		ClientSecret: "test-secret",
+		RedirectURL:  "http://localhost/auth/stub/callback",
+	}})
+	server.initializeRoutes()
+
+	return server, provider
+}
+
+// ssoLogin menjalankan /auth/stub lalu callback-nya. claims menerima nonce dari authorize
+// URL dan mengembalikan isi ID token yang akan dikeluarkan provider.
+func ssoLogin(t *testing.T, server *Server, provider *fakeOIDCProvider, claims func(nonce string) map[string]interface{}) *httptest.ResponseRecorder {
+	t.Helper()
+
+	w := httptest.NewRecorder()
+	server.Router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/auth/stub", nil))
+	if w.Code != http.StatusFound {
+		t.Fatalf("GET /auth/stub = %d %s", w.Code, w.Header().Get("Location"))
+	}
+
+	authURL, err := url.Parse(w.Header().Get("Location"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(authURL.String(), provider.URL+"/authorize") {
+		t.Fatalf("redirected to %s, want provider authorize endpoint", authURL)
+	}
+	provider.setClaims(claims(authURL.Query().Get("nonce")))
+
+	// Sesi bisa disimpan lebih dari sekali dalam satu respon (CSRF lalu request SSO), yang terakhir berlaku
+	cookies := map[string]*http.Cookie{}
+	for _, cookie := range w.Result().Cookies() {
+		cookies[cookie.Name] = cookie
+	}
+
+	callback := httptest.NewRequest(http.MethodGet, "/auth/stub/callback?code=test-code&state="+url.QueryEscape(authURL.Query().Get("state")), nil)
+	for _, cookie := range cookies {
+		callback.AddCookie(cookie)
+	}
+
+	w = httptest.NewRecorder()
+	server.Router.ServeHTTP(w, callback)
+
+	return w
+}
+
+// ssoLoginError mengembalikan pesan ?error= jika callback mengarah kembali ke /login.
+func ssoLoginError(w *httptest.ResponseRecorder) string {
+	location, err := url.Parse(w.Header().Get("Location"))
+	if w.Code != http.StatusSeeOther || err != nil || location.Path != "/login" {
+		return ""
+	}
+
+	return location.Query().Get("error")
+}
+
+func findTestIdentity(t *testing.T, server *Server, subject string) *models.UserIdentity {
+	t.Helper()
+
+	identityModel := models.UserIdentity{}
+	identity, err := identityModel.FindByProviderSubject(server.DB, "stub", subject)
+	if err != nil {
+		return nil
+	}
+
+	return identity
+}
+
+func TestSSOLinksExistingUserByVerifiedEmail(t *testing.T) {
+	server, provider := newSSOTestServer(t)
+	user := createTestUser(t, server.DB, "linked@example.com")
+
+	w := ssoLogin(t, server, provider, func(nonce string) map[string]interface{} {
+		return map[string]interface{}{"sub": "subject-1", "nonce": nonce, "email": user.Email, "email_verified": true}
+	})
+	if w.Code != http.StatusSeeOther || w.Header().Get("Location") != "/" {
+		t.Fatalf("callback = %d %s, want redirect to /", w.Code, w.Header().Get("Location"))
+	}
+
+	identity := findTestIdentity(t, server, "subject-1")
+	if identity == nil || identity.UserID != user.ID {
+		t.Fatalf("identity = %+v, want linked to %s", identity, user.ID)
+	}
+}
+
+func TestSSORejectsUnverifiedEmail(t *testing.T) {
+	server, provider := newSSOTestServer(t)
+	user := createTestUser(t, server.DB, "victim@example.com")
+
+	w := ssoLogin(t, server, provider, func(nonce string) map[string]interface{} {
+		return map[string]interface{}{"sub": "attacker", "nonce": nonce, "email": user.Email, "email_verified": false}
+	})
+	if got := ssoLoginError(w); got != "Email akun stub Anda belum terverifikasi" {
+		t.Fatalf("callback = %d %s, want unverified email error", w.Code, w.Header().Get("Location"))
+	}
+
+	if identity := findTestIdentity(t, server, "attacker"); identity != nil {
+		t.Errorf("unverified email must not be linked, got %+v", identity)
+	}
+}
+
+func TestSSORejectsNonceMismatch(t *testing.T) {
+	server, provider := newSSOTestServer(t)
+
+	w := ssoLogin(t, server, provider, func(nonce string) map[string]interface{} {
+		return map[string]interface{}{"sub": "subject-2", "nonce": "other-" + nonce, "email": "new@example.com", "email_verified": true}
+	})
+	if got := ssoLoginError(w); got != "Login dengan stub gagal" {
+		t.Fatalf("callback = %d %s, want failed login error", w.Code, w.Header().Get("Location"))
+	}
+
+	userModel := models.User{}
+	if user, err := userModel.FindByEmail(server.DB, "new@example.com"); err == nil {
+		t.Errorf("user must not be created on nonce mismatch, got %s", user.ID)
+	}
+}
+
+func TestSSOCreatesPasswordlessUser(t *testing.T) {
+	server, provider := newSSOTestServer(t)
+
+	w := ssoLogin(t, server, provider, func(nonce string) map[string]interface{} {
+		return map[string]interface{}{
+			"sub":            "subject-3",
+			"nonce":          nonce,
+			"email":          "fresh@example.com",
+			"email_verified": true,
+			"given_name":     "Fresh",
+			"family_name":    "User",
+		}
+	})
+	if w.Code != http.StatusSeeOther || w.Header().Get("Location") != "/" {
+		t.Fatalf("callback = %d %s, want redirect to /", w.Code, w.Header().Get("Location"))
+	}
+
+	userModel := models.User{}
+	user, err := userModel.FindByEmail(server.DB, "fresh@example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if user.HasPassword() || !user.IsEmailVerified() || user.FirstName != "Fresh" || user.LastName != "User" {
+		t.Errorf("created user = %+v, want verified user without password", user)
+	}
+
+	identity := findTestIdentity(t, server, "subject-3")
+	if identity == nil || identity.UserID != user.ID {
+		t.Fatalf("identity = %+v, want linked to %s", identity, user.ID)
+	}
+}
+
+func TestSSORevokesAccessToUnverifiedLocalAccount(t *testing.T) {
+	server, provider := newSSOTestServer(t)
+
+	// Akun dibuat orang lain dengan email korban dan belum pernah diverifikasi
+	squatted := createTestUser(t, server.DB, "victim@example.com")
+	attackerSession := loginCookies(t, squatted)
+	now := time.Now()
+	if err := squatted.EnableTwoFactor(server.DB, "attacker-secret", now.Unix()/30, []string{"recovery-code"}); err != nil {
+		t.Fatal(err)
+	}
+
+	w := ssoLogin(t, server, provider, func(nonce string) map[string]interface{} {
+		return map[string]interface{}{"sub": "victim", "nonce": nonce, "email": squatted.Email, "email_verified": true}
+	})
+	if w.Code != http.StatusSeeOther || w.Header().Get("Location") != "/" {
+		t.Fatalf("callback = %d %s, want redirect to /", w.Code, w.Header().Get("Location"))
+	}
+
+	userModel := models.User{}
+	user, err := userModel.FindByID(server.DB, squatted.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if user.HasPassword() || user.HasTwoFactor() || !user.IsEmailVerified() {
+		t.Errorf("linked user = %+v, want verified user without password or 2FA", user)
+	}
+	if user.RememberToken == squatted.RememberToken {
+		t.Error("remember token must be rotated so old sessions are revoked")
+	}
+
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	for _, cookie := range attackerSession {
+		r.AddCookie(cookie)
+	}
+	if current := auth.CurrentUser(server.DB, httptest.NewRecorder(), r); current != nil {
+		t.Errorf("session created before linking is still valid for %s", current.Email)
+	}
+}
+
+func TestSSOKeepsPasswordOfVerifiedLocalAccount(t *testing.T) {
+	server, provider := newSSOTestServer(t)
+	user := createTestUser(t, server.DB, "verified@example.com")
+	if err := user.MarkEmailVerified(server.DB); err != nil {
+		t.Fatal(err)
+	}
+
+	w := ssoLogin(t, server, provider, func(nonce string) map[string]interface{} {
+		return map[string]interface{}{"sub": "subject-4", "nonce": nonce, "email": user.Email, "email_verified": true}
+	})
+	if w.Code != http.StatusSeeOther {
+		t.Fatalf("callback = %d %s", w.Code, w.Header().Get("Location"))
+	}
+
+	userModel := models.User{}
+	linked, err := userModel.FindByID(server.DB, user.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !linked.HasPassword() || linked.RememberToken != user.RememberToken {
+		t.Errorf("verified account must keep its password and sessions, got %+v", linked)
+	}
+}
@@ -0,0 +1,262 @@
+package controllers
+
+import (
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/gieart87/gotoko/app/core/session/auth"
+	"github.com/gieart87/gotoko/app/core/twofactor"
+	"github.com/gieart87/gotoko/app/models"
+)
+
+// TwoFactorChallenge menampilkan form kode 2FA, langkah kedua setelah password benar.
+func (server *Server) TwoFactorChallenge(w http.ResponseWriter, r *http.Request) {
+	if _, ok := auth.GetPendingTwoFactor(r); !ok {
+		http.Redirect(w, r, "/login?error=Sesi+login+kedaluwarsa,+silakan+login+ulang", http.StatusSeeOther)
+		return
+	}
+
+	_ = server.getRenderer().HTML(w, http.StatusOK, "login_2fa", map[string]interface{}{
+		"Error": r.URL.Query().Get("error"),
+	})
+}
+
+// DoTwoFactorChallenge menerima kode dari aplikasi authenticator atau salah satu kode cadangan.
+// Kode salah dihitung sebagai login gagal sehingga ikut terkena jeda dan penguncian akun.
+func (server *Server) DoTwoFactorChallenge(w http.ResponseWriter, r *http.Request) {
+	userID, ok := auth.GetPendingTwoFactor(r)
+	if !ok {
+		http.Redirect(w, r, "/login?error=Sesi+login+kedaluwarsa,+silakan+login+ulang", http.StatusSeeOther)
+		return
+	}
+
+	userModel := models.User{}
+	user, err := userModel.FindByID(server.DB, userID)
+	if err != nil || user.IsSuspended() || !user.HasTwoFactor() {
+		auth.ClearPendingTwoFactor(w, r)
+		http.Redirect(w, r, "/login", http.StatusSeeOther)
+		return
+	}
+
+	attempt := server.newLoginAttempt(r, user.Email)
+	if wait := user.LoginRetryAfter(time.Now()); wait > 0 {
+		reason := models.LoginThrottled
+		if user.IsLocked() {
+			reason = models.LoginLocked
+			auth.ClearPendingTwoFactor(w, r)
+		}
+		server.recordLoginAttempt(attempt, user, reason)
+		http.Redirect(w, r, "/login/2fa?error="+url.QueryEscape(loginRetryMessage(wait)), http.StatusSeeOther)
+		return
+	}
+
+	code := r.FormValue("code")
+	valid, err := server.checkTOTP(user, code)
+	if err == nil && !valid && isRecoveryCode(code) {
+		recoveryCodeModel := models.RecoveryCode{}
+		valid, err = recoveryCodeModel.UseRecoveryCode(server.DB, user.ID, code)
+	}
+	if err != nil {
+		http.Redirect(w, r, "/login/2fa?error=Terjadi+kesalahan,+coba+lagi", http.StatusSeeOther)
+		return
+	}
+
+	if !valid {
+		server.recordLoginAttempt(attempt, user, models.LoginInvalidCode)
+		if err := user.RegisterFailedLogin(server.DB, server.AppConfig.LoginMaxFailures, server.AppConfig.LoginLockout); err != nil {
+			log.Printf("Gagal mencatat login gagal untuk %s: %v", user.Email, err)
+		}
+		http.Redirect(w, r, "/login/2fa?error=Kode+verifikasi+salah", http.StatusSeeOther)
+		return
+	}
+
+	server.completeLogin(w, r, user, attempt)
+}
+
+// checkTOTP memvalidasi kode TOTP; kode yang sudah pernah dipakai ditolak.
+func (server *Server) checkTOTP(user *models.User, code string) (bool, error) {
+	step, ok := twofactor.Validate(user.TOTPSecret, code, time.Now(), user.TOTPLastStep)
+	if !ok {
+		return false, nil
+	}
+
+	return user.UseTOTPStep(server.DB, step)
+}
+
+func isRecoveryCode(code string) bool {
+	return len(strings.TrimSpace(code)) > 6
+}
+
+// TwoFactorSettings menampilkan status 2FA. Jika belum aktif, secret baru dibuat dan
+// ditampilkan sebagai QR code; secret baru disimpan permanen setelah dikonfirmasi.
+func (server *Server) TwoFactorSettings(w http.ResponseWriter, r *http.Request) {
+	user := auth.CurrentUser(server.DB, w, r)
+	if user == nil {
+		http.Redirect(w, r, "/login", http.StatusSeeOther)
+		return
+	}
+
+	data := map[string]interface{}{
+		"user":     user,
+		"Enabled":  user.HasTwoFactor(),
+		"Required": user.RequiresTwoFactor(),
+		"Error":    r.URL.Query().Get("error"),
+		"Message":  r.URL.Query().Get("message"),
+	}
+
+	if user.HasTwoFactor() {
+		recoveryCodeModel := models.RecoveryCode{}
+		remaining, err := recoveryCodeModel.CountUnusedRecoveryCodes(server.DB, user.ID)
+		if err != nil {
+			http.Error(w, "Gagal memuat kode cadangan", http.StatusInternalServerError)
+			return
+		}
+		data["RemainingRecoveryCodes"] = remaining
+
+		_ = server.getRenderer().HTML(w, http.StatusOK, "account_2fa", data)
+		return
+	}
+
+	enrollment, err := server.twoFactorEnrollment(w, r, user)
+	if err != nil {
+		http.Error(w, "Gagal membuat secret 2FA", http.StatusInternalServerError)
+		return
+	}
+	data["QRCode"] = enrollment.QRCode
+	data["Secret"] = enrollment.Secret
+
+	_ = server.getRenderer().HTML(w, http.StatusOK, "account_2fa", data)
+}
+
+// twoFactorEnrollment memakai secret yang sedang didaftarkan di sesi, atau membuat yang baru.
+func (server *Server) twoFactorEnrollment(w http.ResponseWriter, r *http.Request, user *models.User) (*twofactor.Enrollment, error) {
+	if keyURL := auth.GetTwoFactorSetup(r); keyURL != "" {
+		if enrollment, err := twofactor.EnrollmentFromURL(keyURL); err == nil {
+			return enrollment, nil
+		}
+	}
+
+	enrollment, err := twofactor.NewEnrollment(server.AppConfig.AppName, user.Email)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := auth.SetTwoFactorSetup(w, r, enrollment.URL); err != nil {
+		return nil, err
+	}
+
+	return enrollment, nil
+}
+
+// EnableTwoFactor mengaktifkan 2FA setelah user memasukkan kode pertama dari aplikasi
+// authenticator, lalu menampilkan kode cadangan satu kali.
+func (server *Server) EnableTwoFactor(w http.ResponseWriter, r *http.Request) {
+	user := auth.CurrentUser(server.DB, w, r)
+	if user == nil {
+		http.Redirect(w, r, "/login", http.StatusSeeOther)
+		return
+	}
+
+	if user.HasTwoFactor() {
+		http.Redirect(w, r, "/account/2fa", http.StatusSeeOther)
+		return
+	}
+
+	keyURL := auth.GetTwoFactorSetup(r)
+	enrollment, err := twofactor.EnrollmentFromURL(keyURL)
+	if keyURL == "" || err != nil {
+		http.Redirect(w, r, "/account/2fa?error=Secret+2FA+kedaluwarsa,+silakan+scan+ulang+QR+code", http.StatusSeeOther)
+		return
+	}
+
+	step, ok := twofactor.Validate(enrollment.Secret, r.FormValue("code"), time.Now(), 0)
+	if !ok {
+		http.Redirect(w, r, "/account/2fa?error=Kode+verifikasi+salah", http.StatusSeeOther)
+		return
+	}
+
+	recoveryCodes, err := twofactor.NewRecoveryCodes()
+	if err != nil {
+		http.Redirect(w, r, "/account/2fa?error=Gagal+membuat+kode+cadangan", http.StatusSeeOther)
+		return
+	}
+
+	if err := user.EnableTwoFactor(server.DB, enrollment.Secret, step, recoveryCodes); err != nil {
+		http.Redirect(w, r, "/account/2fa?error=Gagal+mengaktifkan+2FA", http.StatusSeeOther)
+		return
+	}
+
+	_ = auth.SetTwoFactorSetup(w, r, "")
+
+	server.renderRecoveryCodes(w, user, recoveryCodes)
+}
+
+// RegenerateRecoveryCodes mengganti semua kode cadangan; membutuhkan kode TOTP yang valid.
+func (server *Server) RegenerateRecoveryCodes(w http.ResponseWriter, r *http.Request) {
+	user := auth.CurrentUser(server.DB, w, r)
+	if user == nil {
+		http.Redirect(w, r, "/login", http.StatusSeeOther)
+		return
+	}
+
+	if valid, err := server.checkTOTP(user, r.FormValue("code")); err != nil || !valid {
+		http.Redirect(w, r, "/account/2fa?error=Kode+verifikasi+salah", http.StatusSeeOther)
+		return
+	}
+
+	recoveryCodes, err := twofactor.NewRecoveryCodes()
+	if err != nil {
+		http.Redirect(w, r, "/account/2fa?error=Gagal+membuat+kode+cadangan", http.StatusSeeOther)
+		return
+	}
+
+	recoveryCodeModel := models.RecoveryCode{}
+	if err := recoveryCodeModel.ReplaceRecoveryCodes(server.DB, user.ID, recoveryCodes); err != nil {
+		http.Redirect(w, r, "/account/2fa?error=Gagal+membuat+kode+cadangan", http.StatusSeeOther)
+		return
+	}
+
+	server.renderRecoveryCodes(w, user, recoveryCodes)
+}
+
+func (server *Server) renderRecoveryCodes(w http.ResponseWriter, user *models.User, recoveryCodes []string) {
+	_ = server.getRenderer().HTML(w, http.StatusOK, "account_2fa_recovery_codes", map[string]interface{}{
+		"user":          user,
+		"RecoveryCodes": recoveryCodes,
+	})
+}
+
+// DisableTwoFactor mematikan 2FA dengan konfirmasi password dan kode TOTP.
+// Tidak tersedia untuk role yang wajib memakai 2FA.
+func (server *Server) DisableTwoFactor(w http.ResponseWriter, r *http.Request) {
+	user := auth.CurrentUser(server.DB, w, r)
+	if user == nil {
+		http.Redirect(w, r, "/login", http.StatusSeeOther)
+		return
+	}
+
+	if user.RequiresTwoFactor() {
+		http.Redirect(w, r, "/account/2fa?error=2FA+wajib+untuk+role+Anda", http.StatusSeeOther)
+		return
+	}
+
+	if user.HasPassword() && !auth.ComparePassword(r.FormValue("password"), user.Password) {
+		http.Redirect(w, r, "/account/2fa?error=Password+salah", http.StatusSeeOther)
+		return
+	}
+
+	if valid, err := server.checkTOTP(user, r.FormValue("code")); err != nil || !valid {
+		http.Redirect(w, r, "/account/2fa?error=Kode+verifikasi+salah", http.StatusSeeOther)
+		return
+	}
+
+	if err := user.DisableTwoFactor(server.DB); err != nil {
+		http.Redirect(w, r, "/account/2fa?error=Gagal+menonaktifkan+2FA", http.StatusSeeOther)
+		return
+	}
+
+	http.Redirect(w, r, "/account/2fa?message=2FA+berhasil+dinonaktifkan", http.StatusSeeOther)
+}
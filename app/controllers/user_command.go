@@ -0,0 +1,178 @@
+package controllers
+
+import (
+	"bufio"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/gieart87/gotoko/app/core/session/auth"
+	"github.com/gieart87/gotoko/app/models"
+	"github.com/google/uuid"
+	"github.com/urfave/cli"
+	"gorm.io/gorm"
+)
+
+// commandPassword mengambil password dari --password atau --password-stdin.
+// Jika keduanya kosong dibuat password acak (generated = true) yang dicetak ke layar.
+func commandPassword(c *cli.Context) (password string, generated bool, err error) {
+	password = c.String("password")
+
+	if c.Bool("password-stdin") {
+		line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+		if err != nil && line == "" {
+			return "", false, fmt.Errorf("cannot read password from stdin: %w", err)
+		}
+		password = strings.TrimRight(line, "\r\n")
+	}
+
+	if password == "" {
+		password, err = randomPassword()
+		return password, true, err
+	}
+
+	if len(password) < minPasswordLength {
+		return "", false, fmt.Errorf("password must be at least %d characters", minPasswordLength)
+	}
+
+	return password, false, nil
+}
+
+func randomPassword() (string, error) {
+	buf := make([]byte, 12)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+
+	return base64.RawURLEncoding.EncodeToString(buf), nil
+}
+
+func (server *Server) commandRole(name string) (*models.Role, error) {
+	roleModel := models.Role{}
+	role, err := roleModel.FindByName(server.DB, name)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, cli.NewExitError(fmt.Sprintf("role %q not found, run role:seed first", name), 1)
+	}
+
+	return role, err
+}
+
+func (server *Server) commandUser(email string) (*models.User, error) {
+	if email == "" {
+		return nil, cli.NewExitError("--email is required", 1)
+	}
+
+	userModel := models.User{}
+	user, err := userModel.FindByEmail(server.DB, email)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, cli.NewExitError(fmt.Sprintf("user %s not found", email), 1)
+	}
+
+	return user, err
+}
+
+func (server *Server) createUserFromCLI(email string, firstName string, lastName string, roleName string, password string, generated bool) error {
+	email = strings.TrimSpace(email)
+	if email == "" {
+		return cli.NewExitError("--email is required", 1)
+	}
+
+	role, err := server.commandRole(roleName)
+	if err != nil {
+		return err
+	}
+
+	userModel := models.User{}
+	if existUser, _ := userModel.FindByEmail(server.DB, email); existUser != nil {
+		return cli.NewExitError(fmt.Sprintf("user %s already exists, use user:set-role or user:reset-password", email), 1)
+	}
+
+	hashedPassword, err := auth.MakePassword(password)
+	if err != nil {
+		return err
+	}
+
+	// Akun dari CLI dibuat oleh admin, email dianggap sudah terverifikasi
+	now := time.Now()
+	user, err := userModel.CreateUser(server.DB, &models.User{
+		ID:              uuid.New().String(),
+		EmailVerifiedAt: &now,
+		RoleID:          role.ID,
+		FirstName:       firstName,
+		LastName:        lastName,
+		Email:           email,
+		Password:        hashedPassword,
+	})
+	if err != nil {
+		return err
+	}
+
+	if generated {
+		if err := user.ForcePasswordReset(server.DB); err != nil {
+			return err
+		}
+	}
+
+	fmt.Printf("User %s created with role %s.\n", user.Email, role.Name)
+	printGeneratedPassword(password, generated)
+	return nil
+}
+
+func (server *Server) setUserRoleFromCLI(email string, roleName string) error {
+	if roleName == "" {
+		return cli.NewExitError("--role is required", 1)
+	}
+
+	user, err := server.commandUser(email)
+	if err != nil {
+		return err
+	}
+
+	role, err := server.commandRole(roleName)
+	if err != nil {
+		return err
+	}
+
+	if err := user.AssignRole(server.DB, role.ID); err != nil {
+		return err
+	}
+
+	fmt.Printf("User %s now has role %s.\n", user.Email, role.Name)
+	return nil
+}
+
+func (server *Server) resetPasswordFromCLI(email string, password string, forceChange bool, generated bool) error {
+	user, err := server.commandUser(email)
+	if err != nil {
+		return err
+	}
+
+	hashedPassword, err := auth.MakePassword(password)
+	if err != nil {
+		return err
+	}
+
+	if err := user.UpdatePassword(server.DB, hashedPassword); err != nil {
+		return err
+	}
+
+	if forceChange {
+		if err := user.ForcePasswordReset(server.DB); err != nil {
+			return err
+		}
+	}
+
+	fmt.Printf("Password for %s updated.\n", user.Email)
+	printGeneratedPassword(password, generated)
+	return nil
+}
+
+func printGeneratedPassword(password string, generated bool) {
+	if generated {
+		fmt.Printf("Generated password: %s (must be changed after the first login)\n", password)
+	}
+}
@@ -1,8 +1,11 @@
 package controllers
 
 import (
-	"fmt"
+	"log"
 	"net/http"
+	netmail "net/mail"
+	"net/url"
+	"time"
 
 	"github.com/gieart87/gotoko/app/core/session/auth"
 	"github.com/gieart87/gotoko/app/models"
@@ -12,7 +15,7 @@ import (
 )
 
 func (server *Server) Login(w http.ResponseWriter, r *http.Request) {
-	render := render.New(render.Options{
+	render := newRender(render.Options{
 		Layout:     "layout",
 		Extensions: []string{".html", ".tmpl"},
 	})
@@ -20,7 +23,9 @@ func (server *Server) Login(w http.ResponseWriter, r *http.Request) {
 	errorMsg := r.URL.Query().Get("error")
 
 	_ = render.HTML(w, http.StatusOK, "login", map[string]interface{}{
-		"Error": errorMsg,
+		"Error":        errorMsg,
+		"Message":      r.URL.Query().Get("message"),
+		"SSOProviders": server.SSO.Providers(),
 	})
 }
 
@@ -38,28 +43,89 @@ func (server *Server) DoLogin(w http.ResponseWriter, r *http.Request) {
         return
     }
 
+    attempt := server.newLoginAttempt(r, email)
+    if blocked, err := server.loginIPBlocked(attempt.IPAddress); err != nil || blocked {
+        server.recordLoginAttempt(attempt, nil, models.LoginIPLimited)
+        http.Redirect(w, r, "/login?error=Terlalu+banyak+percobaan+login,+coba+lagi+nanti", http.StatusSeeOther)
+        return
+    }
+
     userModel := models.User{}
     user, err := userModel.FindByEmail(server.DB, email)
-    if err != nil || user == nil || !auth.ComparePassword(password, user.Password) {
+    if err != nil || user == nil {
+        server.recordLoginAttempt(attempt, nil, models.LoginUnknownEmail)
         http.Redirect(w, r, "/login?error=email+atau+password+salah", http.StatusSeeOther)
         return
     }
 
-   session, err := auth.GetSessionUser(r)
-	if err != nil {
-    fmt.Println("Gagal mengambil sesi lama:", err)
-	}
+    // Akun yang terkunci atau masih dalam jeda tidak diperiksa passwordnya sama sekali
+    if wait := user.LoginRetryAfter(time.Now()); wait > 0 {
+        reason := models.LoginThrottled
+        if user.IsLocked() {
+            reason = models.LoginLocked
+        }
+        server.recordLoginAttempt(attempt, user, reason)
+        http.Redirect(w, r, "/login?error="+url.QueryEscape(loginRetryMessage(wait)), http.StatusSeeOther)
+        return
+    }
 
+    if !auth.ComparePassword(password, user.Password) {
+        server.recordLoginAttempt(attempt, user, models.LoginInvalidPassword)
+        if err := user.RegisterFailedLogin(server.DB, server.AppConfig.LoginMaxFailures, server.AppConfig.LoginLockout); err != nil {
+            log.Printf("Gagal mencatat login gagal untuk %s: %v", user.Email, err)
+        }
+        http.Redirect(w, r, "/login?error=email+atau+password+salah", http.StatusSeeOther)
+        return
+    }
 
-	session.Values["user_id"] = user.ID
+    if user.IsSuspended() {
+        server.recordLoginAttempt(attempt, user, models.LoginSuspended)
+        http.Redirect(w, r, "/login?error=Akun+Anda+dinonaktifkan", http.StatusSeeOther)
+        return
+    }
+
+    if server.AppConfig.RequireVerifiedLogin && !user.IsEmailVerified() {
+        http.Redirect(w, r, "/email/verification?email="+url.QueryEscape(user.Email)+"&error=Email+belum+diverifikasi", http.StatusSeeOther)
+        return
+    }
 
+    // Password benar, tetapi user dengan 2FA aktif baru login setelah memasukkan kode
+    if user.HasTwoFactor() {
+        if err := auth.SetPendingTwoFactor(w, r, user.ID); err != nil {
+            http.Redirect(w, r, "/login?error=Gagal menyimpan sesi baru", http.StatusSeeOther)
+            return
+        }
+        http.Redirect(w, r, "/login/2fa", http.StatusSeeOther)
+        return
+    }
 
-	if err := session.Save(r, w); err != nil {
-    http.Redirect(w, r, "/login?error=Gagal menyimpan sesi baru", http.StatusSeeOther)
-    return
+    server.completeLogin(w, r, user, attempt)
 }
-   
-    if user.Role.Name == consts.RoleAdmin || user.Role.Name == consts.RoleOperator {
+
+// completeLogin menyimpan sesi login setelah semua langkah autentikasi berhasil,
+// lalu mengarahkan user ke halaman yang sesuai.
+func (server *Server) completeLogin(w http.ResponseWriter, r *http.Request, user *models.User, attempt *models.LoginAttempt) {
+    if err := auth.SetSessionUser(w, r, user); err != nil {
+        http.Redirect(w, r, "/login?error=Gagal menyimpan sesi baru", http.StatusSeeOther)
+        return
+    }
+
+    server.recordLoginAttempt(attempt, user, models.LoginSuccess)
+    if err := user.ResetFailedLogins(server.DB); err != nil {
+        log.Printf("Gagal me-reset login gagal untuk %s: %v", user.Email, err)
+    }
+
+    if user.MustResetPassword {
+        http.Redirect(w, r, "/password/change?error=Silakan+ganti+password+Anda", http.StatusSeeOther)
+        return
+    }
+
+    if user.RequiresTwoFactor() && !user.HasTwoFactor() {
+        http.Redirect(w, r, "/account/2fa?error=Aktifkan+autentikasi+dua+langkah+untuk+melanjutkan", http.StatusSeeOther)
+        return
+    }
+
+    if user.Can(consts.PermissionDashboardView) {
         http.Redirect(w, r, "/admin/dashboard", http.StatusSeeOther)
         return
     }
@@ -68,7 +134,7 @@ func (server *Server) DoLogin(w http.ResponseWriter, r *http.Request) {
 }
 
 func (server *Server) Register(w http.ResponseWriter, r *http.Request) {
-	render := render.New(render.Options{
+	render := newRender(render.Options{
 		Layout:     "layout",
 		Extensions: []string{".html", ".tmpl"},
 	})
@@ -96,6 +162,11 @@ func (server *Server) DoRegister(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if address, err := netmail.ParseAddress(email); err != nil || address.Address != email {
+		http.Redirect(w, r, "/register?error=Email tidak valid", http.StatusSeeOther)
+		return
+	}
+
 	userModel := models.User{}
 	existUser, _ := userModel.FindByEmail(server.DB, email)
 	if existUser != nil {
@@ -117,25 +188,33 @@ func (server *Server) DoRegister(w http.ResponseWriter, r *http.Request) {
 		Password:  hashedPassword,
 	}
 
+	roleModel := models.Role{}
+	if role, err := roleModel.FindByName(server.DB, consts.RoleCustomer); err == nil {
+		params.RoleID = role.ID
+	}
+
 	user, err := userModel.CreateUser(server.DB, params)
 	if err != nil {
 		http.Redirect(w, r, "/register?error=Registration failed", http.StatusSeeOther)
 		return
 	}
 
-	session, err := auth.GetSessionUser(r)
-	if err != nil {
-		http.Redirect(w, r, "/?error=Gagal membuat sesi", http.StatusSeeOther)
+	if err := server.sendVerificationEmail(r.Context(), user); err != nil {
+		log.Printf("Gagal mengirim email verifikasi ke %s: %v", user.Email, err)
+	}
+
+	// Login ditunda sampai email diverifikasi jika diwajibkan
+	if server.AppConfig.RequireVerifiedLogin {
+		http.Redirect(w, r, "/email/verification?email="+url.QueryEscape(user.Email)+"&message="+url.QueryEscape(verificationSentMsg), http.StatusSeeOther)
 		return
 	}
 
-	session.Values["user_id"] = user.ID
-	if err := session.Save(r, w); err != nil {
+	if err := auth.SetSessionUser(w, r, user); err != nil {
 		http.Redirect(w, r, "/?error=Gagal menyimpan sesi", http.StatusSeeOther)
 		return
 	}
 
-	http.Redirect(w, r, "/", http.StatusSeeOther)
+	http.Redirect(w, r, "/?message="+url.QueryEscape(verificationSentMsg), http.StatusSeeOther)
 }
 
 func (server *Server) Logout(w http.ResponseWriter, r *http.Request) {
@@ -155,4 +234,83 @@ func (server *Server) Logout(w http.ResponseWriter, r *http.Request) {
 
     // Redirect ke halaman login agar admin bisa masuk kembali jika mau
     http.Redirect(w, r, "/login", http.StatusSeeOther)
-}
\ No newline at end of file
+}
+
+const minPasswordLength = 8
+
+// ChangePassword menampilkan form ganti password, termasuk saat admin mewajibkan reset password.
+func (server *Server) ChangePassword(w http.ResponseWriter, r *http.Request) {
+	render := newRender(render.Options{
+		Layout:     "layout",
+		Extensions: []string{".html", ".tmpl"},
+	})
+
+	user := auth.CurrentUser(server.DB, w, r)
+	if user == nil {
+		http.Redirect(w, r, "/login", http.StatusSeeOther)
+		return
+	}
+
+	_ = render.HTML(w, http.StatusOK, "change_password", map[string]interface{}{
+		"user":    user,
+		"Forced":  user.MustResetPassword,
+		"Error":   r.URL.Query().Get("error"),
+		"Message": r.URL.Query().Get("message"),
+	})
+}
+
+func (server *Server) DoChangePassword(w http.ResponseWriter, r *http.Request) {
+	user := auth.CurrentUser(server.DB, w, r)
+	if user == nil {
+		http.Redirect(w, r, "/login", http.StatusSeeOther)
+		return
+	}
+
+	currentPassword := r.FormValue("current_password")
+	newPassword := r.FormValue("password")
+
+	// User dari login eksternal belum punya password, jadi boleh langsung membuat password
+	if user.HasPassword() && !auth.ComparePassword(currentPassword, user.Password) {
+		http.Redirect(w, r, "/password/change?error=Password+lama+salah", http.StatusSeeOther)
+		return
+	}
+
+	if len(newPassword) < minPasswordLength {
+		http.Redirect(w, r, "/password/change?error=Password+minimal+8+karakter", http.StatusSeeOther)
+		return
+	}
+
+	if newPassword != r.FormValue("password_confirmation") {
+		http.Redirect(w, r, "/password/change?error=Konfirmasi+password+tidak+sama", http.StatusSeeOther)
+		return
+	}
+
+	if user.HasPassword() && auth.ComparePassword(newPassword, user.Password) {
+		http.Redirect(w, r, "/password/change?error=Password+baru+harus+berbeda", http.StatusSeeOther)
+		return
+	}
+
+	hashedPassword, err := auth.MakePassword(newPassword)
+	if err != nil {
+		http.Redirect(w, r, "/password/change?error=Terjadi+kesalahan+saat+membuat+password", http.StatusSeeOther)
+		return
+	}
+
+	if err := user.UpdatePassword(server.DB, hashedPassword); err != nil {
+		http.Redirect(w, r, "/password/change?error=Gagal+menyimpan+password", http.StatusSeeOther)
+		return
+	}
+
+	// Sesi lain sudah tidak berlaku, sesi saat ini diperbarui dengan token baru
+	if err := auth.SetSessionUser(w, r, user); err != nil {
+		http.Redirect(w, r, "/login", http.StatusSeeOther)
+		return
+	}
+
+	if user.Can(consts.PermissionDashboardView) {
+		http.Redirect(w, r, "/admin/dashboard", http.StatusSeeOther)
+		return
+	}
+
+	http.Redirect(w, r, "/?message=Password+berhasil+diganti", http.StatusSeeOther)
+}
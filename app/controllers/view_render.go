@@ -0,0 +1,30 @@
+package controllers
+
+import (
+	"html/template"
+	"io"
+
+	"github.com/gieart87/gotoko/app/middlewares"
+	"github.com/unrolled/render"
+)
+
+// viewRender membungkus unrolled/render agar setiap template mendapat token CSRF:
+// {{ .csrfField }} untuk form dan {{ .csrfToken }} untuk request AJAX (header X-CSRF-Token).
+type viewRender struct {
+	*render.Render
+}
+
+func newRender(options ...render.Options) *viewRender {
+	return &viewRender{Render: render.New(options...)}
+}
+
+func (v *viewRender) HTML(w io.Writer, status int, name string, binding interface{}, htmlOpt ...render.HTMLOptions) error {
+	if data, ok := binding.(map[string]interface{}); ok {
+		if token := middlewares.CSRFTokenFromWriter(w); token != "" {
+			data["csrfToken"] = token
+			data["csrfField"] = template.HTML(`<input type="hidden" name="` + middlewares.CSRFFieldName + `" value="` + template.HTMLEscapeString(token) + `">`)
+		}
+	}
+
+	return v.Render.HTML(w, status, name, binding, htmlOpt...)
+}
@@ -0,0 +1,100 @@
+package ai
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+var ErrCircuitOpen = errors.New("ai: circuit breaker open, service dianggap mati")
+
+type breakerState int
+
+const (
+	stateClosed breakerState = iota
+	stateOpen
+	stateHalfOpen
+)
+
+// Breaker adalah circuit breaker sederhana. Setelah Threshold kegagalan berturut-turut
+// semua request langsung ditolak selama Cooldown, lalu satu request percobaan diizinkan
+// (half-open). Jika berhasil breaker tertutup kembali, jika gagal terbuka lagi.
+type Breaker struct {
+	mu        sync.Mutex
+	state     breakerState
+	failures  int
+	openedAt  time.Time
+	threshold int
+	cooldown  time.Duration
+	now       func() time.Time
+}
+
+func NewBreaker(threshold int, cooldown time.Duration) *Breaker {
+	if threshold < 1 {
+		threshold = 1
+	}
+
+	return &Breaker{
+		threshold: threshold,
+		cooldown:  cooldown,
+		now:       time.Now,
+	}
+}
+
+// Allow mengembalikan ErrCircuitOpen jika request tidak boleh dikirim.
+func (b *Breaker) Allow() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.state {
+	case stateOpen:
+		if b.now().Sub(b.openedAt) < b.cooldown {
+			return ErrCircuitOpen
+		}
+		b.state = stateHalfOpen
+		return nil
+	case stateHalfOpen:
+		// Hanya satu request percobaan yang boleh berjalan
+		return ErrCircuitOpen
+	default:
+		return nil
+	}
+}
+
+func (b *Breaker) Success() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.state = stateClosed
+	b.failures = 0
+}
+
+func (b *Breaker) Failure() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.failures++
+	if b.state == stateHalfOpen || b.failures >= b.threshold {
+		b.state = stateOpen
+		b.openedAt = b.now()
+	}
+}
+
+// Cancel dipanggil jika request dibatalkan pemanggil. Tidak dihitung sebagai kegagalan,
+// tetapi percobaan half-open dilepas agar request berikutnya bisa mencoba lagi.
+func (b *Breaker) Cancel() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.state == stateHalfOpen {
+		b.state = stateOpen
+	}
+}
+
+// Open menandakan breaker sedang menolak request.
+func (b *Breaker) Open() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.state == stateOpen && b.now().Sub(b.openedAt) < b.cooldown
+}
@@ -0,0 +1,109 @@
+package ai
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+// fakeClock menggantikan time.Now pada Breaker agar cooldown bisa diuji tanpa menunggu.
+type fakeClock struct {
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time {
+	return c.now
+}
+
+func (c *fakeClock) Advance(d time.Duration) {
+	c.now = c.now.Add(d)
+}
+
+func newTestBreaker(threshold int, cooldown time.Duration) (*Breaker, *fakeClock) {
+	clock := &fakeClock{now: time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)}
+	breaker := NewBreaker(threshold, cooldown)
+	breaker.now = clock.Now
+
+	return breaker, clock
+}
+
+func TestBreakerOpensAfterThreshold(t *testing.T) {
+	breaker, _ := newTestBreaker(3, time.Minute)
+
+	for i := 0; i < 2; i++ {
+		if err := breaker.Allow(); err != nil {
+			t.Fatalf("Allow() after %d failures = %v", i, err)
+		}
+		breaker.Failure()
+	}
+	if breaker.Open() {
+		t.Fatal("breaker open before threshold")
+	}
+
+	breaker.Failure()
+	if !breaker.Open() {
+		t.Fatal("breaker not open after threshold")
+	}
+	if err := breaker.Allow(); !errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("Allow() = %v, want %v", err, ErrCircuitOpen)
+	}
+}
+
+func TestBreakerSuccessResetsFailures(t *testing.T) {
+	breaker, _ := newTestBreaker(2, time.Minute)
+
+	breaker.Failure()
+	breaker.Success()
+	breaker.Failure()
+
+	if breaker.Open() {
+		t.Fatal("failures must be consecutive to open the breaker")
+	}
+}
+
+func TestBreakerHalfOpenAfterCooldown(t *testing.T) {
+	tests := []struct {
+		name      string
+		probe     func(b *Breaker)
+		wantOpen  bool
+		wantAllow bool
+	}{
+		{"probe succeeds", (*Breaker).Success, false, true},
+		{"probe fails", (*Breaker).Failure, true, false},
+		// Percobaan yang dibatalkan tidak dihitung gagal, request berikutnya boleh mencoba lagi
+		{"probe cancelled", (*Breaker).Cancel, false, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			breaker, clock := newTestBreaker(1, time.Minute)
+			breaker.Failure()
+
+			clock.Advance(59 * time.Second)
+			if err := breaker.Allow(); !errors.Is(err, ErrCircuitOpen) {
+				t.Fatalf("Allow() during cooldown = %v", err)
+			}
+
+			clock.Advance(time.Second)
+			if breaker.Open() {
+				t.Fatal("Open() must be false once cooldown has passed")
+			}
+			if err := breaker.Allow(); err != nil {
+				t.Fatalf("half-open probe rejected: %v", err)
+			}
+			// Hanya satu request percobaan saat half-open
+			if err := breaker.Allow(); !errors.Is(err, ErrCircuitOpen) {
+				t.Fatalf("second half-open request = %v, want %v", err, ErrCircuitOpen)
+			}
+
+			tt.probe(breaker)
+
+			if breaker.Open() != tt.wantOpen {
+				t.Errorf("Open() = %v, want %v", breaker.Open(), tt.wantOpen)
+			}
+			if allowed := breaker.Allow() == nil; allowed != tt.wantAllow {
+				t.Errorf("Allow() allowed = %v, want %v", allowed, tt.wantAllow)
+			}
+		})
+	}
+}
@@ -0,0 +1,211 @@
+package ai
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Endpoint milik service AI (FastAPI).
+const (
+	pathSearch        = "/search"
+	pathSync          = "/sync"
+	pathAddProduct    = "/add-product"
+	pathDeleteProduct = "/delete-product"
+)
+
+type Config struct {
+	BaseURL          string
+	Timeout          time.Duration
+	SearchTimeout    time.Duration
+	MaxRetries       int
+	RetryBackoff     time.Duration
+	BreakerThreshold int
+	BreakerCooldown  time.Duration
+	OutboxInterval   time.Duration
+}
+
+// Product adalah data produk yang dikirim ke service AI.
+type Product struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+	Desc string `json:"desc"`
+}
+
+// StatusError dikembalikan jika service AI membalas dengan status selain 2xx.
+type StatusError struct {
+	StatusCode int
+	Body       string
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("ai: unexpected status %d: %s", e.StatusCode, e.Body)
+}
+
+// retryable: error jaringan, 429 dan 5xx boleh dicoba lagi, 4xx lainnya tidak.
+func retryable(err error) bool {
+	var statusErr *StatusError
+	if errors.As(err, &statusErr) {
+		return statusErr.StatusCode == http.StatusTooManyRequests || statusErr.StatusCode >= 500
+	}
+
+	return !errors.Is(err, context.Canceled)
+}
+
+type Client struct {
+	baseURL      string
+	http         *http.Client
+	searchHTTP   *http.Client
+	maxRetries   int
+	retryBackoff time.Duration
+	breaker      *Breaker
+}
+
+func NewClient(config Config) *Client {
+	if config.BaseURL == "" {
+		config.BaseURL = "http://localhost:8000"
+	}
+	if config.Timeout <= 0 {
+		config.Timeout = 5 * time.Second
+	}
+	if config.SearchTimeout <= 0 {
+		config.SearchTimeout = 2 * time.Second
+	}
+	if config.RetryBackoff <= 0 {
+		config.RetryBackoff = 200 * time.Millisecond
+	}
+	if config.BreakerCooldown <= 0 {
+		config.BreakerCooldown = 30 * time.Second
+	}
+
+	return &Client{
+		baseURL:      strings.TrimSuffix(config.BaseURL, "/"),
+		http:         &http.Client{Timeout: config.Timeout},
+		searchHTTP:   &http.Client{Timeout: config.SearchTimeout},
+		maxRetries:   config.MaxRetries,
+		retryBackoff: config.RetryBackoff,
+		breaker:      NewBreaker(config.BreakerThreshold, config.BreakerCooldown),
+	}
+}
+
+// Available bernilai false selama circuit breaker terbuka.
+func (c *Client) Available() bool {
+	return !c.breaker.Open()
+}
+
+// Search meminta saran kata kunci ke service AI. Dipakai langsung oleh halaman pencarian,
+// sehingga tidak di-retry dan memakai timeout yang lebih pendek.
+func (c *Client) Search(ctx context.Context, keyword string) ([]string, error) {
+	var result struct {
+		Results []string `json:"results"`
+	}
+
+	err := c.call(ctx, c.searchHTTP, 0, pathSearch, map[string]string{"keyword": keyword}, &result)
+	if err != nil {
+		return nil, err
+	}
+
+	return result.Results, nil
+}
+
+// SyncProducts mengirim ulang seluruh katalog ke service AI.
+func (c *Client) SyncProducts(ctx context.Context, products []Product) error {
+	return c.call(ctx, c.http, c.maxRetries, pathSync, map[string][]Product{"products": products}, nil)
+}
+
+func (c *Client) UpsertProduct(ctx context.Context, product Product) error {
+	return c.call(ctx, c.http, c.maxRetries, pathAddProduct, product, nil)
+}
+
+func (c *Client) DeleteProduct(ctx context.Context, productID string) error {
+	return c.call(ctx, c.http, c.maxRetries, pathDeleteProduct, map[string]string{"id": productID}, nil)
+}
+
+// call mengirim POST JSON dengan retry + exponential backoff. Kegagalan setelah semua
+// retry dihitung satu kali oleh circuit breaker.
+func (c *Client) call(ctx context.Context, client *http.Client, retries int, path string, payload interface{}, out interface{}) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	if err := c.breaker.Allow(); err != nil {
+		return err
+	}
+
+	for attempt := 0; ; attempt++ {
+		err = c.post(ctx, client, path, body, out)
+		if err == nil {
+			c.breaker.Success()
+			return nil
+		}
+
+		if attempt >= retries || !retryable(err) {
+			break
+		}
+
+		// Backoff: 1x, 2x, 4x ... ditambah jitter agar tidak serentak
+		backoff := c.retryBackoff << attempt
+		backoff += time.Duration(rand.Int63n(int64(backoff)/2 + 1))
+
+		select {
+		case <-ctx.Done():
+			c.breaker.Cancel()
+			return ctx.Err()
+		case <-time.After(backoff):
+		}
+	}
+
+	if errors.Is(err, context.Canceled) {
+		c.breaker.Cancel()
+		return err
+	}
+
+	// Error 4xx berarti service hidup tetapi request kita salah, bukan alasan membuka breaker
+	var statusErr *StatusError
+	if errors.As(err, &statusErr) && !retryable(err) {
+		c.breaker.Success()
+	} else {
+		c.breaker.Failure()
+	}
+
+	return err
+}
+
+func (c *Client) post(ctx context.Context, client *http.Client, path string, body []byte, out interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.baseURL+path, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		snippet, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return &StatusError{StatusCode: resp.StatusCode, Body: strings.TrimSpace(string(snippet))}
+	}
+
+	if out == nil {
+		_, _ = io.Copy(io.Discard, resp.Body)
+		return nil
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("ai: invalid response from %s: %w", path, err)
+	}
+
+	return nil
+}
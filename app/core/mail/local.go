@@ -0,0 +1,64 @@
+package mail
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// LogMailer hanya mencatat penerima dan subjek email ke log. Isi email tidak dicetak karena
+// berisi token reset password dan verifikasi; pakai FileMailer untuk melihat isinya.
+type LogMailer struct {
+	from string
+}
+
+func NewLogMailer(from string) *LogMailer {
+	return &LogMailer{from: from}
+}
+
+func (m *LogMailer) Send(ctx context.Context, message Message) error {
+	if err := validate(message); err != nil {
+		return err
+	}
+
+	log.Printf("mail: to=%s subject=%q (body not logged)", message.To, message.Subject)
+	return nil
+}
+
+// FileMailer menyimpan setiap email sebagai file .eml di folder lokal, untuk development.
+type FileMailer struct {
+	from string
+	dir  string
+}
+
+func NewFileMailer(from string, dir string) *FileMailer {
+	if dir == "" {
+		dir = "storage/mail"
+	}
+
+	return &FileMailer{from: from, dir: dir}
+}
+
+func (m *FileMailer) Send(ctx context.Context, message Message) error {
+	if err := validate(message); err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(m.dir, 0o755); err != nil {
+		return err
+	}
+
+	name := fmt.Sprintf("%s-%s.eml", time.Now().Format("20060102-150405"), uuid.New().String())
+	path := filepath.Join(m.dir, name)
+	if err := os.WriteFile(path, compose(m.from, message), 0o644); err != nil {
+		return err
+	}
+
+	log.Printf("mail: to=%s subject=%q saved to %s", message.To, message.Subject, path)
+	return nil
+}
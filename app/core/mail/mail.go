@@ -0,0 +1,77 @@
+package mail
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Message adalah email teks sederhana. Body dikirim sebagai text/plain UTF-8.
+type Message struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+// Mailer mengirim email transaksional (reset password, verifikasi email, dll).
+type Mailer interface {
+	Send(ctx context.Context, message Message) error
+}
+
+type Config struct {
+	Driver string // "smtp", "file" atau "log" (hanya mencatat penerima dan subjek)
+	From   string
+
+	FileDir string // folder file .eml untuk driver "file"
+
+	SMTPHost     string
+	SMTPPort     string
+	SMTPUsername string
+	SMTPPassword string
+}
+
+func New(config Config) (Mailer, error) {
+	switch config.Driver {
+	case "":
+		return nil, errors.New("mail: MAIL_DRIVER is not configured (smtp, file or log)")
+	case "log":
+		return NewLogMailer(config.From), nil
+	case "file":
+		return NewFileMailer(config.From, config.FileDir), nil
+	case "smtp":
+		return NewSMTPMailer(config)
+	default:
+		return nil, errors.New("mail: unknown driver " + config.Driver)
+	}
+}
+
+// compose membentuk email lengkap (header + body) dalam format RFC 5322.
+func compose(from string, message Message) []byte {
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "From: %s\r\n", from)
+	fmt.Fprintf(&b, "To: %s\r\n", message.To)
+	fmt.Fprintf(&b, "Subject: %s\r\n", headerValue(message.Subject))
+	fmt.Fprintf(&b, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	b.WriteString("MIME-Version: 1.0\r\n")
+	b.WriteString("Content-Type: text/plain; charset=UTF-8\r\n")
+	b.WriteString("\r\n")
+	b.WriteString(strings.ReplaceAll(message.Body, "\n", "\r\n"))
+
+	return []byte(b.String())
+}
+
+// headerValue membuang baris baru agar nilai header tidak bisa menyisipkan header lain.
+func headerValue(value string) string {
+	return strings.NewReplacer("\r", " ", "\n", " ").Replace(value)
+}
+
+func validate(message Message) error {
+	if message.To == "" || strings.ContainsAny(message.To, "\r\n") {
+		return errors.New("mail: invalid recipient")
+	}
+
+	return nil
+}
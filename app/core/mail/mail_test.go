@@ -0,0 +1,41 @@
+package mail
+
+import (
+	"bytes"
+	"context"
+	"log"
+	"strings"
+	"testing"
+)
+
+func TestNewRequiresDriver(t *testing.T) {
+	if _, err := New(Config{}); err == nil {
+		t.Fatal("New() without driver must fail")
+	}
+	if _, err := New(Config{Driver: "file", FileDir: t.TempDir()}); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestLogMailerDoesNotLogBody(t *testing.T) {
+	var buf bytes.Buffer
+	original := log.Writer()
+	log.SetOutput(&buf)
+	defer log.SetOutput(original)
+
+	err := NewLogMailer("no-reply@example.com").Send(context.Background(), Message{
+		To:      "user@example.com",
+		Subject: "Reset password",
+		Body:    "https://example.com/password/reset?token=secret-token",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if strings.Contains(buf.String(), "secret-token") {
+		t.Errorf("log contains mail body: %s", buf.String())
+	}
+	if !strings.Contains(buf.String(), "user@example.com") {
+		t.Errorf("log does not mention recipient: %s", buf.String())
+	}
+}
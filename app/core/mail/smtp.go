@@ -0,0 +1,59 @@
+package mail
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/smtp"
+)
+
+// SMTPMailer mengirim email lewat server SMTP. STARTTLS dipakai otomatis jika didukung server.
+type SMTPMailer struct {
+	from string
+	addr string
+	auth smtp.Auth
+}
+
+func NewSMTPMailer(config Config) (*SMTPMailer, error) {
+	if config.SMTPHost == "" {
+		return nil, errors.New("mail: SMTP host is required")
+	}
+	if config.From == "" {
+		return nil, errors.New("mail: sender address is required")
+	}
+
+	port := config.SMTPPort
+	if port == "" {
+		port = "587"
+	}
+
+	mailer := &SMTPMailer{
+		from: config.From,
+		addr: net.JoinHostPort(config.SMTPHost, port),
+	}
+	if config.SMTPUsername != "" {
+		mailer.auth = smtp.PlainAuth("", config.SMTPUsername, config.SMTPPassword, config.SMTPHost)
+	}
+
+	return mailer, nil
+}
+
+func (m *SMTPMailer) Send(ctx context.Context, message Message) error {
+	if err := validate(message); err != nil {
+		return err
+	}
+
+	// net/smtp tidak mendukung context, jadi pengiriman dijalankan di goroutine
+	// dan request tidak ikut menunggu jika context sudah selesai.
+	done := make(chan error, 1)
+	go func() {
+		done <- smtp.SendMail(m.addr, m.auth, m.from, []string{message.To}, compose(m.from, message))
+	}()
+
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,408 @@
+package search
+
+import (
+	"sort"
+	"strings"
+	"sync"
+	"unicode"
+)
+
+// Bobot setiap field saat dokumen diindeks.
+const (
+	weightName        = 3.0
+	weightSku         = 3.0
+	weightCategory    = 2.0
+	weightDescription = 1.0
+)
+
+// Skor kecocokan berdasarkan cara term ditemukan.
+const (
+	scoreExact   = 1.0
+	scoreSynonym = 0.9
+	scorePrefix  = 0.8
+	scoreFuzzy   = 0.7
+)
+
+// Document adalah data produk yang disimpan di index.
+type Document struct {
+	ID          string
+	Name        string
+	Slug        string
+	Sku         string
+	Categories  []string
+	Description string
+}
+
+// Hit adalah satu hasil pencarian. Terms berisi term index yang cocok
+// (dipakai untuk highlight).
+type Hit struct {
+	Document Document
+	Score    float64
+	Matched  int
+	Terms    []string
+}
+
+// Index adalah inverted index in-memory yang aman dipakai bersamaan oleh banyak goroutine.
+type Index struct {
+	mu       sync.RWMutex
+	docs     map[string]Document
+	postings map[string]map[string]float64
+	terms    []string
+	dirty    bool
+	synonyms map[string][]string
+}
+
+func NewIndex() *Index {
+	return &Index{
+		docs:     map[string]Document{},
+		postings: map[string]map[string]float64{},
+		synonyms: map[string][]string{},
+	}
+}
+
+// Add menambahkan atau mengganti dokumen dengan ID yang sama.
+func (idx *Index) Add(doc Document) {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+
+	idx.remove(doc.ID)
+	idx.docs[doc.ID] = doc
+
+	idx.addField(doc.ID, doc.Name, weightName)
+	idx.addField(doc.ID, doc.Sku, weightSku)
+	for _, category := range doc.Categories {
+		idx.addField(doc.ID, category, weightCategory)
+	}
+	idx.addField(doc.ID, doc.Description, weightDescription)
+}
+
+// Replace mengganti seluruh isi index, dipakai saat index dibangun ulang.
+func (idx *Index) Replace(docs []Document) {
+	fresh := NewIndex()
+	for _, doc := range docs {
+		fresh.Add(doc)
+	}
+
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+
+	idx.docs = fresh.docs
+	idx.postings = fresh.postings
+	idx.dirty = true
+}
+
+func (idx *Index) Remove(id string) {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+
+	idx.remove(id)
+}
+
+func (idx *Index) Len() int {
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+
+	return len(idx.docs)
+}
+
+// SetSynonyms mengganti daftar sinonim. Setiap grup berisi istilah yang dianggap sama,
+// misalnya nama merek dan nama generik obat: ["panadol", "paracetamol", "parasetamol"].
+func (idx *Index) SetSynonyms(groups [][]string) {
+	synonyms := map[string][]string{}
+	for _, group := range groups {
+		var tokens []string
+		for _, phrase := range group {
+			tokens = append(tokens, Tokenize(phrase)...)
+		}
+
+		for _, token := range tokens {
+			for _, other := range tokens {
+				if other != token && !contains(synonyms[token], other) {
+					synonyms[token] = append(synonyms[token], other)
+				}
+			}
+		}
+	}
+
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+
+	idx.synonyms = synonyms
+}
+
+// Search mencari dokumen yang cocok dengan query. Setiap kata query dicocokkan secara
+// exact, lewat sinonim, sebagai awalan kata, atau dengan toleransi salah ketik (Levenshtein).
+// Dokumen yang cocok dengan lebih banyak kata query diurutkan lebih dulu.
+func (idx *Index) Search(query string, limit int) []Hit {
+	tokens := Tokenize(query)
+	if len(tokens) == 0 {
+		return nil
+	}
+
+	idx.ensureTerms()
+
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+
+	type docScore struct {
+		score   float64
+		matched int
+		terms   map[string]bool
+	}
+	scores := map[string]*docScore{}
+
+	for _, token := range tokens {
+		// Skor terbaik per dokumen untuk kata query ini
+		best := map[string]float64{}
+		matchedTerms := map[string][]string{}
+
+		for term, termScore := range idx.candidates(token) {
+			for docID, weight := range idx.postings[term] {
+				if s := termScore * weight; s > best[docID] {
+					best[docID] = s
+				}
+				matchedTerms[docID] = append(matchedTerms[docID], term)
+			}
+		}
+
+		for docID, s := range best {
+			entry, ok := scores[docID]
+			if !ok {
+				entry = &docScore{terms: map[string]bool{}}
+				scores[docID] = entry
+			}
+			entry.score += s
+			entry.matched++
+			for _, term := range matchedTerms[docID] {
+				entry.terms[term] = true
+			}
+		}
+	}
+
+	hits := make([]Hit, 0, len(scores))
+	for docID, entry := range scores {
+		hit := Hit{
+			Document: idx.docs[docID],
+			Score:    entry.score,
+			Matched:  entry.matched,
+		}
+		for term := range entry.terms {
+			hit.Terms = append(hit.Terms, term)
+		}
+		sort.Strings(hit.Terms)
+		hits = append(hits, hit)
+	}
+
+	sort.Slice(hits, func(i, j int) bool {
+		if hits[i].Matched != hits[j].Matched {
+			return hits[i].Matched > hits[j].Matched
+		}
+		if hits[i].Score != hits[j].Score {
+			return hits[i].Score > hits[j].Score
+		}
+		return hits[i].Document.Name < hits[j].Document.Name
+	})
+
+	if limit > 0 && len(hits) > limit {
+		hits = hits[:limit]
+	}
+
+	return hits
+}
+
+// candidates mengembalikan term index yang dianggap cocok dengan token beserta skornya.
+func (idx *Index) candidates(token string) map[string]float64 {
+	result := map[string]float64{}
+	set := func(term string, score float64) {
+		if score > result[term] {
+			result[term] = score
+		}
+	}
+
+	if _, ok := idx.postings[token]; ok {
+		set(token, scoreExact)
+	}
+
+	for _, synonym := range idx.synonyms[token] {
+		if _, ok := idx.postings[synonym]; ok {
+			set(synonym, scoreSynonym)
+		}
+	}
+
+	// Awalan kata, dipakai juga untuk autocomplete
+	if len([]rune(token)) >= 2 {
+		start := sort.SearchStrings(idx.terms, token)
+		for i := start; i < len(idx.terms) && strings.HasPrefix(idx.terms[i], token); i++ {
+			set(idx.terms[i], scorePrefix)
+		}
+	}
+
+	maxEdits := maxEditsFor(token)
+	if maxEdits > 0 {
+		for _, term := range idx.terms {
+			if distance := editDistance(token, term, maxEdits); distance <= maxEdits {
+				set(term, scoreFuzzy-0.1*float64(distance-1))
+			}
+		}
+
+		// Salah ketik pada nama merek tetap menemukan nama generiknya,
+		// walaupun nama merek itu sendiri tidak ada di katalog
+		for term, synonyms := range idx.synonyms {
+			distance := editDistance(token, term, maxEdits)
+			if distance > maxEdits {
+				continue
+			}
+
+			for _, synonym := range synonyms {
+				if _, ok := idx.postings[synonym]; ok {
+					set(synonym, scoreFuzzy-0.1*float64(distance))
+				}
+			}
+		}
+	}
+
+	return result
+}
+
+// editDistance seperti levenshtein, tetapi langsung menolak kata yang selisih panjangnya
+// sudah melebihi max.
+func editDistance(a string, b string, max int) int {
+	diff := len([]rune(a)) - len([]rune(b))
+	if diff > max || -diff > max {
+		return max + 1
+	}
+
+	return levenshtein(a, b, max)
+}
+
+func (idx *Index) addField(docID string, text string, weight float64) {
+	for _, token := range Tokenize(text) {
+		docs, ok := idx.postings[token]
+		if !ok {
+			docs = map[string]float64{}
+			idx.postings[token] = docs
+			idx.dirty = true
+		}
+
+		if weight > docs[docID] {
+			docs[docID] = weight
+		}
+	}
+}
+
+func (idx *Index) remove(id string) {
+	if _, ok := idx.docs[id]; !ok {
+		return
+	}
+
+	for term, docs := range idx.postings {
+		delete(docs, id)
+		if len(docs) == 0 {
+			delete(idx.postings, term)
+			idx.dirty = true
+		}
+	}
+
+	delete(idx.docs, id)
+}
+
+// ensureTerms menyusun ulang daftar term terurut (untuk prefix search) jika index berubah.
+func (idx *Index) ensureTerms() {
+	idx.mu.RLock()
+	dirty := idx.dirty
+	idx.mu.RUnlock()
+
+	if !dirty {
+		return
+	}
+
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+
+	terms := make([]string, 0, len(idx.postings))
+	for term := range idx.postings {
+		terms = append(terms, term)
+	}
+	sort.Strings(terms)
+
+	idx.terms = terms
+	idx.dirty = false
+}
+
+// Tokenize memecah teks menjadi kata huruf kecil (huruf dan angka).
+func Tokenize(text string) []string {
+	return strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+// maxEditsFor menentukan batas salah ketik: kata pendek harus tepat,
+// kata panjang (nama obat) boleh salah hingga 2 huruf.
+func maxEditsFor(token string) int {
+	switch length := len([]rune(token)); {
+	case length < 4:
+		return 0
+	case length < 8:
+		return 1
+	default:
+		return 2
+	}
+}
+
+// levenshtein menghitung jarak edit antara a dan b. Perhitungan dihentikan lebih awal
+// dan mengembalikan max+1 jika jarak sudah pasti melebihi max.
+func levenshtein(a string, b string, max int) int {
+	ra, rb := []rune(a), []rune(b)
+
+	previous := make([]int, len(rb)+1)
+	current := make([]int, len(rb)+1)
+	for j := range previous {
+		previous[j] = j
+	}
+
+	for i := 1; i <= len(ra); i++ {
+		current[0] = i
+		rowMin := current[0]
+
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+
+			current[j] = minInt(previous[j]+1, current[j-1]+1, previous[j-1]+cost)
+			if current[j] < rowMin {
+				rowMin = current[j]
+			}
+		}
+
+		if rowMin > max {
+			return max + 1
+		}
+
+		previous, current = current, previous
+	}
+
+	return previous[len(rb)]
+}
+
+func minInt(values ...int) int {
+	min := values[0]
+	for _, value := range values[1:] {
+		if value < min {
+			min = value
+		}
+	}
+
+	return min
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,110 @@
+package search
+
+import (
+	"reflect"
+	"testing"
+)
+
+func newTestIndex() *Index {
+	idx := NewIndex()
+	idx.Replace([]Document{
+		{ID: "paracetamol", Name: "Paracetamol 500mg", Categories: []string{"Analgesik"}, Description: "Pereda demam dan nyeri"},
+		{ID: "amoxicillin", Name: "Amoxicillin 500mg", Categories: []string{"Antibiotik"}},
+		{ID: "ibuprofen", Name: "Ibuprofen 400mg", Categories: []string{"Analgesik"}, Description: "Pereda nyeri"},
+		{ID: "panadol", Name: "Panadol Extra", Categories: []string{"Analgesik"}},
+		{ID: "vitamin", Name: "Vitamin Anak", Description: "Aman untuk ibu hamil"},
+	})
+	idx.SetSynonyms([][]string{
+		{"panadol", "paracetamol"},
+		{"amoxil", "amoxicillin"},
+	})
+
+	return idx
+}
+
+func hitIDs(hits []Hit) []string {
+	ids := []string{}
+	for _, hit := range hits {
+		ids = append(ids, hit.Document.ID)
+	}
+
+	return ids
+}
+
+func TestSearch(t *testing.T) {
+	idx := newTestIndex()
+
+	tests := []struct {
+		name  string
+		query string
+		limit int
+		want  []string
+	}{
+		// Salah ketik nama obat
+		{"misspelled generic", "parasetamol", 0, []string{"paracetamol", "panadol"}},
+		{"missing letter", "amoxicilin", 0, []string{"amoxicillin"}},
+		{"misspelled long name", "ibuprofn", 0, []string{"ibuprofen"}},
+		{"short word must be exact", "amx", 0, []string{}},
+		{"unknown word", "xyzol", 0, []string{}},
+
+		// Sinonim merek <-> generik
+		{"generic finds brand", "paracetamol", 0, []string{"paracetamol", "panadol"}},
+		{"brand finds generic", "panadol", 0, []string{"panadol", "paracetamol"}},
+		{"brand not in catalog", "amoxil", 0, []string{"amoxicillin"}},
+		{"misspelled brand not in catalog", "amoxl", 0, []string{"amoxicillin"}},
+
+		// Urutan autocomplete
+		{"name prefix above description match", "ibu", 0, []string{"ibuprofen", "vitamin"}},
+		{"more matched words first", "para 500", 0, []string{"paracetamol", "amoxicillin"}},
+		{"ties ordered by name", "500", 0, []string{"amoxicillin", "paracetamol"}},
+		{"limit", "500", 1, []string{"amoxicillin"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := hitIDs(idx.Search(tt.query, tt.limit)); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Search(%q) = %v, want %v", tt.query, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSearchMatchedTerms(t *testing.T) {
+	idx := newTestIndex()
+
+	hits := idx.Search("panadl", 0)
+	if got := hitIDs(hits); !reflect.DeepEqual(got, []string{"panadol", "paracetamol"}) {
+		t.Fatalf("Search(panadl) = %v", got)
+	}
+	if !reflect.DeepEqual(hits[1].Terms, []string{"paracetamol"}) {
+		t.Errorf("terms for generic = %v, want [paracetamol]", hits[1].Terms)
+	}
+}
+
+func TestSearchAfterRemove(t *testing.T) {
+	idx := newTestIndex()
+	idx.Remove("panadol")
+
+	if got := hitIDs(idx.Search("panadol", 0)); !reflect.DeepEqual(got, []string{"paracetamol"}) {
+		t.Errorf("Search(panadol) after Remove = %v, want [paracetamol]", got)
+	}
+}
+
+func TestLevenshtein(t *testing.T) {
+	tests := []struct {
+		a, b string
+		max  int
+		want int
+	}{
+		{"paracetamol", "parasetamol", 2, 1},
+		{"amoxicilin", "amoxicillin", 2, 1},
+		{"obat", "obat", 1, 0},
+		{"panadol", "ibuprofen", 2, 3},
+	}
+
+	for _, tt := range tests {
+		if got := editDistance(tt.a, tt.b, tt.max); got != tt.want {
+			t.Errorf("editDistance(%q, %q, %d) = %d, want %d", tt.a, tt.b, tt.max, got, tt.want)
+		}
+	}
+}
@@ -1,10 +1,15 @@
 package auth
 
 import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
 	"fmt"
 	"log"
 	"net/http"
+	"time"
 
+	"github.com/gieart87/gotoko/app/core/sso"
 	"github.com/gieart87/gotoko/app/models"
 	"github.com/google/uuid"
 	"github.com/gorilla/securecookie"
@@ -52,8 +57,11 @@ func CurrentUser(db *gorm.DB, w http.ResponseWriter, r *http.Request) *models.Us
 		return nil
 	}
 
+	// Akun yang dinonaktifkan admin, atau sesi yang dibuat sebelum password diganti,
+	// langsung keluar
 	user, err := (&models.User{}).FindByID(db, userID)
-	if err != nil {
+	sessionToken, _ := session.Values["session_token"].(string)
+	if err != nil || user.IsSuspended() || sessionToken != user.RememberToken {
 		session.Options.MaxAge = -1
 		session.Save(r, w)
 		return nil
@@ -61,6 +69,165 @@ func CurrentUser(db *gorm.DB, w http.ResponseWriter, r *http.Request) *models.Us
 
 	return user
 }
+
+// SetSessionUser menandai user sebagai login di sesi saat ini. RememberToken ikut disimpan
+// agar sesi ini otomatis berakhir ketika password diganti.
+func SetSessionUser(w http.ResponseWriter, r *http.Request, user *models.User) error {
+	session, err := store.Get(r, sessionUser)
+	if err != nil {
+		session = sessions.NewSession(store, sessionUser)
+	}
+
+	delete(session.Values, "2fa-user-id")
+	delete(session.Values, "2fa-expires")
+	session.Values["user_id"] = user.ID
+	session.Values["session_token"] = user.RememberToken
+
+	return session.Save(r, w)
+}
+
+// Login dua langkah: setelah password benar user disimpan sebagai "pending" sampai kode 2FA
+// dimasukkan. Status pending hanya berlaku beberapa menit.
+const twoFactorPendingTTL = 5 * time.Minute
+
+func SetPendingTwoFactor(w http.ResponseWriter, r *http.Request, userID string) error {
+	session, err := store.Get(r, sessionUser)
+	if err != nil {
+		session = sessions.NewSession(store, sessionUser)
+	}
+
+	delete(session.Values, "user_id")
+	session.Values["2fa-user-id"] = userID
+	session.Values["2fa-expires"] = time.Now().Add(twoFactorPendingTTL).Unix()
+
+	return session.Save(r, w)
+}
+
+// GetPendingTwoFactor mengembalikan ID user yang sedang menunggu kode 2FA.
+func GetPendingTwoFactor(r *http.Request) (string, bool) {
+	session, err := store.Get(r, sessionUser)
+	if err != nil {
+		return "", false
+	}
+
+	userID, _ := session.Values["2fa-user-id"].(string)
+	expires, _ := session.Values["2fa-expires"].(int64)
+	if userID == "" || time.Now().Unix() > expires {
+		return "", false
+	}
+
+	return userID, true
+}
+
+func ClearPendingTwoFactor(w http.ResponseWriter, r *http.Request) {
+	session, err := store.Get(r, sessionUser)
+	if err != nil {
+		return
+	}
+
+	delete(session.Values, "2fa-user-id")
+	delete(session.Values, "2fa-expires")
+	_ = session.Save(r, w)
+}
+
+// SetTwoFactorSetup menyimpan URL otpauth:// secret yang sedang didaftarkan, sampai user
+// mengonfirmasi dengan kode pertama. Kosongkan keyURL untuk menghapusnya.
+func SetTwoFactorSetup(w http.ResponseWriter, r *http.Request, keyURL string) error {
+	session, err := store.Get(r, sessionUser)
+	if err != nil {
+		return err
+	}
+
+	if keyURL == "" {
+		delete(session.Values, "2fa-setup-url")
+	} else {
+		session.Values["2fa-setup-url"] = keyURL
+	}
+
+	return session.Save(r, w)
+}
+
+func GetTwoFactorSetup(r *http.Request) string {
+	session, err := store.Get(r, sessionUser)
+	if err != nil {
+		return ""
+	}
+
+	keyURL, _ := session.Values["2fa-setup-url"].(string)
+	return keyURL
+}
+
+// SetSSORequest menyimpan state, nonce dan verifier PKCE login OIDC sampai callback diterima.
+func SetSSORequest(w http.ResponseWriter, r *http.Request, request *sso.AuthRequest) error {
+	session, err := store.Get(r, sessionUser)
+	if err != nil {
+		session = sessions.NewSession(store, sessionUser)
+	}
+
+	session.Values["sso-provider"] = request.Provider
+	session.Values["sso-state"] = request.State
+	session.Values["sso-nonce"] = request.Nonce
+	session.Values["sso-verifier"] = request.Verifier
+
+	return session.Save(r, w)
+}
+
+// PopSSORequest mengambil lalu menghapus request OIDC dari sesi, agar callback hanya bisa dipakai sekali.
+func PopSSORequest(w http.ResponseWriter, r *http.Request) (*sso.AuthRequest, bool) {
+	session, err := store.Get(r, sessionUser)
+	if err != nil {
+		return nil, false
+	}
+
+	request := &sso.AuthRequest{}
+	request.Provider, _ = session.Values["sso-provider"].(string)
+	request.State, _ = session.Values["sso-state"].(string)
+	request.Nonce, _ = session.Values["sso-nonce"].(string)
+	request.Verifier, _ = session.Values["sso-verifier"].(string)
+
+	for _, key := range []string{"sso-provider", "sso-state", "sso-nonce", "sso-verifier"} {
+		delete(session.Values, key)
+	}
+	_ = session.Save(r, w)
+
+	return request, request.State != ""
+}
+
+// CSRFToken mengembalikan token CSRF sesi saat ini, dan membuatnya jika belum ada.
+func CSRFToken(w http.ResponseWriter, r *http.Request) (string, error) {
+	session, err := store.Get(r, sessionUser)
+	if err != nil {
+		session = sessions.NewSession(store, sessionUser)
+	}
+
+	if token, ok := session.Values["csrf-token"].(string); ok && token != "" {
+		return token, nil
+	}
+
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	token := base64.RawURLEncoding.EncodeToString(buf)
+
+	session.Values["csrf-token"] = token
+	if err := session.Save(r, w); err != nil {
+		return "", err
+	}
+
+	return token, nil
+}
+
+func ValidCSRFToken(r *http.Request, token string) bool {
+	session, err := store.Get(r, sessionUser)
+	if err != nil {
+		return false
+	}
+
+	expected, _ := session.Values["csrf-token"].(string)
+	return expected != "" && subtle.ConstantTimeCompare([]byte(expected), []byte(token)) == 1
+}
+
 func GetCartID(w http.ResponseWriter, r *http.Request) string {
 	
 	session, err := store.Get(r, sessionUser)
@@ -76,4 +243,71 @@ func GetCartID(w http.ResponseWriter, r *http.Request) string {
 	_ = session.Save(r, w)
 	return session.Values["cart-id"].(string)
 	
-}
\ No newline at end of file
+}
+
+// maxSessionSearchQueries adalah jumlah ID pencarian terakhir yang diingat per sesi.
+const maxSessionSearchQueries = 20
+
+// AddSearchQuery mencatat ID pencarian yang dibuat untuk sesi ini, agar ?sq= hanya diterima
+// dari pencarian milik sesi sendiri.
+func AddSearchQuery(w http.ResponseWriter, r *http.Request, searchQueryID string) {
+	session, err := store.Get(r, sessionUser)
+	if err != nil {
+		session = sessions.NewSession(store, sessionUser)
+	}
+
+	searchQueryIDs, _ := session.Values["search-query-ids"].([]string)
+	searchQueryIDs = append(searchQueryIDs, searchQueryID)
+	if len(searchQueryIDs) > maxSessionSearchQueries {
+		searchQueryIDs = searchQueryIDs[len(searchQueryIDs)-maxSessionSearchQueries:]
+	}
+
+	session.Values["search-query-ids"] = searchQueryIDs
+	_ = session.Save(r, w)
+}
+
+// HasSearchQuery mengecek apakah searchQueryID dibuat untuk sesi ini.
+func HasSearchQuery(r *http.Request, searchQueryID string) bool {
+	session, err := store.Get(r, sessionUser)
+	if err != nil || searchQueryID == "" {
+		return false
+	}
+
+	searchQueryIDs, _ := session.Values["search-query-ids"].([]string)
+	for _, id := range searchQueryIDs {
+		if id == searchQueryID {
+			return true
+		}
+	}
+
+	return false
+}
+
+// SetSearchClick menyimpan pencarian dan produk yang terakhir diklik dari hasil pencarian,
+// agar add-to-cart berikutnya bisa dihubungkan ke pencarian tersebut.
+func SetSearchClick(w http.ResponseWriter, r *http.Request, searchQueryID string, productID string) {
+	session, err := store.Get(r, sessionUser)
+	if err != nil {
+		session = sessions.NewSession(store, sessionUser)
+	}
+
+	session.Values["search-query-id"] = searchQueryID
+	session.Values["search-product-id"] = productID
+	_ = session.Save(r, w)
+}
+
+// GetSearchClick mengembalikan ID pencarian jika productID adalah produk yang terakhir diklik dari pencarian.
+func GetSearchClick(r *http.Request, productID string) (string, bool) {
+	session, err := store.Get(r, sessionUser)
+	if err != nil {
+		return "", false
+	}
+
+	searchQueryID, _ := session.Values["search-query-id"].(string)
+	clickedProductID, _ := session.Values["search-product-id"].(string)
+	if searchQueryID == "" || clickedProductID != productID {
+		return "", false
+	}
+
+	return searchQueryID, true
+}
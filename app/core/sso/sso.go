@@ -0,0 +1,230 @@
+package sso
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/coreos/go-oidc/v3/oidc"
+	"golang.org/x/oauth2"
+)
+
+var (
+	ErrUnknownProvider = errors.New("sso: unknown provider")
+	ErrInvalidNonce    = errors.New("sso: invalid nonce")
+)
+
+// Config adalah konfigurasi satu provider OpenID Connect (Google, Keycloak, stub lokal, dll).
+type Config struct {
+	Name         string // dipakai di URL: /auth/{name}
+	DisplayName  string // label tombol login, contoh "Google"
+	IssuerURL    string
+	ClientID     string
+	ClientSecret string
+	RedirectURL  string
+	Scopes       []string // default: openid, email, profile
+}
+
+// Identity adalah data user dari ID token yang sudah diverifikasi.
+type Identity struct {
+	Provider      string
+	Subject       string
+	Email         string
+	EmailVerified bool
+	Name          string
+	GivenName     string
+	FamilyName    string
+}
+
+// Provider membungkus satu provider OIDC. Discovery (.well-known/openid-configuration)
+// dilakukan saat pertama dipakai, sehingga aplikasi tetap bisa jalan walaupun provider sedang mati.
+type Provider struct {
+	config Config
+
+	mu       sync.Mutex
+	oauth    *oauth2.Config
+	verifier *oidc.IDTokenVerifier
+}
+
+func NewProvider(config Config) *Provider {
+	if config.DisplayName == "" {
+		config.DisplayName = config.Name
+	}
+	if len(config.Scopes) == 0 {
+		config.Scopes = []string{oidc.ScopeOpenID, "email", "profile"}
+	}
+
+	return &Provider{config: config}
+}
+
+func (p *Provider) Name() string {
+	return p.config.Name
+}
+
+func (p *Provider) DisplayName() string {
+	return p.config.DisplayName
+}
+
+func (p *Provider) discover(ctx context.Context) (*oauth2.Config, *oidc.IDTokenVerifier, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.oauth != nil {
+		return p.oauth, p.verifier, nil
+	}
+
+	provider, err := oidc.NewProvider(ctx, p.config.IssuerURL)
+	if err != nil {
+		return nil, nil, fmt.Errorf("sso: discovery %s: %w", p.config.Name, err)
+	}
+
+	p.oauth = &oauth2.Config{
+		ClientID:     p.config.ClientID,
+		ClientSecret: p.config.ClientSecret,
+		RedirectURL:  p.config.RedirectURL,
+		Endpoint:     provider.Endpoint(),
+		Scopes:       p.config.Scopes,
+	}
+	p.verifier = provider.Verifier(&oidc.Config{ClientID: p.config.ClientID})
+
+	return p.oauth, p.verifier, nil
+}
+
+// AuthCodeURL membuat URL login di provider untuk request yang sudah disimpan di sesi.
+func (p *Provider) AuthCodeURL(ctx context.Context, request *AuthRequest) (string, error) {
+	oauthConfig, _, err := p.discover(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	return oauthConfig.AuthCodeURL(request.State, oidc.Nonce(request.Nonce), oauth2.S256ChallengeOption(request.Verifier)), nil
+}
+
+// Exchange menukar authorization code dengan token, lalu memverifikasi tanda tangan,
+// audience, masa berlaku dan nonce ID token.
+func (p *Provider) Exchange(ctx context.Context, code string, request *AuthRequest) (*Identity, error) {
+	oauthConfig, idVerifier, err := p.discover(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	token, err := oauthConfig.Exchange(ctx, code, oauth2.VerifierOption(request.Verifier))
+	if err != nil {
+		return nil, fmt.Errorf("sso: exchange code: %w", err)
+	}
+
+	rawIDToken, ok := token.Extra("id_token").(string)
+	if !ok {
+		return nil, errors.New("sso: token response has no id_token")
+	}
+
+	idToken, err := idVerifier.Verify(ctx, rawIDToken)
+	if err != nil {
+		return nil, fmt.Errorf("sso: verify id_token: %w", err)
+	}
+
+	if request.Nonce == "" || subtle.ConstantTimeCompare([]byte(idToken.Nonce), []byte(request.Nonce)) != 1 {
+		return nil, ErrInvalidNonce
+	}
+
+	var claims struct {
+		Email         string `json:"email"`
+		EmailVerified bool   `json:"email_verified"`
+		Name          string `json:"name"`
+		GivenName     string `json:"given_name"`
+		FamilyName    string `json:"family_name"`
+	}
+	if err := idToken.Claims(&claims); err != nil {
+		return nil, err
+	}
+
+	return &Identity{
+		Provider:      p.config.Name,
+		Subject:       idToken.Subject,
+		Email:         strings.TrimSpace(claims.Email),
+		EmailVerified: claims.EmailVerified,
+		Name:          claims.Name,
+		GivenName:     claims.GivenName,
+		FamilyName:    claims.FamilyName,
+	}, nil
+}
+
+// AuthRequest berisi nilai acak untuk satu kali login: state (CSRF), nonce (replay ID token)
+// dan verifier PKCE. Disimpan di sesi sampai callback dari provider diterima.
+type AuthRequest struct {
+	Provider string
+	State    string
+	Nonce    string
+	Verifier string
+}
+
+func NewAuthRequest(provider string) (*AuthRequest, error) {
+	state, err := randomString()
+	if err != nil {
+		return nil, err
+	}
+
+	nonce, err := randomString()
+	if err != nil {
+		return nil, err
+	}
+
+	return &AuthRequest{
+		Provider: provider,
+		State:    state,
+		Nonce:    nonce,
+		Verifier: oauth2.GenerateVerifier(),
+	}, nil
+}
+
+func randomString() (string, error) {
+	buf := make([]byte, 24)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+
+	return base64.RawURLEncoding.EncodeToString(buf), nil
+}
+
+// Registry menyimpan semua provider yang dikonfigurasi.
+type Registry struct {
+	providers map[string]*Provider
+}
+
+func NewRegistry(configs []Config) *Registry {
+	registry := &Registry{providers: map[string]*Provider{}}
+	for _, config := range configs {
+		registry.providers[config.Name] = NewProvider(config)
+	}
+
+	return registry
+}
+
+func (r *Registry) Get(name string) (*Provider, error) {
+	provider, ok := r.providers[name]
+	if !ok {
+		return nil, ErrUnknownProvider
+	}
+
+	return provider, nil
+}
+
+// Providers mengembalikan semua provider, urut berdasarkan nama, untuk tombol di halaman login.
+func (r *Registry) Providers() []*Provider {
+	providers := make([]*Provider, 0, len(r.providers))
+	for _, provider := range r.providers {
+		providers = append(providers, provider)
+	}
+
+	sort.Slice(providers, func(i, j int) bool {
+		return providers[i].config.Name < providers[j].config.Name
+	})
+
+	return providers
+}
@@ -0,0 +1,98 @@
+package storage
+
+import (
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// LocalStorage menyimpan file di disk dan dilayani oleh http.FileServer di app/server.go.
+type LocalStorage struct {
+	Root    string
+	BaseURL string
+}
+
+func NewLocalStorage(root string, baseURL string) *LocalStorage {
+	if root == "" {
+		root = "public"
+	}
+	if baseURL == "" {
+		baseURL = "/"
+	}
+
+	return &LocalStorage{
+		Root:    root,
+		BaseURL: baseURL,
+	}
+}
+
+func (s *LocalStorage) path(key string) (string, error) {
+	cleaned, err := cleanKey(key)
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.Join(s.Root, filepath.FromSlash(cleaned)), nil
+}
+
+func (s *LocalStorage) Put(ctx context.Context, key string, data io.Reader, size int64, contentType string) error {
+	physicalPath, err := s.path(key)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(physicalPath), os.ModePerm); err != nil {
+		return err
+	}
+
+	// Tulis ke file sementara lalu rename agar file tidak pernah terbaca setengah jadi
+	tmp, err := os.CreateTemp(filepath.Dir(physicalPath), ".upload-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := io.Copy(tmp, data); err != nil {
+		tmp.Close()
+		return err
+	}
+
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	if err := os.Chmod(tmp.Name(), 0644); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp.Name(), physicalPath)
+}
+
+func (s *LocalStorage) Get(ctx context.Context, key string) (io.ReadCloser, error) {
+	physicalPath, err := s.path(key)
+	if err != nil {
+		return nil, err
+	}
+
+	return os.Open(physicalPath)
+}
+
+func (s *LocalStorage) Delete(ctx context.Context, key string) error {
+	physicalPath, err := s.path(key)
+	if err != nil {
+		return err
+	}
+
+	err = os.Remove(physicalPath)
+	if os.IsNotExist(err) {
+		return nil
+	}
+
+	return err
+}
+
+func (s *LocalStorage) URL(key string) string {
+	return strings.TrimSuffix(s.BaseURL, "/") + "/" + strings.TrimPrefix(key, "/")
+}
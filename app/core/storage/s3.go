@@ -0,0 +1,95 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"io"
+	"strings"
+
+	"github.com/minio/minio-go/v7"
+	"github.com/minio/minio-go/v7/pkg/credentials"
+)
+
+// S3Storage bekerja dengan AWS S3 maupun layanan S3-compatible seperti MinIO,
+// sehingga bisa dicoba secara lokal dengan menjalankan MinIO sebagai pengganti S3.
+type S3Storage struct {
+	client    *minio.Client
+	bucket    string
+	publicURL string
+}
+
+func NewS3Storage(config Config) (*S3Storage, error) {
+	if config.S3Endpoint == "" || config.S3Bucket == "" {
+		return nil, errors.New("storage: S3 endpoint and bucket are required")
+	}
+
+	client, err := minio.New(config.S3Endpoint, &minio.Options{
+		Creds:  credentials.NewStaticV4(config.S3AccessKey, config.S3SecretKey, ""),
+		Secure: config.S3UseSSL,
+		Region: config.S3Region,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	publicURL := config.S3PublicURL
+	if publicURL == "" {
+		scheme := "http://"
+		if config.S3UseSSL {
+			scheme = "https://"
+		}
+		publicURL = scheme + config.S3Endpoint + "/" + config.S3Bucket
+	}
+
+	return &S3Storage{
+		client:    client,
+		bucket:    config.S3Bucket,
+		publicURL: strings.TrimSuffix(publicURL, "/"),
+	}, nil
+}
+
+func (s *S3Storage) Put(ctx context.Context, key string, data io.Reader, size int64, contentType string) error {
+	cleaned, err := cleanKey(key)
+	if err != nil {
+		return err
+	}
+
+	_, err = s.client.PutObject(ctx, s.bucket, cleaned, data, size, minio.PutObjectOptions{
+		ContentType: contentType,
+	})
+
+	return err
+}
+
+func (s *S3Storage) Get(ctx context.Context, key string) (io.ReadCloser, error) {
+	cleaned, err := cleanKey(key)
+	if err != nil {
+		return nil, err
+	}
+
+	object, err := s.client.GetObject(ctx, s.bucket, cleaned, minio.GetObjectOptions{})
+	if err != nil {
+		return nil, err
+	}
+
+	// GetObject baru menghubungi server saat dibaca, Stat memastikan object memang ada
+	if _, err := object.Stat(); err != nil {
+		object.Close()
+		return nil, err
+	}
+
+	return object, nil
+}
+
+func (s *S3Storage) Delete(ctx context.Context, key string) error {
+	cleaned, err := cleanKey(key)
+	if err != nil {
+		return err
+	}
+
+	return s.client.RemoveObject(ctx, s.bucket, cleaned, minio.RemoveObjectOptions{})
+}
+
+func (s *S3Storage) URL(key string) string {
+	return s.publicURL + "/" + strings.TrimPrefix(key, "/")
+}
@@ -0,0 +1,58 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"io"
+	"path"
+	"strings"
+)
+
+var ErrInvalidKey = errors.New("storage: invalid key")
+
+// Storage adalah backend penyimpanan file upload. Key selalu berupa path relatif
+// dengan pemisah "/", contoh: "uploads/<hash>.jpg", dan disimpan apa adanya di database.
+type Storage interface {
+	Put(ctx context.Context, key string, data io.Reader, size int64, contentType string) error
+	Get(ctx context.Context, key string) (io.ReadCloser, error)
+	Delete(ctx context.Context, key string) error
+	URL(key string) string
+}
+
+type Config struct {
+	Driver string // "local" (default) atau "s3"
+
+	LocalRoot    string // folder fisik, default "public"
+	LocalBaseURL string // prefix URL, default "/"
+
+	S3Endpoint  string
+	S3Region    string
+	S3Bucket    string
+	S3AccessKey string
+	S3SecretKey string
+	S3UseSSL    bool
+	S3PublicURL string // jika kosong, URL dibentuk dari endpoint + bucket
+}
+
+func New(config Config) (Storage, error) {
+	switch config.Driver {
+	case "", "local":
+		return NewLocalStorage(config.LocalRoot, config.LocalBaseURL), nil
+	case "s3":
+		return NewS3Storage(config)
+	default:
+		return nil, errors.New("storage: unknown driver " + config.Driver)
+	}
+}
+
+// cleanKey menolak key kosong, absolut, atau yang keluar dari root (mis. "../").
+func cleanKey(key string) (string, error) {
+	cleaned := path.Clean("/" + strings.ReplaceAll(key, "\\", "/"))
+	cleaned = strings.TrimPrefix(cleaned, "/")
+
+	if cleaned == "" || cleaned == "." || cleaned != strings.TrimPrefix(key, "/") {
+		return "", ErrInvalidKey
+	}
+
+	return cleaned, nil
+}
@@ -0,0 +1,216 @@
+package storage
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeS3 adalah pengganti S3 minimal (path-style PUT/GET/HEAD/DELETE object) untuk test.
+type fakeS3 struct {
+	*httptest.Server
+
+	mu      sync.Mutex
+	objects map[string][]byte
+}
+
+func newFakeS3(t *testing.T) *fakeS3 {
+	t.Helper()
+
+	s3 := &fakeS3{objects: map[string][]byte{}}
+	s3.Server = httptest.NewServer(http.HandlerFunc(s3.serve))
+	t.Cleanup(s3.Close)
+
+	return s3
+}
+
+func (s *fakeS3) serve(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key := strings.TrimPrefix(r.URL.Path, "/")
+	switch r.Method {
+	case http.MethodPut:
+		data, err := readS3Body(r)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		s.objects[key] = data
+		w.Header().Set("ETag", `"etag"`)
+	case http.MethodGet, http.MethodHead:
+		data, ok := s.objects[key]
+		if !ok {
+			w.Header().Set("Content-Type", "application/xml")
+			w.WriteHeader(http.StatusNotFound)
+			if r.Method == http.MethodGet {
+				fmt.Fprintf(w, "<Error><Code>NoSuchKey</Code><Message>not found</Message><Key>%s</Key></Error>", key)
+			}
+			return
+		}
+		w.Header().Set("ETag", `"etag"`)
+		w.Header().Set("Last-Modified", time.Now().UTC().Format(http.TimeFormat))
+		w.Header().Set("Content-Type", "text/plain")
+		w.Header().Set("Content-Length", strconv.Itoa(len(data)))
+		if r.Method == http.MethodGet {
+			_, _ = w.Write(data)
+		}
+	case http.MethodDelete:
+		delete(s.objects, key)
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+func (s *fakeS3) keys() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var keys []string
+	for key := range s.objects {
+		keys = append(keys, key)
+	}
+
+	return keys
+}
+
+// readS3Body membaca body upload, termasuk format aws-chunked yang dipakai client
+// S3 saat upload lewat HTTP tanpa TLS.
+func readS3Body(r *http.Request) ([]byte, error) {
+	if !strings.HasPrefix(r.Header.Get("X-Amz-Content-Sha256"), "STREAMING-") {
+		return io.ReadAll(r.Body)
+	}
+
+	var data bytes.Buffer
+	reader := bufio.NewReader(r.Body)
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			return nil, err
+		}
+
+		sizeHex := strings.TrimSpace(strings.SplitN(line, ";", 2)[0])
+		size, err := strconv.ParseInt(sizeHex, 16, 64)
+		if err != nil {
+			return nil, err
+		}
+		if size == 0 {
+			return data.Bytes(), nil
+		}
+
+		if _, err := io.CopyN(&data, reader, size); err != nil {
+			return nil, err
+		}
+		if _, err := reader.Discard(2); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// testStorageContract memastikan perilaku yang sama untuk setiap backend Storage.
+func testStorageContract(t *testing.T, storage Storage, baseURL string) {
+	ctx := context.Background()
+	key := "uploads/contract.txt"
+	content := []byte("isi file")
+
+	if err := storage.Put(ctx, key, bytes.NewReader(content), int64(len(content)), "text/plain"); err != nil {
+		t.Fatalf("Put() error = %v", err)
+	}
+
+	reader, err := storage.Get(ctx, key)
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	got, err := io.ReadAll(reader)
+	reader.Close()
+	if err != nil || !bytes.Equal(got, content) {
+		t.Fatalf("Get() = %q, %v, want %q", got, err, content)
+	}
+
+	if got, want := storage.URL(key), baseURL+"/"+key; got != want {
+		t.Errorf("URL() = %q, want %q", got, want)
+	}
+
+	if err := storage.Delete(ctx, key); err != nil {
+		t.Fatalf("Delete() error = %v", err)
+	}
+	if reader, err := storage.Get(ctx, key); err == nil {
+		reader.Close()
+		t.Error("Get() after Delete() must fail")
+	}
+	if err := storage.Delete(ctx, key); err != nil {
+		t.Errorf("Delete() of missing key error = %v, want nil", err)
+	}
+
+	for _, invalid := range []string{"", ".", "../secret.txt", "uploads/../../secret.txt", "uploads/./contract.txt", `..\secret.txt`} {
+		t.Run("invalid key "+invalid, func(t *testing.T) {
+			if err := storage.Put(ctx, invalid, bytes.NewReader(content), int64(len(content)), "text/plain"); !errors.Is(err, ErrInvalidKey) {
+				t.Errorf("Put(%q) error = %v, want ErrInvalidKey", invalid, err)
+			}
+			if _, err := storage.Get(ctx, invalid); !errors.Is(err, ErrInvalidKey) {
+				t.Errorf("Get(%q) error = %v, want ErrInvalidKey", invalid, err)
+			}
+			if err := storage.Delete(ctx, invalid); !errors.Is(err, ErrInvalidKey) {
+				t.Errorf("Delete(%q) error = %v, want ErrInvalidKey", invalid, err)
+			}
+		})
+	}
+}
+
+func TestLocalStorageContract(t *testing.T) {
+	parent := t.TempDir()
+	root := filepath.Join(parent, "public")
+
+	testStorageContract(t, NewLocalStorage(root, "/static/"), "/static")
+
+	// Key yang ditolak tidak boleh membuat file di luar root
+	entries, err := os.ReadDir(parent)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, entry := range entries {
+		if entry.Name() != "public" {
+			t.Errorf("file written outside root: %s", entry.Name())
+		}
+	}
+}
+
+func TestS3StorageContract(t *testing.T) {
+	s3 := newFakeS3(t)
+	endpoint, err := url.Parse(s3.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	storage, err := NewS3Storage(Config{
+		S3Endpoint:  endpoint.Host,
+		S3Region:    "us-east-1",
+		S3Bucket:    "bucket",
+		S3AccessKey: "access",
+		S3SecretKey: "secret",
+		S3PublicURL: "https://cdn.example.com/",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	testStorageContract(t, storage, "https://cdn.example.com")
+
+	if keys := s3.keys(); len(keys) != 0 {
+		t.Errorf("objects left in bucket: %v", keys)
+	}
+}
@@ -4,14 +4,30 @@ import (
 	"net/http"
 
 	"github.com/gieart87/gotoko/app/core/session/auth"
+	"gorm.io/gorm"
 )
 
-func AuthMiddleware(next http.HandlerFunc) http.HandlerFunc {
+// passwordResetPaths adalah halaman yang tetap bisa dibuka user yang wajib mengganti password.
+var passwordResetPaths = map[string]bool{
+	"/password/change": true,
+	"/logout":          true,
+}
+
+// AuthMiddleware hanya meneruskan request dari user yang login. User yang wajib mengganti
+// password diarahkan ke /password/change sampai password diganti.
+func AuthMiddleware(next http.HandlerFunc, db *gorm.DB) http.HandlerFunc {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-	if !auth.IsLoggedIn(r) {
-		http.Redirect(w, r, "/login?error=Anda+Perlu+Login", http.StatusSeeOther)
-		return
-	}
+		user := auth.CurrentUser(db, w, r)
+		if user == nil {
+			http.Redirect(w, r, "/login?error=Anda+Perlu+Login", http.StatusSeeOther)
+			return
+		}
+
+		if user.MustResetPassword && !passwordResetPaths[r.URL.Path] {
+			http.Redirect(w, r, "/password/change?error=Silakan+ganti+password+Anda", http.StatusSeeOther)
+			return
+		}
+
 		next.ServeHTTP(w, r)
 	})
-}
\ No newline at end of file
+}
@@ -0,0 +1,75 @@
+package middlewares
+
+import (
+	"io"
+	"net/http"
+
+	"github.com/gieart87/gotoko/app/core/session/auth"
+)
+
+const (
+	CSRFFieldName  = "csrf_token"
+	CSRFHeaderName = "X-CSRF-Token"
+)
+
+// CSRFResponseWriter membawa token CSRF request saat ini sampai ke renderer template.
+type CSRFResponseWriter struct {
+	http.ResponseWriter
+	token string
+}
+
+func (w *CSRFResponseWriter) CSRFToken() string {
+	return w.token
+}
+
+func (w *CSRFResponseWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// CSRFTokenFromWriter mengembalikan token CSRF jika w dibungkus CSRFMiddleware.
+func CSRFTokenFromWriter(w io.Writer) string {
+	if csrfWriter, ok := w.(*CSRFResponseWriter); ok {
+		return csrfWriter.token
+	}
+
+	return ""
+}
+
+// CSRFMiddleware menyimpan token CSRF di sesi user dan menolak (403) request POST/PUT/PATCH/DELETE
+// yang tidak menyertakan token yang sama lewat field csrf_token atau header X-CSRF-Token.
+// exemptPaths untuk endpoint yang dipanggil server lain, misalnya webhook pembayaran.
+func CSRFMiddleware(next http.Handler, exemptPaths ...string) http.Handler {
+	exempt := map[string]bool{}
+	for _, path := range exemptPaths {
+		exempt[path] = true
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if exempt[r.URL.Path] {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		token, err := auth.CSRFToken(w, r)
+		if err != nil {
+			http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+			return
+		}
+
+		switch r.Method {
+		case http.MethodGet, http.MethodHead, http.MethodOptions:
+		default:
+			submitted := r.Header.Get(CSRFHeaderName)
+			if submitted == "" {
+				submitted = r.FormValue(CSRFFieldName)
+			}
+
+			if !auth.ValidCSRFToken(r, submitted) {
+				http.Error(w, "Token CSRF tidak valid, muat ulang halaman lalu coba lagi", http.StatusForbidden)
+				return
+			}
+		}
+
+		next.ServeHTTP(&CSRFResponseWriter{ResponseWriter: w, token: token}, r)
+	})
+}
@@ -0,0 +1,39 @@
+package middlewares
+
+import (
+	"net/http"
+
+	"github.com/gieart87/gotoko/app/core/session/auth"
+	"gorm.io/gorm"
+)
+
+// PermissionMiddleware hanya meneruskan request jika role user memiliki semua permission
+// yang diminta. User yang belum login diarahkan ke halaman login, selain itu 403.
+func PermissionMiddleware(next http.HandlerFunc, db *gorm.DB, permissions ...string) http.HandlerFunc {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		user := auth.CurrentUser(db, w, r)
+		if user == nil {
+			http.Redirect(w, r, "/login?error=Anda+Perlu+Login", http.StatusSeeOther)
+			return
+		}
+
+		if user.MustResetPassword {
+			http.Redirect(w, r, "/password/change?error=Silakan+ganti+password+Anda", http.StatusSeeOther)
+			return
+		}
+
+		if user.RequiresTwoFactor() && !user.HasTwoFactor() {
+			http.Redirect(w, r, "/account/2fa?error=Aktifkan+autentikasi+dua+langkah+untuk+melanjutkan", http.StatusSeeOther)
+			return
+		}
+
+		for _, permission := range permissions {
+			if !user.Can(permission) {
+				http.Error(w, http.StatusText(http.StatusForbidden), http.StatusForbidden)
+				return
+			}
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
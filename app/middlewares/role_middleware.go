@@ -11,6 +11,10 @@ import (
 func RoleMiddleware(next http.HandlerFunc, db *gorm.DB, roles ...string) http.HandlerFunc {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		user := auth.CurrentUser(db, w, r)
+		if user == nil {
+			http.Redirect(w, r, "/login?error=Anda+Perlu+Login", http.StatusSeeOther)
+			return
+		}
 		if !slices.Contains(roles, user.Role.Name) {
 			http.Redirect(w, r, "/", http.StatusSeeOther)
 			return
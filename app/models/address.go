@@ -1,12 +1,19 @@
 package models
 
-import "time"
+import (
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
 
 type Address struct {
 	ID         string `gorm:"size:36;not null;uniqueIndex;primary_key"`
 	User       User
 	UserID     string `gorm:"size:36;index"`
-	Name       string `gorm:"size:100;not null"`
+	Label      string `gorm:"size:50"`           // contoh: Rumah, Kantor
+	Name       string `gorm:"size:100;not null"` // nama penerima
 	IsPrimary  bool
 	CityID     string `gorm:"size:100"`
 	ProvinceID string `gorm:"size:100"`
@@ -18,3 +25,114 @@ type Address struct {
 	CreatedAt  time.Time
 	UpdatedAt  time.Time
 }
+
+func (a *Address) GetUserAddresses(db *gorm.DB, userID string) ([]Address, error) {
+	var addresses []Address
+
+	err := db.Debug().Where("user_id = ?", userID).Order("is_primary desc, created_at desc").Find(&addresses).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return addresses, nil
+}
+
+func (a *Address) FindUserAddress(db *gorm.DB, userID string, addressID string) (*Address, error) {
+	var address Address
+
+	err := db.Debug().Where("id = ? AND user_id = ?", addressID, userID).First(&address).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return &address, nil
+}
+
+// GetPrimaryAddress mengembalikan alamat utama user, atau alamat terbaru jika belum ada yang utama.
+func (a *Address) GetPrimaryAddress(db *gorm.DB, userID string) (*Address, error) {
+	var address Address
+
+	err := db.Debug().Where("user_id = ?", userID).Order("is_primary desc, created_at desc").First(&address).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return &address, nil
+}
+
+// SaveAddress membuat atau mengubah alamat. Alamat pertama user otomatis menjadi alamat utama,
+// dan jika IsPrimary diisi alamat utama sebelumnya dilepas.
+func (a *Address) SaveAddress(db *gorm.DB, address *Address) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		var count int64
+		if err := tx.Model(&Address{}).Where("user_id = ? AND id <> ?", address.UserID, address.ID).Count(&count).Error; err != nil {
+			return err
+		}
+		if count == 0 {
+			address.IsPrimary = true
+		}
+
+		if address.IsPrimary {
+			if err := unsetPrimaryAddress(tx, address.UserID); err != nil {
+				return err
+			}
+		}
+
+		if address.ID == "" {
+			address.ID = uuid.New().String()
+			return tx.Create(address).Error
+		}
+
+		return tx.Model(&Address{}).Where("id = ? AND user_id = ?", address.ID, address.UserID).Updates(map[string]interface{}{
+			"label":       address.Label,
+			"name":        address.Name,
+			"is_primary":  address.IsPrimary,
+			"province_id": address.ProvinceID,
+			"city_id":     address.CityID,
+			"address1":    address.Address1,
+			"address2":    address.Address2,
+			"phone":       address.Phone,
+			"email":       address.Email,
+			"post_code":   address.PostCode,
+			"updated_at":  time.Now(),
+		}).Error
+	})
+}
+
+func (a *Address) SetPrimary(db *gorm.DB) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		if err := unsetPrimaryAddress(tx, a.UserID); err != nil {
+			return err
+		}
+
+		return tx.Model(&Address{}).Where("id = ?", a.ID).Update("is_primary", true).Error
+	})
+}
+
+// DeleteAddress menghapus alamat; jika yang dihapus alamat utama, alamat terbaru menjadi utama.
+func (a *Address) DeleteAddress(db *gorm.DB) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("id = ? AND user_id = ?", a.ID, a.UserID).Delete(&Address{}).Error; err != nil {
+			return err
+		}
+
+		if !a.IsPrimary {
+			return nil
+		}
+
+		var next Address
+		err := tx.Where("user_id = ?", a.UserID).Order("created_at desc").First(&next).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		return tx.Model(&Address{}).Where("id = ?", next.ID).Update("is_primary", true).Error
+	})
+}
+
+func unsetPrimaryAddress(tx *gorm.DB, userID string) error {
+	return tx.Model(&Address{}).Where("user_id = ? AND is_primary = ?", userID, true).Update("is_primary", false).Error
+}
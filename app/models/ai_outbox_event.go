@@ -0,0 +1,220 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	AIEventUpsert = "upsert"
+	AIEventDelete = "delete"
+)
+
+const (
+	// MaxAIEventAttempts adalah batas percobaan kirim; setelah itu event masuk dead letter (dead_at).
+	MaxAIEventAttempts = 10
+
+	// aiEventLease adalah lama event yang sudah diambil satu worker tidak diambil worker lain.
+	aiEventLease = 5 * time.Minute
+)
+
+// AIOutboxEvent mencatat perubahan produk yang harus dikirim ke service AI.
+// Event ditulis dalam transaksi yang sama dengan perubahan produk, lalu dikirim
+// oleh worker sehingga tidak hilang walaupun service AI sedang mati.
+type AIOutboxEvent struct {
+	ID            string     `gorm:"size:36;not null;uniqueIndex;primary_key"`
+	ProductID     string     `gorm:"size:36;index"`
+	Action        string     `gorm:"size:20"`
+	Payload       string     `gorm:"type:text"`
+	Attempts      int        `gorm:"default:0"`
+	LastError     string     `gorm:"type:text"`
+	NextAttemptAt time.Time  `gorm:"index"`
+	LockedUntil   *time.Time `gorm:"index"`
+	ProcessedAt   *time.Time `gorm:"index"`
+	DeadAt        *time.Time `gorm:"index"`
+	CreatedAt     time.Time
+	UpdatedAt     time.Time
+}
+
+func (e *AIOutboxEvent) BeforeCreate(db *gorm.DB) error {
+	if e.ID == "" {
+		e.ID = uuid.New().String()
+	}
+
+	return nil
+}
+
+// EnqueueAIEvent menyimpan event baru dan menggantikan event produk yang sama
+// yang belum terkirim. payload di-encode sebagai JSON.
+func EnqueueAIEvent(db *gorm.DB, productID string, action string, payload interface{}) error {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+
+	// Event lama yang belum terkirim tidak perlu dikirim lagi, payload terbaru sudah berisi data lengkap
+	err = db.Model(&AIOutboxEvent{}).
+		Where("product_id = ? AND processed_at IS NULL", productID).
+		Updates(map[string]interface{}{
+			"processed_at": now,
+			"last_error":   "superseded",
+			"updated_at":   now,
+		}).Error
+	if err != nil {
+		return err
+	}
+
+	return db.Create(&AIOutboxEvent{
+		ProductID:     productID,
+		Action:        action,
+		Payload:       string(data),
+		NextAttemptAt: now,
+		CreatedAt:     now,
+		UpdatedAt:     now,
+	}).Error
+}
+
+// DueAIEvents mengambil (claim) event yang belum terkirim dan sudah waktunya dicoba, urut dari
+// yang terlama. Event yang diambil di-lease selama aiEventLease sehingga worker lain (proses atau
+// server lain) tidak mengirimnya dua kali; FOR UPDATE SKIP LOCKED mencegah dua worker mengambil
+// baris yang sama pada saat bersamaan. Lease yang habis (worker mati) otomatis bisa diambil lagi.
+func (e *AIOutboxEvent) DueAIEvents(db *gorm.DB, limit int) ([]AIOutboxEvent, error) {
+	var events []AIOutboxEvent
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		now := time.Now()
+
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("processed_at IS NULL AND dead_at IS NULL AND next_attempt_at <= ?", now).
+			Where("locked_until IS NULL OR locked_until <= ?", now).
+			Order("created_at asc").
+			Limit(limit).
+			Find(&events).Error
+		if err != nil || len(events) == 0 {
+			return err
+		}
+
+		ids := make([]string, len(events))
+		lockedUntil := now.Add(aiEventLease)
+		for i := range events {
+			ids[i] = events[i].ID
+			events[i].LockedUntil = &lockedUntil
+		}
+
+		return tx.Model(&AIOutboxEvent{}).Where("id IN ?", ids).Updates(map[string]interface{}{
+			"locked_until": lockedUntil,
+			"updated_at":   now,
+		}).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return events, nil
+}
+
+// ReleaseAIEvents melepas lease event yang sudah diambil tetapi belum dicoba,
+// agar bisa langsung diambil pada putaran berikutnya.
+func ReleaseAIEvents(db *gorm.DB, events []AIOutboxEvent) error {
+	if len(events) == 0 {
+		return nil
+	}
+
+	ids := make([]string, len(events))
+	for i := range events {
+		ids[i] = events[i].ID
+	}
+
+	return db.Model(&AIOutboxEvent{}).Where("id IN ? AND processed_at IS NULL", ids).Updates(map[string]interface{}{
+		"locked_until": nil,
+		"updated_at":   time.Now(),
+	}).Error
+}
+
+func (e *AIOutboxEvent) MarkProcessed(db *gorm.DB) error {
+	now := time.Now()
+	e.ProcessedAt = &now
+	e.LockedUntil = nil
+
+	return db.Model(&AIOutboxEvent{}).Where("id = ?", e.ID).Updates(map[string]interface{}{
+		"processed_at": now,
+		"locked_until": nil,
+		"last_error":   "",
+		"updated_at":   now,
+	}).Error
+}
+
+// MarkFailed menjadwalkan ulang event dengan jeda yang makin panjang (maksimal 1 jam).
+// Setelah MaxAIEventAttempts percobaan event tidak dicoba lagi (dead letter).
+func (e *AIOutboxEvent) MarkFailed(db *gorm.DB, cause error, countAttempt bool) error {
+	now := time.Now()
+	if countAttempt {
+		e.Attempts++
+	}
+
+	delay := time.Duration(1<<uint(minAttempts(e.Attempts, 12))) * time.Second
+	if delay > time.Hour {
+		delay = time.Hour
+	}
+	e.NextAttemptAt = now.Add(delay)
+	e.LastError = cause.Error()
+	e.LockedUntil = nil
+
+	updates := map[string]interface{}{
+		"attempts":        e.Attempts,
+		"last_error":      e.LastError,
+		"next_attempt_at": e.NextAttemptAt,
+		"locked_until":    nil,
+		"updated_at":      now,
+	}
+	if e.Attempts >= MaxAIEventAttempts {
+		e.DeadAt = &now
+		updates["dead_at"] = now
+	}
+
+	return db.Model(&AIOutboxEvent{}).Where("id = ?", e.ID).Updates(updates).Error
+}
+
+func minAttempts(attempts int, max int) int {
+	if attempts > max {
+		return max
+	}
+
+	return attempts
+}
+
+// PendingAIEventCount dipakai untuk memantau antrian outbox.
+func (e *AIOutboxEvent) PendingAIEventCount(db *gorm.DB) (int64, error) {
+	var count int64
+	err := db.Model(&AIOutboxEvent{}).Where("processed_at IS NULL AND dead_at IS NULL").Count(&count).Error
+
+	return count, err
+}
+
+// DeadAIEventCount menghitung event yang berhenti dicoba karena melewati MaxAIEventAttempts.
+func (e *AIOutboxEvent) DeadAIEventCount(db *gorm.DB) (int64, error) {
+	var count int64
+	err := db.Model(&AIOutboxEvent{}).Where("processed_at IS NULL AND dead_at IS NOT NULL").Count(&count).Error
+
+	return count, err
+}
+
+// RetryDeadAIEvents memasukkan kembali event dead letter ke antrian dengan hitungan percobaan dari nol.
+func (e *AIOutboxEvent) RetryDeadAIEvents(db *gorm.DB) (int64, error) {
+	now := time.Now()
+
+	result := db.Model(&AIOutboxEvent{}).Where("processed_at IS NULL AND dead_at IS NOT NULL").Updates(map[string]interface{}{
+		"dead_at":         nil,
+		"attempts":        0,
+		"next_attempt_at": now,
+		"updated_at":      now,
+	})
+
+	return result.RowsAffected, result.Error
+}
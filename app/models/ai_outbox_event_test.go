@@ -0,0 +1,106 @@
+package models
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestDueAIEventsLeasesClaimedEvents(t *testing.T) {
+	db := newTestDB(t, &AIOutboxEvent{})
+	eventModel := AIOutboxEvent{}
+
+	for _, productID := range []string{"product-1", "product-2"} {
+		if err := EnqueueAIEvent(db, productID, AIEventUpsert, map[string]string{"id": productID}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	first, err := eventModel.DueAIEvents(db, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(first) != 1 || first[0].ProductID != "product-1" {
+		t.Fatalf("first claim = %+v, want product-1", first)
+	}
+
+	// Worker kedua tidak mendapat event yang sedang di-lease
+	second, err := eventModel.DueAIEvents(db, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(second) != 1 || second[0].ProductID != "product-2" {
+		t.Fatalf("second claim = %+v, want only product-2", second)
+	}
+
+	if err := ReleaseAIEvents(db, first); err != nil {
+		t.Fatal(err)
+	}
+	again, err := eventModel.DueAIEvents(db, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(again) != 1 || again[0].ID != first[0].ID {
+		t.Fatalf("claim after release = %+v, want %s", again, first[0].ID)
+	}
+}
+
+func TestMarkFailedMovesEventToDeadLetter(t *testing.T) {
+	db := newTestDB(t, &AIOutboxEvent{})
+	eventModel := AIOutboxEvent{}
+
+	if err := EnqueueAIEvent(db, "product-1", AIEventDelete, map[string]string{"id": "product-1"}); err != nil {
+		t.Fatal(err)
+	}
+
+	events, err := eventModel.DueAIEvents(db, 1)
+	if err != nil || len(events) != 1 {
+		t.Fatalf("DueAIEvents() = %v, %v", events, err)
+	}
+	event := events[0]
+
+	cause := errors.New("service unavailable")
+	for i := 1; i < MaxAIEventAttempts; i++ {
+		if err := event.MarkFailed(db, cause, true); err != nil {
+			t.Fatal(err)
+		}
+		if event.DeadAt != nil {
+			t.Fatalf("event dead after %d attempts, want %d", i, MaxAIEventAttempts)
+		}
+	}
+
+	// Kegagalan karena circuit breaker tidak dihitung sebagai percobaan
+	if err := event.MarkFailed(db, cause, false); err != nil {
+		t.Fatal(err)
+	}
+	if event.DeadAt != nil {
+		t.Fatal("uncounted failure must not dead-letter the event")
+	}
+
+	if err := event.MarkFailed(db, cause, true); err != nil {
+		t.Fatal(err)
+	}
+	if event.DeadAt == nil {
+		t.Fatalf("event not dead after %d attempts", event.Attempts)
+	}
+
+	// Event dead tidak diambil lagi walaupun sudah jatuh tempo
+	db.Model(&AIOutboxEvent{}).Where("id = ?", event.ID).Update("next_attempt_at", time.Now().Add(-time.Hour))
+	if due, _ := eventModel.DueAIEvents(db, 10); len(due) != 0 {
+		t.Fatalf("dead event claimed: %+v", due)
+	}
+
+	pending, _ := eventModel.PendingAIEventCount(db)
+	dead, _ := eventModel.DeadAIEventCount(db)
+	if pending != 0 || dead != 1 {
+		t.Errorf("pending = %d, dead = %d, want 0 and 1", pending, dead)
+	}
+
+	requeued, err := eventModel.RetryDeadAIEvents(db)
+	if err != nil || requeued != 1 {
+		t.Fatalf("RetryDeadAIEvents() = %d, %v", requeued, err)
+	}
+	if due, _ := eventModel.DueAIEvents(db, 10); len(due) != 1 || due[0].Attempts != 0 {
+		t.Fatalf("requeued event not claimable: %+v", due)
+	}
+}
@@ -1,15 +1,322 @@
 package models
 
-import "time"
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/gosimple/slug"
+	"gorm.io/gorm"
+)
 
 type Category struct {
 	ID        string `gorm:"size:36;not null;uniqueIndex;primary_key"`
-	ParentID  string `gorm:"size:36"`
+	ParentID  string `gorm:"size:36;index"`
 	Section   Section
-	SectionID string    `gorm:"size:36;index"`
-	Products  []Product `gorm:"many2many:product_categories;"`
-	Name      string    `gorm:"size:100;"`
-	Slug      string    `gorm:"size:100;"`
+	SectionID string     `gorm:"size:36;index"`
+	Products  []Product  `gorm:"many2many:product_categories;"`
+	Name      string     `gorm:"size:100;"`
+	Slug      string     `gorm:"size:100;"`
+	Children  []Category `gorm:"-"`
 	CreatedAt time.Time
 	UpdatedAt time.Time
 }
+
+func (c *Category) BeforeCreate(db *gorm.DB) error {
+	if c.ID == "" {
+		c.ID = uuid.New().String()
+	}
+
+	return nil
+}
+
+func (c *Category) GetCategories(db *gorm.DB) ([]Category, error) {
+	var categories []Category
+
+	err := db.Debug().Preload("Section").Order("name asc").Find(&categories).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return categories, nil
+}
+
+func (c *Category) FindByID(db *gorm.DB, categoryID string) (*Category, error) {
+	var category Category
+
+	err := db.Debug().Preload("Section").Where("id = ?", categoryID).First(&category).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return &category, nil
+}
+
+func (c *Category) FindBySlug(db *gorm.DB, categorySlug string) (*Category, error) {
+	var category Category
+
+	err := db.Debug().Where("slug = ?", categorySlug).First(&category).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return &category, nil
+}
+
+func (c *Category) FindByIDs(db *gorm.DB, categoryIDs []string) ([]Category, error) {
+	var categories []Category
+
+	if len(categoryIDs) == 0 {
+		return categories, nil
+	}
+
+	err := db.Debug().Where("id IN ?", categoryIDs).Find(&categories).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return categories, nil
+}
+
+// GetCategoryTree menyusun semua kategori menjadi pohon berdasarkan ParentID.
+// Kategori yang parent-nya tidak ditemukan dianggap sebagai root.
+func (c *Category) GetCategoryTree(db *gorm.DB) ([]Category, error) {
+	categories, err := c.GetCategories(db)
+	if err != nil {
+		return nil, err
+	}
+
+	return buildCategoryTree(categories), nil
+}
+
+func buildCategoryTree(categories []Category) []Category {
+	known := make(map[string]bool, len(categories))
+	for _, category := range categories {
+		known[category.ID] = true
+	}
+
+	childrenOf := make(map[string][]Category)
+	for _, category := range categories {
+		parentID := category.ParentID
+		if !known[parentID] {
+			parentID = ""
+		}
+		childrenOf[parentID] = append(childrenOf[parentID], category)
+	}
+
+	var attach func(parentID string, visited map[string]bool) []Category
+	attach = func(parentID string, visited map[string]bool) []Category {
+		var nodes []Category
+		for _, category := range childrenOf[parentID] {
+			if visited[category.ID] {
+				continue
+			}
+			visited[category.ID] = true
+			category.Children = attach(category.ID, visited)
+			nodes = append(nodes, category)
+		}
+		return nodes
+	}
+
+	return attach("", map[string]bool{})
+}
+
+// DescendantIDs mengembalikan ID kategori beserta seluruh turunannya.
+func (c *Category) DescendantIDs(db *gorm.DB, categoryID string) ([]string, error) {
+	var categories []Category
+	if err := db.Debug().Select("id", "parent_id").Find(&categories).Error; err != nil {
+		return nil, err
+	}
+
+	childrenOf := make(map[string][]string)
+	for _, category := range categories {
+		childrenOf[category.ParentID] = append(childrenOf[category.ParentID], category.ID)
+	}
+
+	ids := []string{categoryID}
+	visited := map[string]bool{categoryID: true}
+	for i := 0; i < len(ids); i++ {
+		for _, childID := range childrenOf[ids[i]] {
+			if visited[childID] {
+				continue
+			}
+			visited[childID] = true
+			ids = append(ids, childID)
+		}
+	}
+
+	return ids, nil
+}
+
+func (c *Category) validate(db *gorm.DB) error {
+	if c.SectionID == "" {
+		return errors.New("section wajib dipilih")
+	}
+
+	if c.ParentID == "" {
+		return nil
+	}
+
+	if c.ParentID == c.ID {
+		return errors.New("kategori tidak bisa menjadi parent dirinya sendiri")
+	}
+
+	var parent Category
+	if err := db.Where("id = ?", c.ParentID).First(&parent).Error; err != nil {
+		return errors.New("parent kategori tidak ditemukan")
+	}
+
+	if c.ID == "" {
+		return nil
+	}
+
+	descendants, err := c.DescendantIDs(db, c.ID)
+	if err != nil {
+		return err
+	}
+	for _, id := range descendants {
+		if id == c.ParentID {
+			return errors.New("parent kategori tidak boleh turunan dari kategori ini")
+		}
+	}
+
+	return nil
+}
+
+func (c *Category) CreateCategory(db *gorm.DB, param *Category) (*Category, error) {
+	category := &Category{
+		ParentID:  param.ParentID,
+		SectionID: param.SectionID,
+		Name:      param.Name,
+	}
+
+	if err := category.validate(db); err != nil {
+		return nil, err
+	}
+
+	categorySlug, err := uniqueSlug(db, &Category{}, param.Name, "")
+	if err != nil {
+		return nil, err
+	}
+	category.Slug = categorySlug
+
+	if err := db.Debug().Create(category).Error; err != nil {
+		return nil, err
+	}
+
+	return category, nil
+}
+
+func (c *Category) UpdateCategory(db *gorm.DB, param *Category) (*Category, error) {
+	c.ParentID = param.ParentID
+	c.SectionID = param.SectionID
+
+	if err := c.validate(db); err != nil {
+		return nil, err
+	}
+
+	if c.Name != param.Name {
+		categorySlug, err := uniqueSlug(db, &Category{}, param.Name, c.ID)
+		if err != nil {
+			return nil, err
+		}
+		c.Slug = categorySlug
+	}
+	renamed := c.Name != param.Name
+	c.Name = param.Name
+
+	err := db.Debug().Model(&Category{}).Where("id = ?", c.ID).Updates(map[string]interface{}{
+		"parent_id":  c.ParentID,
+		"section_id": c.SectionID,
+		"name":       c.Name,
+		"slug":       c.Slug,
+		"updated_at": time.Now(),
+	}).Error
+	if err != nil {
+		return nil, err
+	}
+
+	if renamed {
+		if err := c.refreshProductSearch(db); err != nil {
+			return nil, err
+		}
+	}
+
+	return c, nil
+}
+
+// DeleteCategory memindahkan sub-kategori ke parent dari kategori yang dihapus
+// dan melepas relasi produk sebelum kategori dihapus.
+func (c *Category) DeleteCategory(db *gorm.DB) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&Category{}).Where("parent_id = ?", c.ID).Update("parent_id", c.ParentID).Error; err != nil {
+			return err
+		}
+
+		productIDs, err := c.productIDs(tx)
+		if err != nil {
+			return err
+		}
+
+		if err := tx.Model(c).Association("Products").Clear(); err != nil {
+			return err
+		}
+
+		if err := tx.Delete(c).Error; err != nil {
+			return err
+		}
+
+		if len(productIDs) == 0 {
+			return nil
+		}
+
+		productModel := Product{}
+		return productModel.RefreshSearchCategories(tx, productIDs...)
+	})
+}
+
+func (c *Category) productIDs(db *gorm.DB) ([]string, error) {
+	var productIDs []string
+	err := db.Table("product_categories").Where("category_id = ?", c.ID).Pluck("product_id", &productIDs).Error
+
+	return productIDs, err
+}
+
+// refreshProductSearch memperbarui nama kategori pada index pencarian produk di kategori ini.
+func (c *Category) refreshProductSearch(db *gorm.DB) error {
+	productIDs, err := c.productIDs(db)
+	if err != nil || len(productIDs) == 0 {
+		return err
+	}
+
+	productModel := Product{}
+	return productModel.RefreshSearchCategories(db, productIDs...)
+}
+
+// uniqueSlug membuat slug dari name dan menambahkan akhiran angka
+// jika slug tersebut sudah dipakai oleh baris lain pada tabel model.
+func uniqueSlug(db *gorm.DB, model interface{}, name string, excludeID string) (string, error) {
+	base := slug.Make(name)
+	if base == "" {
+		return "", errors.New("nama tidak boleh kosong")
+	}
+
+	candidate := base
+	for i := 2; ; i++ {
+		var count int64
+		query := db.Model(model).Where("slug = ?", candidate)
+		if excludeID != "" {
+			query = query.Where("id <> ?", excludeID)
+		}
+		if err := query.Count(&count).Error; err != nil {
+			return "", err
+		}
+
+		if count == 0 {
+			return candidate, nil
+		}
+
+		candidate = fmt.Sprintf("%s-%d", base, i)
+	}
+}
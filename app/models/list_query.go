@@ -0,0 +1,184 @@
+package models
+
+import (
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const (
+	DefaultListPerPage = 20
+	MaxListPerPage     = 100
+	listDateLayout     = "2006-01-02"
+)
+
+// ListFilter adalah filter nilai tepat (?status=1). Options membatasi nilai yang diterima,
+// kosong berarti bebas. Int dipakai untuk kolom angka agar nilai dikirim sebagai integer.
+type ListFilter struct {
+	Column  string
+	Options []string
+	Int     bool
+}
+
+// ListSpec mendeskripsikan kolom yang boleh dipakai halaman daftar admin. Hanya kolom
+// yang terdaftar di sini yang pernah masuk ke SQL; nilai dari URL selalu jadi parameter.
+type ListSpec struct {
+	SearchColumns []string              // dicari dengan ?q= (LIKE, tidak peka huruf besar)
+	Sorts         map[string]string     // ?sort=<nama> -> kolom
+	DefaultSort   string                // salah satu key Sorts
+	DefaultDesc   bool                  // arah default jika ?dir= tidak valid
+	Filters       map[string]ListFilter // ?<nama>=<nilai>
+	DateColumn    string                // kolom untuk ?from= dan ?to= (YYYY-MM-DD)
+}
+
+// ListQuery adalah parameter daftar yang sudah divalidasi terhadap ListSpec.
+type ListQuery struct {
+	Search  string
+	Sort    string
+	Desc    bool
+	Page    int
+	PerPage int
+	From    string
+	To      string
+	Filters map[string]string
+}
+
+// ParseListQuery membaca ?q=&sort=&dir=asc|desc&page=&per_page=&from=&to= serta filter
+// dari spec. Nilai yang tidak dikenal diabaikan sehingga kembali ke default.
+func ParseListQuery(q url.Values, spec ListSpec) ListQuery {
+	lq := ListQuery{
+		Search:  strings.TrimSpace(q.Get("q")),
+		Sort:    q.Get("sort"),
+		Desc:    spec.DefaultDesc,
+		PerPage: DefaultListPerPage,
+		Filters: map[string]string{},
+	}
+
+	if _, ok := spec.Sorts[lq.Sort]; !ok {
+		lq.Sort = spec.DefaultSort
+	}
+	switch q.Get("dir") {
+	case "asc":
+		lq.Desc = false
+	case "desc":
+		lq.Desc = true
+	}
+
+	lq.Page, _ = strconv.Atoi(q.Get("page"))
+	if lq.Page <= 0 {
+		lq.Page = 1
+	}
+	if perPage, err := strconv.Atoi(q.Get("per_page")); err == nil && perPage > 0 {
+		if perPage > MaxListPerPage {
+			perPage = MaxListPerPage
+		}
+		lq.PerPage = perPage
+	}
+
+	if spec.DateColumn != "" {
+		if _, err := time.Parse(listDateLayout, q.Get("from")); err == nil {
+			lq.From = q.Get("from")
+		}
+		if _, err := time.Parse(listDateLayout, q.Get("to")); err == nil {
+			lq.To = q.Get("to")
+		}
+	}
+
+	for name, filter := range spec.Filters {
+		value := strings.TrimSpace(q.Get(name))
+		if value == "" {
+			continue
+		}
+		if len(filter.Options) > 0 && !containsString(filter.Options, value) {
+			continue
+		}
+		if _, err := strconv.Atoi(value); filter.Int && err != nil {
+			continue
+		}
+		lq.Filters[name] = value
+	}
+
+	return lq
+}
+
+// Apply menambahkan pencarian, filter dan rentang tanggal ke query (tanpa urutan dan paginasi),
+// sehingga hasilnya bisa dipakai untuk Count maupun Find.
+func (lq ListQuery) Apply(query *gorm.DB, spec ListSpec) *gorm.DB {
+	if lq.Search != "" && len(spec.SearchColumns) > 0 {
+		like := "%" + escapeLike(strings.ToLower(lq.Search)) + "%"
+		conditions := make([]string, len(spec.SearchColumns))
+		args := make([]interface{}, len(spec.SearchColumns))
+		for i, column := range spec.SearchColumns {
+			conditions[i] = "LOWER(" + column + ") LIKE ? ESCAPE '!'"
+			args[i] = like
+		}
+		query = query.Where("("+strings.Join(conditions, " OR ")+")", args...)
+	}
+
+	for name, value := range lq.Filters {
+		filter, ok := spec.Filters[name]
+		if !ok {
+			continue
+		}
+		if filter.Int {
+			number, _ := strconv.Atoi(value)
+			query = query.Where(filter.Column+" = ?", number)
+		} else {
+			query = query.Where(filter.Column+" = ?", value)
+		}
+	}
+
+	// Tanggal ?to= inklusif: dibandingkan dengan awal hari berikutnya
+	if from, err := time.ParseInLocation(listDateLayout, lq.From, time.Local); err == nil && spec.DateColumn != "" {
+		query = query.Where(spec.DateColumn+" >= ?", from)
+	}
+	if to, err := time.ParseInLocation(listDateLayout, lq.To, time.Local); err == nil && spec.DateColumn != "" {
+		query = query.Where(spec.DateColumn+" < ?", to.AddDate(0, 0, 1))
+	}
+
+	return query
+}
+
+// likeEscaper meng-escape wildcard LIKE dengan "!" (bukan backslash, yang diperlakukan berbeda
+// oleh PostgreSQL, MySQL dan SQLite), sehingga ?q=10% mencari teks "10%" apa adanya.
+var likeEscaper = strings.NewReplacer("!", "!!", "%", "!%", "_", "!_")
+
+func escapeLike(value string) string {
+	return likeEscaper.Replace(value)
+}
+
+// Paginate adalah scope GORM untuk urutan dan halaman, dipakai setelah Count:
+// query.Scopes(lq.Paginate(spec)).Find(&rows)
+func (lq ListQuery) Paginate(spec ListSpec) func(*gorm.DB) *gorm.DB {
+	return func(query *gorm.DB) *gorm.DB {
+		if column, ok := spec.Sorts[lq.Sort]; ok {
+			direction := " asc"
+			if lq.Desc {
+				direction = " desc"
+			}
+			query = query.Order(column + direction)
+		}
+
+		return query.Limit(lq.PerPage).Offset((lq.Page - 1) * lq.PerPage)
+	}
+}
+
+// FindList menjalankan query daftar: filter, hitung total, lalu ambil satu halaman ke dest.
+// query berisi Model tanpa Preload; Preload diberikan lewat scopes karena hanya dipakai saat Find.
+func FindList(query *gorm.DB, lq ListQuery, spec ListSpec, dest interface{}, scopes ...func(*gorm.DB) *gorm.DB) (int64, error) {
+	var count int64
+
+	query = lq.Apply(query, spec)
+	if err := query.Session(&gorm.Session{}).Count(&count).Error; err != nil {
+		return 0, err
+	}
+
+	if err := query.Scopes(scopes...).Scopes(lq.Paginate(spec)).Find(dest).Error; err != nil {
+		return 0, err
+	}
+
+	return count, nil
+}
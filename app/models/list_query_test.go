@@ -0,0 +1,165 @@
+package models
+
+import (
+	"net/url"
+	"reflect"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/gieart87/gotoko/app/consts"
+)
+
+func TestParseListQuery(t *testing.T) {
+	tests := []struct {
+		name  string
+		query string
+		want  ListQuery
+	}{
+		{
+			name:  "defaults",
+			query: "",
+			want:  ListQuery{Sort: "date", Desc: true, Page: 1, PerPage: DefaultListPerPage, Filters: map[string]string{}},
+		},
+		{
+			name:  "known sort and direction",
+			query: "sort=total&dir=asc&page=3&per_page=50",
+			want:  ListQuery{Sort: "total", Desc: false, Page: 3, PerPage: 50, Filters: map[string]string{}},
+		},
+		{
+			name:  "unknown sort, direction and filter are dropped",
+			query: "sort=password&dir=sideways&user_id=1&orders.status=1",
+			want:  ListQuery{Sort: "date", Desc: true, Page: 1, PerPage: DefaultListPerPage, Filters: map[string]string{}},
+		},
+		{
+			name:  "valid filters are kept",
+			query: "status=" + strconv.Itoa(consts.OrderStatusDelivered) + "&payment_status=" + consts.OrderPaymentStatusPaid,
+			want: ListQuery{Sort: "date", Desc: true, Page: 1, PerPage: DefaultListPerPage, Filters: map[string]string{
+				"status":         strconv.Itoa(consts.OrderStatusDelivered),
+				"payment_status": consts.OrderPaymentStatusPaid,
+			}},
+		},
+		{
+			name:  "option filter rejects values outside its options",
+			query: "status=99&payment_status=refunded",
+			want:  ListQuery{Sort: "date", Desc: true, Page: 1, PerPage: DefaultListPerPage, Filters: map[string]string{}},
+		},
+		{
+			name:  "per_page is capped",
+			query: "per_page=1000",
+			want:  ListQuery{Sort: "date", Desc: true, Page: 1, PerPage: MaxListPerPage, Filters: map[string]string{}},
+		},
+		{
+			name:  "invalid page and per_page fall back to defaults",
+			query: "page=-2&per_page=abc",
+			want:  ListQuery{Sort: "date", Desc: true, Page: 1, PerPage: DefaultListPerPage, Filters: map[string]string{}},
+		},
+		{
+			name:  "invalid dates are dropped",
+			query: "from=2026-13-01&to=kemarin",
+			want:  ListQuery{Sort: "date", Desc: true, Page: 1, PerPage: DefaultListPerPage, Filters: map[string]string{}},
+		},
+		{
+			name:  "search and dates",
+			query: "q=+INV-01+&from=2026-01-01&to=2026-01-31",
+			want:  ListQuery{Search: "INV-01", Sort: "date", Desc: true, Page: 1, PerPage: DefaultListPerPage, From: "2026-01-01", To: "2026-01-31", Filters: map[string]string{}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			q, err := url.ParseQuery(tt.query)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			got := ParseListQuery(q, OrderListSpec)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseListQuery(%q) = %+v, want %+v", tt.query, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseListQueryIntFilterRejectsNonNumbers(t *testing.T) {
+	spec := ListSpec{Filters: map[string]ListFilter{"stock": {Column: "products.stock", Int: true}}}
+
+	tests := []struct {
+		value string
+		kept  bool
+	}{
+		{"12", true},
+		{"abc", false},
+		{"1 OR 1=1", false},
+	}
+
+	for _, tt := range tests {
+		got := ParseListQuery(url.Values{"stock": {tt.value}}, spec)
+		if _, ok := got.Filters["stock"]; ok != tt.kept {
+			t.Errorf("stock=%q kept = %v, want %v", tt.value, ok, tt.kept)
+		}
+	}
+}
+
+func TestListQueryApply(t *testing.T) {
+	db := newTestDB(t, &Order{})
+
+	day := func(date string, hour int) time.Time {
+		value, err := time.ParseInLocation(listDateLayout, date, time.Local)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return value.Add(time.Duration(hour) * time.Hour)
+	}
+
+	orders := []Order{
+		{ID: "1", Status: consts.OrderStatusPending, CreatedAt: day("2026-01-01", 0)},
+		{ID: "2", Status: consts.OrderStatusDelivered, CreatedAt: day("2026-01-31", 23)},
+		{ID: "3", Status: consts.OrderStatusDelivered, CreatedAt: day("2026-02-01", 0)},
+		{ID: "4", Status: consts.OrderStatusPending, CreatedAt: day("2026-01-15", 12)},
+		{ID: "5", Status: consts.OrderStatusPending, CreatedAt: day("2026-01-15", 12)},
+		{ID: "6", Status: consts.OrderStatusPending, CreatedAt: day("2026-01-15", 12)},
+		{ID: "7", Status: consts.OrderStatusPending, CreatedAt: day("2026-01-15", 12)},
+	}
+	if err := db.Create(&orders).Error; err != nil {
+		t.Fatal(err)
+	}
+	// Kode order dibuat otomatis saat create, jadi diisi ulang untuk kasus pencarian
+	codes := map[string]string{"1": "INV/001", "2": "INV/002", "3": "INV/003", "4": "PROMO10%", "5": "PROMO100", "6": "A_1", "7": "AB1"}
+	for id, code := range codes {
+		if err := db.Model(&Order{}).Where("id = ?", id).UpdateColumn("code", code).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		name  string
+		query string
+		want  []string
+	}{
+		{"to is inclusive", "from=2026-01-31&to=2026-01-31", []string{"2"}},
+		{"from and to", "from=2026-01-01&to=2026-01-31&q=inv", []string{"1", "2"}},
+		{"filter", "status=" + strconv.Itoa(consts.OrderStatusDelivered), []string{"2", "3"}},
+		{"search is case insensitive", "q=inv/00", []string{"1", "2", "3"}},
+		{"percent is matched literally", "q=10%25", []string{"4"}},
+		{"underscore is matched literally", "q=a_", []string{"6"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			q, err := url.ParseQuery(tt.query)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var ids []string
+			lq := ParseListQuery(q, OrderListSpec)
+			if err := lq.Apply(db.Model(&Order{}), OrderListSpec).Order("id").Pluck("id", &ids).Error; err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(ids, tt.want) {
+				t.Errorf("%s: got %v, want %v", tt.query, ids, tt.want)
+			}
+		})
+	}
+}
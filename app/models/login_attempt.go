@@ -0,0 +1,79 @@
+package models
+
+import (
+	"time"
+
+	"github.com/gieart87/gotoko/app/utils"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Hasil percobaan login yang dicatat di LoginAttempt.Reason.
+const (
+	LoginSuccess         = "success"
+	LoginInvalidPassword = "invalid_password"
+	LoginInvalidCode     = "invalid_2fa"
+	LoginUnknownEmail    = "unknown_email"
+	LoginLocked          = "locked"
+	LoginThrottled       = "throttled"
+	LoginIPLimited       = "ip_limited"
+	LoginSuspended       = "suspended"
+)
+
+// LoginAttempt mencatat setiap percobaan login untuk audit dan pembatasan per IP.
+type LoginAttempt struct {
+	ID        string `gorm:"size:36;not null;uniqueIndex;primary_key"`
+	Email     string `gorm:"size:100;index"`
+	UserID    string `gorm:"size:36;index"`
+	IPAddress string `gorm:"size:45;index"`
+	UserAgent string `gorm:"size:255"`
+	Success   bool
+	Reason    string    `gorm:"size:30"`
+	CreatedAt time.Time `gorm:"index"`
+}
+
+func (a *LoginAttempt) BeforeCreate(db *gorm.DB) error {
+	if a.ID == "" {
+		a.ID = uuid.New().String()
+	}
+
+	return nil
+}
+
+func (a *LoginAttempt) RecordLoginAttempt(db *gorm.DB, attempt *LoginAttempt) error {
+	attempt.UserAgent = utils.Truncate(attempt.UserAgent, 255)
+	attempt.Success = attempt.Reason == LoginSuccess
+
+	return db.Create(attempt).Error
+}
+
+// failedLoginReasons adalah percobaan yang dihitung untuk batas per IP. Percobaan yang ditolak
+// karena sudah dibatasi (locked, throttled, ip_limited) tidak ikut dihitung agar batasnya
+// tidak terus diperpanjang oleh percobaan yang sebenarnya tidak pernah diperiksa.
+var failedLoginReasons = []string{LoginInvalidPassword, LoginUnknownEmail, LoginInvalidCode}
+
+// CountFailedByIP menghitung percobaan login gagal dari satu IP sejak waktu tertentu.
+func (a *LoginAttempt) CountFailedByIP(db *gorm.DB, ip string, since time.Time) (int64, error) {
+	var count int64
+
+	err := db.Model(&LoginAttempt{}).
+		Where("ip_address = ? AND reason IN ? AND created_at >= ?", ip, failedLoginReasons, since).
+		Count(&count).Error
+
+	return count, err
+}
+
+func (a *LoginAttempt) GetRecentLoginAttempts(db *gorm.DB, userID string, limit int) ([]LoginAttempt, error) {
+	var attempts []LoginAttempt
+
+	query := db.Debug().Order("created_at desc").Limit(limit)
+	if userID != "" {
+		query = query.Where("user_id = ?", userID)
+	}
+
+	if err := query.Find(&attempts).Error; err != nil {
+		return nil, err
+	}
+
+	return attempts, nil
+}
@@ -0,0 +1,38 @@
+package models
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCountFailedByIPCountsOnlyCheckedAttempts(t *testing.T) {
+	db := newTestDB(t, &LoginAttempt{})
+	attemptModel := LoginAttempt{}
+
+	reasons := []string{
+		LoginSuccess,
+		LoginInvalidPassword,
+		LoginUnknownEmail,
+		LoginInvalidCode,
+		LoginLocked,
+		LoginThrottled,
+		LoginIPLimited,
+		LoginSuspended,
+	}
+	for _, reason := range reasons {
+		if err := attemptModel.RecordLoginAttempt(db, &LoginAttempt{IPAddress: "203.0.113.7", Reason: reason}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := attemptModel.RecordLoginAttempt(db, &LoginAttempt{IPAddress: "203.0.113.8", Reason: LoginInvalidPassword}); err != nil {
+		t.Fatal(err)
+	}
+
+	count, err := attemptModel.CountFailedByIP(db, "203.0.113.7", time.Now().Add(-time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if count != 3 {
+		t.Errorf("CountFailedByIP() = %d, want 3", count)
+	}
+}
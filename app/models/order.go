@@ -2,6 +2,7 @@ package models
 
 import (
 	"database/sql"
+	"errors"
 	"time"
 	"strconv"
 	"strings"
@@ -24,6 +25,7 @@ type Order struct {
 	PaymentDue          time.Time
 	PaymentStatus       string          `gorm:"size:50;index"`
 	PaymentToken        sql.NullString         `gorm:"size:100;index"`
+	PaidAt              sql.NullTime    `gorm:"index"`
 	BaseTotalPrice      decimal.Decimal `gorm:"type:decimal(16,2)"`
 	TaxAmount           decimal.Decimal `gorm:"type:decimal(16,2)"`
 	TaxPercent          decimal.Decimal `gorm:"type:decimal(10,2)"`
@@ -60,6 +62,25 @@ func (o *Order) FindByID(db *gorm.DB, id string) (*Order, error) {
 }
 
 
+// AccessibleBy menentukan apakah user boleh mengakses order ini: pemilik order, atau staf
+// dengan permission yang diminta (misalnya order.read untuk melihat, order.update untuk mengubah).
+// Staf yang wajib 2FA tetapi belum mengaktifkannya diperlakukan seperti user biasa.
+func (o *Order) AccessibleBy(user *User, permission string) bool {
+	if user == nil {
+		return false
+	}
+
+	if o.UserID != "" && o.UserID == user.ID {
+		return true
+	}
+
+	if user.MustResetPassword || (user.RequiresTwoFactor() && !user.HasTwoFactor()) {
+		return false
+	}
+
+	return user.Can(permission)
+}
+
 func (o *Order) BeforeCreate(db *gorm.DB) error {
 	if o.ID == "" {
 		o.ID = uuid.New().String()
@@ -79,9 +100,13 @@ func (o *Order) CreateOrder(db *gorm.DB, order *Order) (*Order, error) {
 }
 
 func (o *Order) GetStatusLabel() string {
+	return OrderStatusLabel(o.Status)
+}
+
+func OrderStatusLabel(status int) string {
 	var statusLabel string
 
-	switch o.Status {
+	switch status {
 	case consts.OrderStatusPending:
 		statusLabel = "PENDING"
 	case consts.OrderStatusDelivered:
@@ -155,8 +180,245 @@ func intToRoman(num int) string {
 	return roman
 }
 
+// MarkAsPaid menandai order lunas. Order yang masih pending otomatis diterima (received)
+// dan perubahannya dicatat di riwayat status sebagai perubahan oleh sistem.
+//
+// Pembayaran selalu disimpan walaupun status order sudah berubah (mis. dibatalkan admin saat
+// notifikasi diproses). Order batal yang ternyata dibayar ditandai perlu refund di riwayat status.
 func (o *Order) MarkAsPaid(db *gorm.DB) error {
-	o.PaymentStatus = consts.OrderPaymentStatusPaid
-	o.Status = consts.OrderStatusReceived // atau tetap 1, tapi pahami maknanya
-	return db.Save(o).Error
-}
\ No newline at end of file
+	return db.Transaction(func(tx *gorm.DB) error {
+		now := time.Now()
+		err := tx.Model(&Order{}).Where("id = ?", o.ID).Updates(map[string]interface{}{
+			"payment_status": consts.OrderPaymentStatusPaid,
+			"paid_at":        now,
+			"updated_at":     now,
+		}).Error
+		if err != nil {
+			return err
+		}
+		o.PaymentStatus = consts.OrderPaymentStatusPaid
+		o.PaidAt = sql.NullTime{Time: now, Valid: true}
+
+		if o.Status == consts.OrderStatusPending {
+			err := o.transitionStatus(tx, consts.OrderStatusReceived, nil, "Pembayaran diterima")
+			if !errors.Is(err, ErrInvalidOrderTransition) {
+				return err
+			}
+
+			// Status sudah diubah request lain, ambil status terbaru
+			if err := tx.Model(&Order{}).Where("id = ?", o.ID).Pluck("status", &o.Status).Error; err != nil {
+				return err
+			}
+		}
+
+		if o.Status != consts.OrderStatusCancelled {
+			return nil
+		}
+
+		return tx.Create(&OrderStatusHistory{
+			OrderID:    o.ID,
+			FromStatus: o.Status,
+			ToStatus:   o.Status,
+			Note:       "Pembayaran diterima untuk order yang sudah dibatalkan, perlu refund",
+			ActorName:  "Sistem",
+		}).Error
+	})
+}
+
+// MigrateOrderPaidAt mengisi paid_at untuk order lunas yang dibuat sebelum kolom ini ada:
+// waktu pembayaran sukses pertama, atau updated_at jika tidak ada record pembayaran.
+// Dipanggil setelah AutoMigrate.
+func MigrateOrderPaidAt(db *gorm.DB) error {
+	return db.Exec("UPDATE orders SET paid_at = COALESCE(("+
+		"SELECT MIN(payments.created_at) FROM payments "+
+		"WHERE payments.order_id = orders.id AND payments.transaction_status IN ? AND payments.deleted_at IS NULL"+
+		"), orders.updated_at) "+
+		"WHERE payment_status = ? AND paid_at IS NULL",
+		[]string{consts.PaymentStatusCapture, consts.PaymentStatusSettlement}, consts.OrderPaymentStatusPaid).Error
+}
+
+var ErrInvalidOrderTransition = errors.New("perubahan status order tidak diizinkan")
+
+// orderTransitions berisi status tujuan yang boleh dari setiap status.
+// Delivered dan cancelled adalah status akhir.
+var orderTransitions = map[int][]int{
+	consts.OrderStatusPending:  {consts.OrderStatusReceived, consts.OrderStatusCancelled},
+	consts.OrderStatusReceived: {consts.OrderStatusDelivered, consts.OrderStatusCancelled},
+}
+
+func (o *Order) AllowedTransitions() []int {
+	return orderTransitions[o.Status]
+}
+
+// NeedsRefund mengecek apakah perubahan ke status berarti membatalkan order yang sudah dibayar,
+// sehingga pembayarannya harus dikembalikan.
+func (o *Order) NeedsRefund(status int) bool {
+	return status == consts.OrderStatusCancelled && o.IsPaid()
+}
+
+// CancellableByCustomer bernilai true jika pelanggan masih boleh membatalkan order sendiri,
+// yaitu order yang belum diproses dan belum dibayar.
+func (o *Order) CancellableByCustomer() bool {
+	return o.Status == consts.OrderStatusPending && !o.IsPaid()
+}
+
+func (o *Order) CanTransitionTo(status int) bool {
+	for _, allowed := range orderTransitions[o.Status] {
+		if allowed == status {
+			return true
+		}
+	}
+
+	return false
+}
+
+// TransitionStatus mengubah status order jika perubahannya diizinkan dan mencatatnya
+// di riwayat status. actor adalah user yang mengubah, note opsional.
+func (o *Order) TransitionStatus(db *gorm.DB, status int, actor *User, note string) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		return o.transitionStatus(tx, status, actor, note)
+	})
+}
+
+func (o *Order) transitionStatus(tx *gorm.DB, status int, actor *User, note string) error {
+	if !o.CanTransitionTo(status) {
+		return ErrInvalidOrderTransition
+	}
+
+	actorID, actorName := "", "Sistem"
+	if actor != nil {
+		actorID = actor.ID
+		actorName = strings.TrimSpace(actor.FirstName + " " + actor.LastName)
+	}
+
+	now := time.Now()
+	updates := map[string]interface{}{
+		"status":     status,
+		"updated_at": now,
+	}
+
+	switch status {
+	case consts.OrderStatusReceived:
+		if actor != nil {
+			updates["approved_by"] = actorID
+			updates["approved_at"] = now
+		}
+	case consts.OrderStatusCancelled:
+		if actor != nil {
+			updates["cancelled_by"] = actorID
+		}
+		updates["cancelled_at"] = now
+		updates["cancellation_note"] = note
+	}
+
+	// Status lama ikut di WHERE agar dua perubahan bersamaan tidak saling menimpa
+	result := tx.Model(&Order{}).Where("id = ? AND status = ?", o.ID, o.Status).Updates(updates)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrInvalidOrderTransition
+	}
+
+	history := &OrderStatusHistory{
+		OrderID:    o.ID,
+		FromStatus: o.Status,
+		ToStatus:   status,
+		Note:       note,
+		ActorID:    actorID,
+		ActorName:  actorName,
+	}
+	if err := tx.Create(history).Error; err != nil {
+		return err
+	}
+
+	o.Status = status
+	return nil
+}
+
+// UpdateNote menyimpan catatan internal order, hanya terlihat oleh staf.
+func (o *Order) UpdateNote(db *gorm.DB, note string) error {
+	err := db.Debug().Model(&Order{}).Where("id = ?", o.ID).Updates(map[string]interface{}{
+		"note":       note,
+		"updated_at": time.Now(),
+	}).Error
+	if err != nil {
+		return err
+	}
+
+	o.Note = note
+	return nil
+}
+
+// GetUserOrders mengambil order milik user, terbaru lebih dulu.
+func (o *Order) GetUserOrders(db *gorm.DB, userID string, limit int) ([]Order, error) {
+	var orders []Order
+
+	err := db.Debug().Where("user_id = ?", userID).Order("created_at desc").Limit(limit).Find(&orders).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return orders, nil
+}
+
+// GetUserOrdersPaginated mengambil order milik user untuk halaman riwayat order, beserta totalnya.
+func (o *Order) GetUserOrdersPaginated(db *gorm.DB, userID string, perPage int, page int) ([]Order, int64, error) {
+	var orders []Order
+	var count int64
+
+	query := db.Debug().Model(&Order{}).Where("user_id = ?", userID)
+	if err := query.Count(&count).Error; err != nil {
+		return nil, 0, err
+	}
+
+	offset := (page - 1) * perPage
+	err := query.Preload("OrderItems").
+		Order("created_at desc").
+		Limit(perPage).
+		Offset(offset).
+		Find(&orders).Error
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return orders, count, nil
+}
+
+// OrderListSpec adalah kolom yang boleh dicari, diurutkan dan difilter di daftar order admin.
+var OrderListSpec = ListSpec{
+	SearchColumns: []string{"orders.code"},
+	Sorts: map[string]string{
+		"date":  "orders.created_at",
+		"total": "orders.grand_total",
+		"code":  "orders.code",
+	},
+	DefaultSort: "date",
+	DefaultDesc: true,
+	Filters: map[string]ListFilter{
+		"status": {Column: "orders.status", Int: true, Options: []string{
+			strconv.Itoa(consts.OrderStatusPending),
+			strconv.Itoa(consts.OrderStatusReceived),
+			strconv.Itoa(consts.OrderStatusDelivered),
+			strconv.Itoa(consts.OrderStatusCancelled),
+		}},
+		"payment_status": {Column: "orders.payment_status", Options: []string{
+			consts.OrderPaymentStatusUnpaid,
+			consts.OrderPaymentStatusPaid,
+		}},
+	},
+	DateColumn: "orders.created_at",
+}
+
+func (o *Order) FindOrders(db *gorm.DB, lq ListQuery) ([]Order, int64, error) {
+	var orders []Order
+
+	count, err := FindList(db.Debug().Model(&Order{}), lq, OrderListSpec, &orders, func(query *gorm.DB) *gorm.DB {
+		return query.Preload("User").Preload("OrderCustomer")
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return orders, count, nil
+}
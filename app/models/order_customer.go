@@ -33,3 +33,29 @@ func (o *OrderCustomer) BeforeCreate(db *gorm.DB) error {
 
 	return nil
 }
+
+var OrderCustomerListSpec = ListSpec{
+	SearchColumns: []string{"first_name", "last_name", "email", "phone"},
+	Sorts: map[string]string{
+		"date": "created_at",
+		"name": "first_name",
+	},
+	DefaultSort: "date",
+	DefaultDesc: true,
+	Filters: map[string]ListFilter{
+		"province": {Column: "province_name"},
+		"city":     {Column: "city_name"},
+	},
+	DateColumn: "created_at",
+}
+
+func (o *OrderCustomer) FindOrderCustomers(db *gorm.DB, lq ListQuery) ([]OrderCustomer, int64, error) {
+	var customers []OrderCustomer
+
+	count, err := FindList(db.Debug().Model(&OrderCustomer{}), lq, OrderCustomerListSpec, &customers)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return customers, count, nil
+}
@@ -34,3 +34,32 @@ func (o *OrderItem) BeforeCreate(db *gorm.DB) error {
 
 	return nil
 }
+
+var OrderItemListSpec = ListSpec{
+	SearchColumns: []string{"name"},
+	Sorts: map[string]string{
+		"date":     "created_at",
+		"qty":      "qty",
+		"subtotal": "sub_total",
+	},
+	DefaultSort: "date",
+	DefaultDesc: true,
+	Filters: map[string]ListFilter{
+		"order_id":   {Column: "order_id"},
+		"product_id": {Column: "product_id"},
+	},
+	DateColumn: "created_at",
+}
+
+func (o *OrderItem) FindOrderItems(db *gorm.DB, lq ListQuery) ([]OrderItem, int64, error) {
+	var items []OrderItem
+
+	count, err := FindList(db.Debug().Model(&OrderItem{}), lq, OrderItemListSpec, &items, func(query *gorm.DB) *gorm.DB {
+		return query.Preload("Product")
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return items, count, nil
+}
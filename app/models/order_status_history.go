@@ -0,0 +1,50 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// OrderStatusHistory mencatat setiap perubahan status order beserta pelakunya.
+// ActorID kosong berarti perubahan dilakukan sistem (misalnya notifikasi pembayaran).
+// Nama pelaku disimpan saat perubahan terjadi agar riwayat tetap terbaca walau user dihapus.
+type OrderStatusHistory struct {
+	ID         string `gorm:"size:36;not null;uniqueIndex;primary_key"`
+	OrderID    string `gorm:"size:36;index"`
+	FromStatus int
+	ToStatus   int
+	Note       string `gorm:"size:255"`
+	ActorID    string `gorm:"size:36;index"`
+	ActorName  string `gorm:"size:255"`
+	CreatedAt  time.Time
+}
+
+func (h *OrderStatusHistory) BeforeCreate(db *gorm.DB) error {
+	if h.ID == "" {
+		h.ID = uuid.New().String()
+	}
+
+	return nil
+}
+
+func (h *OrderStatusHistory) FromStatusLabel() string {
+	return OrderStatusLabel(h.FromStatus)
+}
+
+func (h *OrderStatusHistory) ToStatusLabel() string {
+	return OrderStatusLabel(h.ToStatus)
+}
+
+// GetOrderStatusHistories mengambil riwayat status order, terlama lebih dulu.
+func (h *OrderStatusHistory) GetOrderStatusHistories(db *gorm.DB, orderID string) ([]OrderStatusHistory, error) {
+	var histories []OrderStatusHistory
+
+	err := db.Debug().Where("order_id = ?", orderID).Order("created_at asc").Find(&histories).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return histories, nil
+}
@@ -0,0 +1,130 @@
+package models
+
+import (
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/gieart87/gotoko/app/consts"
+)
+
+func testUser(id string, roleName string, permissions ...string) *User {
+	user := &User{ID: id}
+	if roleName == "" {
+		return user
+	}
+
+	user.RoleID = "role-" + roleName
+	user.Role = Role{ID: user.RoleID, Name: roleName}
+	for _, name := range permissions {
+		user.Role.Permissions = append(user.Role.Permissions, Permission{Name: name})
+	}
+
+	return user
+}
+
+func TestOrderAccessibleBy(t *testing.T) {
+	now := time.Now()
+	order := &Order{ID: "order-1", UserID: "owner"}
+
+	mustReset := testUser("staff-reset", consts.RoleOperator, consts.PermissionOrderRead)
+	mustReset.MustResetPassword = true
+	mustReset.TOTPSecret = "secret"
+	mustReset.TOTPEnabledAt = &now
+
+	operator := testUser("operator", consts.RoleOperator, consts.PermissionOrderRead)
+	operator.TOTPSecret = "secret"
+	operator.TOTPEnabledAt = &now
+
+	tests := []struct {
+		name       string
+		user       *User
+		permission string
+		want       bool
+	}{
+		{"guest", nil, consts.PermissionOrderRead, false},
+		{"owner", testUser("owner", consts.RoleCustomer), consts.PermissionOrderRead, true},
+		{"owner without role", testUser("owner", ""), consts.PermissionOrderUpdate, true},
+		{"other customer", testUser("other", consts.RoleCustomer), consts.PermissionOrderRead, false},
+		{"staff with order.read", operator, consts.PermissionOrderRead, true},
+		{"staff without the requested permission", operator, consts.PermissionOrderUpdate, false},
+		{"staff who must reset password", mustReset, consts.PermissionOrderRead, false},
+		{"admin without 2FA", testUser("admin", consts.RoleAdmin, consts.PermissionOrderRead), consts.PermissionOrderRead, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := order.AccessibleBy(tt.user, tt.permission); got != tt.want {
+				t.Errorf("AccessibleBy() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestOrderWithoutOwnerIsNotAccessibleByEmptyUserID(t *testing.T) {
+	order := &Order{ID: "order-1"}
+	if order.AccessibleBy(&User{}, consts.PermissionOrderRead) {
+		t.Error("order without owner must not match a user with empty ID")
+	}
+}
+
+func TestOrderNeedsRefund(t *testing.T) {
+	paid := &Order{Status: consts.OrderStatusReceived, PaymentStatus: consts.OrderPaymentStatusPaid}
+	unpaid := &Order{Status: consts.OrderStatusPending}
+
+	if !paid.NeedsRefund(consts.OrderStatusCancelled) {
+		t.Error("cancelling a paid order must need a refund")
+	}
+	if paid.NeedsRefund(consts.OrderStatusDelivered) {
+		t.Error("delivering a paid order must not need a refund")
+	}
+	if unpaid.NeedsRefund(consts.OrderStatusCancelled) {
+		t.Error("cancelling an unpaid order must not need a refund")
+	}
+}
+
+func TestMarkAsPaidKeepsPaymentWhenStatusChangedConcurrently(t *testing.T) {
+	db := newTestDB(t, &Order{}, &OrderStatusHistory{})
+
+	tests := []struct {
+		name        string
+		storeStatus int
+		wantStatus  int
+		wantRefund  bool
+	}{
+		{"pending order is received", consts.OrderStatusPending, consts.OrderStatusReceived, false},
+		{"order cancelled while the notification is handled", consts.OrderStatusCancelled, consts.OrderStatusCancelled, true},
+	}
+
+	for i, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			order := &Order{ID: "order-" + strconv.Itoa(i), Code: "order-" + strconv.Itoa(i), Status: consts.OrderStatusPending}
+			if err := db.Create(order).Error; err != nil {
+				t.Fatal(err)
+			}
+
+			// Status di database berubah setelah order dimuat oleh handler notifikasi
+			if err := db.Model(&Order{}).Where("id = ?", order.ID).Update("status", tt.storeStatus).Error; err != nil {
+				t.Fatal(err)
+			}
+
+			if err := order.MarkAsPaid(db); err != nil {
+				t.Fatalf("MarkAsPaid() error = %v", err)
+			}
+
+			var stored Order
+			if err := db.First(&stored, "id = ?", order.ID).Error; err != nil {
+				t.Fatal(err)
+			}
+			if !stored.IsPaid() || !stored.PaidAt.Valid || stored.Status != tt.wantStatus {
+				t.Errorf("stored order = status %d, payment %s, paid_at %v", stored.Status, stored.PaymentStatus, stored.PaidAt)
+			}
+
+			var refunds int64
+			db.Model(&OrderStatusHistory{}).Where("order_id = ? AND note LIKE ?", order.ID, "%refund%").Count(&refunds)
+			if (refunds > 0) != tt.wantRefund {
+				t.Errorf("refund flagged = %v, want %v", refunds > 0, tt.wantRefund)
+			}
+		})
+	}
+}
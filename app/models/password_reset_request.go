@@ -0,0 +1,53 @@
+package models
+
+import (
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// PasswordResetRequest mencatat permintaan link reset password yang diproses, untuk membatasi
+// permintaan per email dan per IP. Email yang tidak terdaftar ikut dicatat.
+type PasswordResetRequest struct {
+	ID        string    `gorm:"size:36;not null;uniqueIndex;primary_key"`
+	Email     string    `gorm:"size:100;index"`
+	IPAddress string    `gorm:"size:45;index"`
+	CreatedAt time.Time `gorm:"index"`
+}
+
+func (p *PasswordResetRequest) BeforeCreate(db *gorm.DB) error {
+	if p.ID == "" {
+		p.ID = uuid.New().String()
+	}
+
+	return nil
+}
+
+func (p *PasswordResetRequest) RecordPasswordResetRequest(db *gorm.DB, email string, ip string) error {
+	return db.Create(&PasswordResetRequest{
+		Email:     strings.ToLower(strings.TrimSpace(email)),
+		IPAddress: ip,
+	}).Error
+}
+
+func (p *PasswordResetRequest) CountByEmail(db *gorm.DB, email string, since time.Time) (int64, error) {
+	var count int64
+
+	err := db.Model(&PasswordResetRequest{}).
+		Where("email = ? AND created_at >= ?", strings.ToLower(strings.TrimSpace(email)), since).
+		Count(&count).Error
+
+	return count, err
+}
+
+func (p *PasswordResetRequest) CountByIP(db *gorm.DB, ip string, since time.Time) (int64, error) {
+	var count int64
+
+	err := db.Model(&PasswordResetRequest{}).
+		Where("ip_address = ? AND created_at >= ?", ip, since).
+		Count(&count).Error
+
+	return count, err
+}
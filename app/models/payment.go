@@ -106,4 +106,15 @@ func (p *Payment) CreatePayment(db *gorm.DB, payment *Payment) (*Payment, error)
 	}
 
 	return payment, nil
-}
\ No newline at end of file
+}
+
+func (p *Payment) GetOrderPayments(db *gorm.DB, orderID string) ([]Payment, error) {
+	var payments []Payment
+
+	err := db.Debug().Where("order_id = ?", orderID).Order("created_at desc").Find(&payments).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return payments, nil
+}
@@ -0,0 +1,35 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type Permission struct {
+	ID          string `gorm:"size:36;not null;uniqueIndex;primary_key"`
+	Name        string `gorm:"size:100;not null;uniqueIndex"`
+	Description string `gorm:"size:255"`
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+}
+
+func (p *Permission) BeforeCreate(db *gorm.DB) error {
+	if p.ID == "" {
+		p.ID = uuid.New().String()
+	}
+
+	return nil
+}
+
+func (p *Permission) GetPermissions(db *gorm.DB) ([]Permission, error) {
+	var permissions []Permission
+
+	err := db.Debug().Order("name asc").Find(&permissions).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return permissions, nil
+}
@@ -20,51 +20,48 @@ type Product struct {
 	Price            decimal.Decimal `gorm:"type:decimal(16,2);"`
 	Stock            int
 	Weight           decimal.Decimal `gorm:"type:decimal(10,2);"`
+	Manufacturer     string          `gorm:"size:255;index"`
+	DosageForm       string          `gorm:"size:50;index"`
 	ShortDescription string          `gorm:"type:text"`
 	Description      string          `gorm:"type:text"`
 	Status           int             `gorm:"default:0"`
+	SearchCategories string          `gorm:"type:text"`
 	CreatedAt        time.Time
 	UpdatedAt        time.Time
 	DeletedAt        gorm.DeletedAt
 }
 
-func (p *Product) GetProducts(db *gorm.DB, perPage int, page int, categorySlug string) (*[]Product, int64, error) {
-    var products []Product
-    var count int64
-
-    // 1. Mulai query dasar tanpa Join dulu untuk menghitung total
-    query := db.Debug().Model(&Product{})
+// PrimaryImage mengembalikan gambar utama, atau gambar pertama jika belum ada yang ditandai.
+// ProductImages harus sudah di-preload dengan OrderProductImages.
+func (p *Product) PrimaryImage() *ProductImage {
+	for i := range p.ProductImages {
+		if p.ProductImages[i].IsPrimary {
+			return &p.ProductImages[i]
+		}
+	}
 
-    // 2. Jika ada filter kategori, Join HANYA untuk filtering
-    if categorySlug != "" {
-        query = query.Joins("JOIN product_categories ON product_categories.product_id = products.id").
-                      Joins("JOIN categories ON categories.id = product_categories.category_id").
-                      Where("categories.slug = ?", categorySlug)
-    }
+	if len(p.ProductImages) > 0 {
+		return &p.ProductImages[0]
+	}
 
-    // Hitung total data (Count)
-    if err := query.Count(&count).Error; err != nil {
-        return nil, 0, err
-    }
+	return nil
+}
 
-    // 3. AMBIL DATA DENGAN PRELOAD TERPISAH
-    // Kita panggil Preload di sini agar GORM melakukan query kedua untuk mengambil relasi
-    offset := (page - 1) * perPage
-    err := query.Preload("Categories"). // Ini akan membaca tabel image_553461.png
-                Preload("ProductImages").
-                Order("products.created_at desc").
-                Limit(perPage).
-                Offset(offset).
-                Find(&products).Error
-
-    return &products, count, err
+// GetProducts mengambil produk terbaru, opsional difilter berdasarkan kategori (termasuk sub-kategori).
+// Untuk filter & urutan lain gunakan FilterProducts.
+func (p *Product) GetProducts(db *gorm.DB, perPage int, page int, categorySlug string) (*[]Product, int64, error) {
+    return p.FilterProducts(db, perPage, page, ProductFilter{
+        CategorySlug: categorySlug,
+        Sort:         SortNewest,
+    })
 }
+
 func (p *Product) FindBySlug(db *gorm.DB, slug string) (*Product, error) {
 	var product Product
 
 	// Tambahkan Preload("Categories") untuk halaman detail produk
 	err := db.Debug().
-		Preload("ProductImages").
+		Preload("ProductImages", OrderProductImages).
 		Preload("Categories").
 		Model(&Product{}).
 		Where("slug = ?", slug).
@@ -81,7 +78,22 @@ func (p *Product) FindByID(db *gorm.DB, productID string) (*Product, error) {
 	var err error
 	var product Product
 
-	err = db.Debug().Preload("ProductImages").Model(&Product{}).Where("id =?", productID).First(&product).Error
+	err = db.Debug().Preload("ProductImages", OrderProductImages).Model(&Product{}).Where("id =?", productID).First(&product).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return &product, nil
+}
+
+func (p *Product) FindBySku(db *gorm.DB, sku string) (*Product, error) {
+	var product Product
+
+	err := db.Debug().
+		Preload("ProductImages", OrderProductImages).
+		Preload("Categories").
+		Where("sku = ?", sku).
+		First(&product).Error
 	if err != nil {
 		return nil, err
 	}
@@ -101,7 +113,7 @@ func (p *Product) SearchByKeywords(db *gorm.DB, keywords []string) ([]Product, e
 
     // Gunakan ILIKE (PostgreSQL) atau LIKE (MySQL) 
     // agar 'cetaphil' bisa menemukan 'Cetaphil'
-    err := db.Debug().Preload("ProductImages").
+    err := db.Debug().Preload("ProductImages", OrderProductImages).
         Where("LOWER(name) LIKE LOWER(?)", mainKeyword).
         Find(&products).Error
 
@@ -113,10 +125,42 @@ func (p *Product) StandardSearch(db *gorm.DB, query string) ([]Product, error) {
     
     keyword := "%" + query + "%"
     
-    err := db.Debug().Preload("ProductImages").
+    err := db.Debug().Preload("ProductImages", OrderProductImages).
         Where("LOWER(name) LIKE LOWER(?)", keyword).
         Or("LOWER(description) LIKE LOWER(?)", keyword).
         Find(&products).Error
         
     return products, err
-}
\ No newline at end of file
+}
+
+// ProductListSpec dipakai daftar produk admin; pencarian storefront memakai ProductFilter.
+var ProductListSpec = ListSpec{
+	SearchColumns: []string{"products.name", "products.sku", "products.manufacturer"},
+	Sorts: map[string]string{
+		"date":  "products.created_at",
+		"name":  "products.name",
+		"price": "products.price",
+		"stock": "products.stock",
+	},
+	DefaultSort: "date",
+	DefaultDesc: true,
+	Filters: map[string]ListFilter{
+		"status":       {Column: "products.status", Int: true},
+		"manufacturer": {Column: "products.manufacturer"},
+		"dosage_form":  {Column: "products.dosage_form"},
+	},
+	DateColumn: "products.created_at",
+}
+
+func (p *Product) FindAdminProducts(db *gorm.DB, lq ListQuery) ([]Product, int64, error) {
+	var products []Product
+
+	count, err := FindList(db.Debug().Model(&Product{}), lq, ProductListSpec, &products, func(query *gorm.DB) *gorm.DB {
+		return query.Preload("Categories").Preload("ProductImages", OrderProductImages)
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return products, count, nil
+}
@@ -0,0 +1,380 @@
+package models
+
+import (
+	"errors"
+	"net/url"
+	"strings"
+
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+)
+
+const (
+	SortNewest    = "newest"
+	SortPriceAsc  = "price_asc"
+	SortPriceDesc = "price_desc"
+	SortNameAsc   = "name_asc"
+	SortNameDesc  = "name_desc"
+	SortPopular   = "popular"
+)
+
+// Nama facet, dipakai agar facet tidak memfilter dirinya sendiri saat menghitung jumlah.
+const (
+	facetCategory     = "category"
+	facetPrice        = "price"
+	facetStock        = "stock"
+	facetManufacturer = "manufacturer"
+	facetDosageForm   = "dosage_form"
+)
+
+var productSorts = map[string]string{
+	SortNewest:    "products.created_at desc",
+	SortPriceAsc:  "products.price asc, products.created_at desc",
+	SortPriceDesc: "products.price desc, products.created_at desc",
+	SortNameAsc:   "products.name asc",
+	SortNameDesc:  "products.name desc",
+	SortPopular:   "COALESCE(sales.sold, 0) desc, products.created_at desc",
+}
+
+// Rentang harga (Rupiah) yang ditampilkan sebagai facet.
+var priceRanges = []struct {
+	Label string
+	Min   string
+	Max   string
+}{
+	{"< Rp25.000", "", "25000"},
+	{"Rp25.000 - Rp50.000", "25000", "50000"},
+	{"Rp50.000 - Rp100.000", "50000", "100000"},
+	{"Rp100.000 - Rp250.000", "100000", "250000"},
+	{"> Rp250.000", "250000", ""},
+}
+
+type ProductFilter struct {
+	CategorySlug  string
+	MinPrice      *decimal.Decimal
+	MaxPrice      *decimal.Decimal
+	InStock       bool
+	Manufacturers []string
+	DosageForms   []string
+	Sort          string
+}
+
+type FacetValue struct {
+	Value    string
+	Label    string
+	Count    int64
+	Selected bool
+}
+
+type PriceRangeFacet struct {
+	Label    string
+	Min      string
+	Max      string
+	Count    int64
+	Selected bool
+}
+
+type ProductFacets struct {
+	Categories    []FacetValue
+	Manufacturers []FacetValue
+	DosageForms   []FacetValue
+	PriceRanges   []PriceRangeFacet
+	InStockCount  int64
+}
+
+// ProductFilterFromQuery membaca filter dari query string:
+// ?category=&min_price=&max_price=&in_stock=1&manufacturer=..&dosage_form=..&sort=
+func ProductFilterFromQuery(q url.Values) ProductFilter {
+	filter := ProductFilter{
+		CategorySlug:  q.Get("category"),
+		InStock:       q.Get("in_stock") == "1",
+		Manufacturers: nonEmpty(q["manufacturer"]),
+		DosageForms:   nonEmpty(q["dosage_form"]),
+		Sort:          q.Get("sort"),
+	}
+
+	if minPrice, err := decimal.NewFromString(q.Get("min_price")); err == nil {
+		filter.MinPrice = &minPrice
+	}
+	if maxPrice, err := decimal.NewFromString(q.Get("max_price")); err == nil {
+		filter.MaxPrice = &maxPrice
+	}
+
+	if _, ok := productSorts[filter.Sort]; !ok {
+		filter.Sort = SortNewest
+	}
+
+	return filter
+}
+
+func nonEmpty(values []string) []string {
+	var result []string
+	for _, value := range values {
+		if value = strings.TrimSpace(value); value != "" {
+			result = append(result, value)
+		}
+	}
+
+	return result
+}
+
+// filterQuery membuat query produk dengan semua filter kecuali facet skip.
+// Mengembalikan nil jika kategori yang diminta tidak ada (hasil pasti kosong).
+func (f ProductFilter) filterQuery(db *gorm.DB, skip string) (*gorm.DB, error) {
+	query := db.Model(&Product{})
+
+	// Filter kategori ikut menyertakan seluruh sub-kategori.
+	// Subquery dipakai (bukan Join) agar produk dengan banyak kategori tidak terhitung ganda.
+	if f.CategorySlug != "" && skip != facetCategory {
+		categoryModel := Category{}
+		category, err := categoryModel.FindBySlug(db, f.CategorySlug)
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return nil, nil
+			}
+			return nil, err
+		}
+
+		categoryIDs, err := categoryModel.DescendantIDs(db, category.ID)
+		if err != nil {
+			return nil, err
+		}
+
+		query = query.Where("products.id IN (?)",
+			db.Table("product_categories").Select("product_id").Where("category_id IN ?", categoryIDs))
+	}
+
+	if skip != facetPrice {
+		if f.MinPrice != nil {
+			query = query.Where("products.price >= ?", *f.MinPrice)
+		}
+		if f.MaxPrice != nil {
+			query = query.Where("products.price < ?", *f.MaxPrice)
+		}
+	}
+
+	if f.InStock && skip != facetStock {
+		query = query.Where("products.stock > 0")
+	}
+
+	if len(f.Manufacturers) > 0 && skip != facetManufacturer {
+		query = query.Where("products.manufacturer IN ?", f.Manufacturers)
+	}
+
+	if len(f.DosageForms) > 0 && skip != facetDosageForm {
+		query = query.Where("products.dosage_form IN ?", f.DosageForms)
+	}
+
+	return query, nil
+}
+
+// FilterProducts mengembalikan produk sesuai filter dan urutan, beserta total untuk paginasi.
+func (p *Product) FilterProducts(db *gorm.DB, perPage int, page int, filter ProductFilter) (*[]Product, int64, error) {
+	var products []Product
+	var count int64
+
+	query, err := filter.filterQuery(db.Debug(), "")
+	if err != nil || query == nil {
+		return &products, 0, err
+	}
+
+	if err := query.Session(&gorm.Session{}).Count(&count).Error; err != nil {
+		return nil, 0, err
+	}
+
+	order, ok := productSorts[filter.Sort]
+	if !ok {
+		order = productSorts[SortNewest]
+	}
+
+	if filter.Sort == SortPopular {
+		// Popularitas = total qty terjual dari seluruh order
+		sales := db.Table("order_items").Select("product_id, SUM(qty) AS sold").Group("product_id")
+		query = query.Select("products.*").
+			Joins("LEFT JOIN (?) AS sales ON sales.product_id = products.id", sales)
+	}
+
+	offset := (page - 1) * perPage
+	err = query.Preload("Categories").
+		Preload("ProductImages", OrderProductImages).
+		Order(order).
+		Limit(perPage).
+		Offset(offset).
+		Find(&products).Error
+
+	return &products, count, err
+}
+
+// ProductFacets menghitung jumlah produk untuk setiap nilai facet. Setiap facet dihitung
+// dengan filter lain yang aktif, tetapi tanpa filternya sendiri, sehingga pilihan lain
+// di facet yang sama tetap terlihat.
+func (p *Product) ProductFacets(db *gorm.DB, filter ProductFilter) (*ProductFacets, error) {
+	facets := &ProductFacets{}
+
+	if err := facets.countCategories(db, filter); err != nil {
+		return nil, err
+	}
+
+	var err error
+	if facets.Manufacturers, err = countColumnFacet(db, filter, facetManufacturer, "manufacturer", filter.Manufacturers); err != nil {
+		return nil, err
+	}
+	if facets.DosageForms, err = countColumnFacet(db, filter, facetDosageForm, "dosage_form", filter.DosageForms); err != nil {
+		return nil, err
+	}
+
+	if err := facets.countPriceRanges(db, filter); err != nil {
+		return nil, err
+	}
+
+	query, err := filter.filterQuery(db, facetStock)
+	if err != nil {
+		return nil, err
+	}
+	if query != nil {
+		if err := query.Where("products.stock > 0").Count(&facets.InStockCount).Error; err != nil {
+			return nil, err
+		}
+	}
+
+	return facets, nil
+}
+
+func (facets *ProductFacets) countCategories(db *gorm.DB, filter ProductFilter) error {
+	query, err := filter.filterQuery(db, facetCategory)
+	if err != nil || query == nil {
+		return err
+	}
+
+	var pairs []struct {
+		ProductID  string
+		CategoryID string
+	}
+	err = db.Debug().Table("product_categories").
+		Distinct("product_id", "category_id").
+		Where("product_id IN (?)", query.Select("products.id")).
+		Scan(&pairs).Error
+	if err != nil {
+		return err
+	}
+
+	var categories []Category
+	if err := db.Debug().Select("id", "parent_id", "slug", "name").Order("name asc").Find(&categories).Error; err != nil {
+		return err
+	}
+
+	parentOf := make(map[string]string, len(categories))
+	for _, category := range categories {
+		parentOf[category.ID] = category.ParentID
+	}
+
+	// Produk di sub-kategori ikut dihitung pada seluruh parent-nya, sama seperti filter kategori
+	// yang menyertakan DescendantIDs. Produk yang sama hanya dihitung sekali per kategori.
+	products := make(map[string]map[string]bool)
+	for _, pair := range pairs {
+		visited := map[string]bool{}
+		for categoryID := pair.CategoryID; categoryID != "" && !visited[categoryID]; categoryID = parentOf[categoryID] {
+			visited[categoryID] = true
+			if products[categoryID] == nil {
+				products[categoryID] = make(map[string]bool)
+			}
+			products[categoryID][pair.ProductID] = true
+		}
+	}
+
+	for _, category := range categories {
+		if len(products[category.ID]) == 0 {
+			continue
+		}
+		facets.Categories = append(facets.Categories, FacetValue{
+			Value:    category.Slug,
+			Label:    category.Name,
+			Count:    int64(len(products[category.ID])),
+			Selected: category.Slug == filter.CategorySlug,
+		})
+	}
+
+	return nil
+}
+
+func countColumnFacet(db *gorm.DB, filter ProductFilter, facet string, column string, selected []string) ([]FacetValue, error) {
+	query, err := filter.filterQuery(db, facet)
+	if err != nil || query == nil {
+		return nil, err
+	}
+
+	var rows []struct {
+		Value string
+		Count int64
+	}
+	err = query.Select("products." + column + " AS value, COUNT(*) AS count").
+		Where("products." + column + " <> ''").
+		Group("products." + column).
+		Order("products." + column + " asc").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	values := make([]FacetValue, len(rows))
+	for i, row := range rows {
+		values[i] = FacetValue{
+			Value:    row.Value,
+			Label:    row.Value,
+			Count:    row.Count,
+			Selected: containsString(selected, row.Value),
+		}
+	}
+
+	return values, nil
+}
+
+func (facets *ProductFacets) countPriceRanges(db *gorm.DB, filter ProductFilter) error {
+	for _, priceRange := range priceRanges {
+		query, err := filter.filterQuery(db, facetPrice)
+		if err != nil {
+			return err
+		}
+
+		facet := PriceRangeFacet{
+			Label:    priceRange.Label,
+			Min:      priceRange.Min,
+			Max:      priceRange.Max,
+			Selected: decimalString(filter.MinPrice) == priceRange.Min && decimalString(filter.MaxPrice) == priceRange.Max,
+		}
+
+		if query != nil {
+			if priceRange.Min != "" {
+				query = query.Where("products.price >= ?", priceRange.Min)
+			}
+			if priceRange.Max != "" {
+				query = query.Where("products.price < ?", priceRange.Max)
+			}
+			if err := query.Count(&facet.Count).Error; err != nil {
+				return err
+			}
+		}
+
+		facets.PriceRanges = append(facets.PriceRanges, facet)
+	}
+
+	return nil
+}
+
+func decimalString(value *decimal.Decimal) string {
+	if value == nil {
+		return ""
+	}
+
+	return value.String()
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,56 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/shopspring/decimal"
+)
+
+func TestProductFacetsCountSubcategoriesInParent(t *testing.T) {
+	db := newTestDB(t, &Section{}, &Category{}, &Product{}, &ProductImage{})
+
+	categories := []Category{
+		{ID: "obat", Name: "Obat", Slug: "obat"},
+		{ID: "analgesik", ParentID: "obat", Name: "Analgesik", Slug: "analgesik"},
+		{ID: "vitamin", Name: "Vitamin", Slug: "vitamin"},
+	}
+	if err := db.Create(&categories).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	products := []Product{
+		{ID: "p1", Name: "Paracetamol", Slug: "paracetamol", Price: decimal.NewFromInt(10000), Categories: []Category{categories[1]}},
+		{ID: "p2", Name: "Ibuprofen", Slug: "ibuprofen", Price: decimal.NewFromInt(20000), Categories: []Category{categories[0], categories[1]}},
+		{ID: "p3", Name: "Vitamin C", Slug: "vitamin-c", Price: decimal.NewFromInt(30000), Categories: []Category{categories[2]}},
+	}
+	if err := db.Create(&products).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	productModel := Product{}
+	filter := ProductFilter{CategorySlug: "obat"}
+
+	facets, err := productModel.ProductFacets(db, filter)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, total, err := productModel.FilterProducts(db, 10, 1, filter)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	counts := map[string]int64{}
+	for _, facet := range facets.Categories {
+		counts[facet.Value] = facet.Count
+	}
+
+	want := map[string]int64{"obat": 2, "analgesik": 2, "vitamin": 1}
+	for slug, count := range want {
+		if counts[slug] != count {
+			t.Errorf("facet %s = %d, want %d (all: %v)", slug, counts[slug], count, counts)
+		}
+	}
+	if counts["obat"] != total {
+		t.Errorf("facet obat = %d, but filtering by obat returns %d products", counts["obat"], total)
+	}
+}
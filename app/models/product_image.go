@@ -1,6 +1,13 @@
 package models
 
-import "time"
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
 
 type ProductImage struct {
 	ID         string `gorm:"size:36;not null;uniqueIndex;primary_key"`
@@ -11,6 +18,205 @@ type ProductImage struct {
 	Large      string `gorm:"type:text"`
 	Medium     string `gorm:"type:text"`
 	Small      string `gorm:"type:text"`
+	AltText    string `gorm:"size:255"`
+	Position   int    `gorm:"default:0;index"`
+	IsPrimary  bool   `gorm:"default:false"`
 	CreatedAt  time.Time
 	UpdatedAt  time.Time
 }
+
+// AssetURL mengubah path file yang tersimpan di database menjadi URL publik.
+// Nilainya diganti sesuai backend storage saat server diinisialisasi.
+var AssetURL = func(path string) string {
+	return "/" + strings.TrimPrefix(path, "/")
+}
+
+// OrderProductImages dipakai sebagai kondisi Preload agar gambar selalu urut sesuai Position,
+// contoh: db.Preload("ProductImages", OrderProductImages).
+func OrderProductImages(db *gorm.DB) *gorm.DB {
+	return db.Order("position asc, created_at asc")
+}
+
+func (p *ProductImage) FindByID(db *gorm.DB, productID string, imageID string) (*ProductImage, error) {
+	var productImage ProductImage
+
+	err := db.Debug().Where("id = ? AND product_id = ?", imageID, productID).First(&productImage).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return &productImage, nil
+}
+
+// FindByPath mencari gambar yang sudah tersimpan dengan file asli di path tersebut.
+func (p *ProductImage) FindByPath(db *gorm.DB, path string) (*ProductImage, error) {
+	var productImage ProductImage
+
+	err := db.Debug().Where("path = ?", path).Order("created_at asc").First(&productImage).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return &productImage, nil
+}
+
+// NextPosition mengembalikan posisi setelah gambar terakhir milik produk.
+func (p *ProductImage) NextPosition(db *gorm.DB, productID string) (int, error) {
+	var maxPosition *int
+
+	err := db.Model(&ProductImage{}).
+		Where("product_id = ?", productID).
+		Select("MAX(position)").
+		Scan(&maxPosition).Error
+	if err != nil {
+		return 0, err
+	}
+
+	if maxPosition == nil {
+		return 0, nil
+	}
+
+	return *maxPosition + 1, nil
+}
+
+// Reorder menyimpan urutan gambar sesuai urutan imageIDs (hasil drag-and-drop).
+// imageIDs harus berisi semua gambar produk tepat satu kali, agar tidak ada posisi ganda.
+func (p *ProductImage) Reorder(db *gorm.DB, productID string, imageIDs []string) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		var count, total int64
+		if err := tx.Model(&ProductImage{}).Where("product_id = ? AND id IN ?", productID, imageIDs).Count(&count).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&ProductImage{}).Where("product_id = ?", productID).Count(&total).Error; err != nil {
+			return err
+		}
+
+		if int(count) != len(imageIDs) || count != total {
+			return errors.New("urutan harus berisi semua gambar produk")
+		}
+
+		for position, imageID := range imageIDs {
+			err := tx.Model(&ProductImage{}).
+				Where("id = ? AND product_id = ?", imageID, productID).
+				Update("position", position).Error
+			if err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
+// SetPrimary menjadikan satu gambar sebagai gambar utama produk.
+func (p *ProductImage) SetPrimary(db *gorm.DB) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&ProductImage{}).Where("product_id = ?", p.ProductID).Update("is_primary", false).Error; err != nil {
+			return err
+		}
+
+		p.IsPrimary = true
+		return tx.Model(&ProductImage{}).Where("id = ?", p.ID).Update("is_primary", true).Error
+	})
+}
+
+// DeleteImage menghapus record gambar. Jika gambar utama yang dihapus,
+// gambar dengan posisi paling awal menjadi gambar utama yang baru.
+func (p *ProductImage) DeleteImage(db *gorm.DB) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Unscoped().Delete(p).Error; err != nil {
+			return err
+		}
+
+		if !p.IsPrimary {
+			return nil
+		}
+
+		var next ProductImage
+		err := OrderProductImages(tx.Where("product_id = ?", p.ProductID)).First(&next).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		return tx.Model(&ProductImage{}).Where("id = ?", next.ID).Update("is_primary", true).Error
+	})
+}
+
+// SetVariant mengisi kolom ukuran berdasarkan nama varian dari utils.ImageVariants.
+func (p *ProductImage) SetVariant(name string, path string) {
+	switch name {
+	case "extra_large":
+		p.ExtraLarge = path
+	case "large":
+		p.Large = path
+	case "medium":
+		p.Medium = path
+	case "small":
+		p.Small = path
+	}
+}
+
+// HasVariants bernilai false untuk gambar lama yang belum diproses.
+func (p *ProductImage) HasVariants() bool {
+	return p.ExtraLarge != "" && p.Large != "" && p.Medium != "" && p.Small != ""
+}
+
+// Files mengembalikan path file asli beserta seluruh variannya (tanpa duplikat).
+func (p *ProductImage) Files() []string {
+	var files []string
+	seen := map[string]bool{}
+	for _, path := range []string{p.Path, p.ExtraLarge, p.Large, p.Medium, p.Small} {
+		if path == "" || seen[path] {
+			continue
+		}
+		seen[path] = true
+		files = append(files, path)
+	}
+
+	return files
+}
+
+// URL dipakai di template, contoh: {{ .URL "medium" }}.
+// Jika varian belum tersedia maka kembali ke gambar asli.
+func (p *ProductImage) URL(size string) string {
+	path := p.Path
+	switch size {
+	case "extra_large":
+		path = firstNonEmpty(p.ExtraLarge, p.Path)
+	case "large":
+		path = firstNonEmpty(p.Large, p.Path)
+	case "medium":
+		path = firstNonEmpty(p.Medium, p.Path)
+	case "small":
+		path = firstNonEmpty(p.Small, p.Path)
+	}
+
+	return AssetURL(path)
+}
+
+// SrcSet dipakai untuk atribut srcset pada tag <img> agar browser memilih ukuran yang sesuai.
+func (p *ProductImage) SrcSet() string {
+	if !p.HasVariants() {
+		return ""
+	}
+
+	return strings.Join([]string{
+		fmt.Sprintf("%s 150w", p.URL("small")),
+		fmt.Sprintf("%s 400w", p.URL("medium")),
+		fmt.Sprintf("%s 800w", p.URL("large")),
+		fmt.Sprintf("%s 1200w", p.URL("extra_large")),
+	}, ", ")
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, value := range values {
+		if value != "" {
+			return value
+		}
+	}
+
+	return ""
+}
@@ -0,0 +1,49 @@
+package models
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestReorderRequiresEveryImageOfProduct(t *testing.T) {
+	db := newTestDB(t, &ProductImage{})
+
+	images := []ProductImage{
+		{ID: "a", ProductID: "p1", Position: 0},
+		{ID: "b", ProductID: "p1", Position: 1},
+		{ID: "c", ProductID: "p1", Position: 2},
+		{ID: "other", ProductID: "p2", Position: 0},
+	}
+	if err := db.Create(&images).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		imageIDs []string
+		wantErr  bool
+	}{
+		{"subset", []string{"c", "a"}, true},
+		{"duplicate", []string{"c", "a", "a"}, true},
+		{"image of another product", []string{"c", "a", "other"}, true},
+		{"every image", []string{"c", "a", "b"}, false},
+	}
+
+	imageModel := ProductImage{}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := imageModel.Reorder(db, "p1", tt.imageIDs)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Reorder(%v) error = %v, wantErr %v", tt.imageIDs, err, tt.wantErr)
+			}
+		})
+	}
+
+	var order []string
+	if err := db.Model(&ProductImage{}).Where("product_id = ?", "p1").Order("position").Pluck("id", &order).Error; err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"c", "a", "b"}; !reflect.DeepEqual(order, want) {
+		t.Errorf("order = %v, want %v", order, want)
+	}
+}
@@ -0,0 +1,411 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"html"
+	"html/template"
+	"regexp"
+	"strings"
+	"sync"
+	"unicode/utf8"
+
+	"gorm.io/gorm"
+)
+
+// Penanda sementara untuk kata yang cocok; teks di-escape dulu sebelum diganti <mark>
+// sehingga hasil highlight aman dirender sebagai template.HTML.
+const (
+	highlightStart = "[[[mark]]]"
+	highlightStop  = "[[[/mark]]]"
+	snippetLength  = 200
+)
+
+// Bobot relevansi per kolom: nama & SKU paling penting, deskripsi paling rendah.
+var searchWeights = [][2]string{
+	{"name", "A"},
+	{"sku", "A"},
+	{"search_categories", "B"},
+	{"short_description", "C"},
+	{"description", "D"},
+}
+
+var (
+	searchConfigsOnce sync.Once
+	searchConfigs     []string
+)
+
+type ProductSearchResult struct {
+	Product
+	Score            float64
+	NameHighlight    template.HTML
+	SnippetHighlight template.HTML
+}
+
+type productSearchRow struct {
+	ID               string
+	Score            float64
+	NameHighlight    string
+	SnippetHighlight string
+}
+
+// productSearchConfigs mengembalikan konfigurasi text search PostgreSQL yang dipakai.
+// Bahasa Indonesia dan Inggris digunakan jika tersedia, selain itu "simple".
+func productSearchConfigs(db *gorm.DB) []string {
+	searchConfigsOnce.Do(func() {
+		for _, config := range []string{"indonesian", "english"} {
+			var count int64
+			db.Raw("SELECT COUNT(*) FROM pg_ts_config WHERE cfgname = ?", config).Scan(&count)
+			if count > 0 {
+				searchConfigs = append(searchConfigs, config)
+			}
+		}
+
+		if len(searchConfigs) == 0 {
+			searchConfigs = []string{"simple"}
+		}
+	})
+
+	return searchConfigs
+}
+
+// MigrateProductSearch membuat index full-text untuk produk. Dipanggil setelah AutoMigrate.
+// PostgreSQL: kolom generated tsvector + GIN index. MySQL: FULLTEXT index.
+func MigrateProductSearch(db *gorm.DB) error {
+	switch db.Dialector.Name() {
+	case "postgres":
+		var parts []string
+		for _, config := range productSearchConfigs(db) {
+			for _, field := range searchWeights {
+				parts = append(parts, fmt.Sprintf("setweight(to_tsvector('%s'::regconfig, coalesce(%s, '')), '%s')", config, field[0], field[1]))
+			}
+		}
+
+		err := db.Exec("ALTER TABLE products ADD COLUMN IF NOT EXISTS search_vector tsvector GENERATED ALWAYS AS (" +
+			strings.Join(parts, " || ") + ") STORED").Error
+		if err != nil {
+			return err
+		}
+
+		return db.Exec("CREATE INDEX IF NOT EXISTS idx_products_search_vector ON products USING GIN (search_vector)").Error
+	case "mysql":
+		var count int64
+		err := db.Raw("SELECT COUNT(*) FROM information_schema.statistics WHERE table_schema = DATABASE() AND table_name = 'products' AND index_name = 'idx_products_fulltext'").
+			Scan(&count).Error
+		if err != nil || count > 0 {
+			return err
+		}
+
+		return db.Exec("CREATE FULLTEXT INDEX idx_products_fulltext ON products (name, sku, short_description, description, search_categories)").Error
+	default:
+		return nil
+	}
+}
+
+// RefreshSearchCategories menyalin nama kategori ke kolom search_categories
+// agar nama kategori ikut terindeks. Tanpa productIDs semua produk diperbarui.
+func (p *Product) RefreshSearchCategories(db *gorm.DB, productIDs ...string) error {
+	var products []Product
+
+	query := db.Preload("Categories").Select("id")
+	if len(productIDs) > 0 {
+		query = query.Where("id IN ?", productIDs)
+	}
+	if err := query.Find(&products).Error; err != nil {
+		return err
+	}
+
+	for _, product := range products {
+		var names []string
+		for _, category := range product.Categories {
+			names = append(names, category.Name)
+		}
+
+		err := db.Model(&Product{}).Where("id = ?", product.ID).
+			UpdateColumn("search_categories", strings.Join(names, " ")).Error
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// FullTextSearch mencari produk berdasarkan nama, deskripsi, SKU dan nama kategori,
+// diurutkan berdasarkan relevansi.
+func (p *Product) FullTextSearch(db *gorm.DB, query string, perPage int, page int) ([]ProductSearchResult, int64, error) {
+	query = strings.TrimSpace(query)
+	if query == "" {
+		return nil, 0, nil
+	}
+
+	var (
+		rows  []productSearchRow
+		count int64
+		err   error
+	)
+
+	offset := (page - 1) * perPage
+
+	switch db.Dialector.Name() {
+	case "postgres":
+		rows, count, err = postgresProductSearch(db, query, perPage, offset)
+	case "mysql":
+		rows, count, err = mysqlProductSearch(db, query, perPage, offset)
+	default:
+		return nil, 0, errors.New("full-text search is not supported for " + db.Dialector.Name())
+	}
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return loadSearchResults(db, rows, count)
+}
+
+func postgresProductSearch(db *gorm.DB, query string, limit int, offset int) ([]productSearchRow, int64, error) {
+	configs := productSearchConfigs(db)
+
+	var tsQueries []string
+	var args []interface{}
+	for _, config := range configs {
+		tsQueries = append(tsQueries, fmt.Sprintf("websearch_to_tsquery('%s'::regconfig, ?)", config))
+		args = append(args, query)
+	}
+
+	searchJoin := "CROSS JOIN (SELECT " + strings.Join(tsQueries, " || ") + " AS query) AS search"
+	headlineOptions := fmt.Sprintf("StartSel=%s, StopSel=%s", highlightStart, highlightStop)
+
+	base := db.Model(&Product{}).
+		Joins(searchJoin, args...).
+		Where("products.search_vector @@ search.query")
+
+	var count int64
+	if err := base.Session(&gorm.Session{}).Count(&count).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var rows []productSearchRow
+	err := base.Session(&gorm.Session{}).
+		Select(fmt.Sprintf(
+			"products.id, ts_rank_cd(products.search_vector, search.query) AS score, "+
+				"ts_headline('%[1]s'::regconfig, products.name, search.query, '%[2]s, HighlightAll=TRUE') AS name_highlight, "+
+				"ts_headline('%[1]s'::regconfig, coalesce(nullif(products.short_description, ''), products.description, ''), search.query, '%[2]s, MaxWords=35, MinWords=15, MaxFragments=2') AS snippet_highlight",
+			configs[0], headlineOptions)).
+		Order("score DESC, products.created_at DESC").
+		Limit(limit).
+		Offset(offset).
+		Scan(&rows).Error
+
+	return rows, count, err
+}
+
+func mysqlProductSearch(db *gorm.DB, query string, limit int, offset int) ([]productSearchRow, int64, error) {
+	match := "MATCH(products.name, products.sku, products.short_description, products.description, products.search_categories) AGAINST (? IN NATURAL LANGUAGE MODE)"
+
+	base := db.Model(&Product{}).Where(match, query)
+
+	var count int64
+	if err := base.Session(&gorm.Session{}).Count(&count).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var products []Product
+	var scores []struct {
+		ID    string
+		Score float64
+	}
+	err := base.Session(&gorm.Session{}).
+		Select("products.id, "+match+" AS score", query).
+		Order("score DESC, products.created_at DESC").
+		Limit(limit).
+		Offset(offset).
+		Scan(&scores).Error
+	if err != nil {
+		return nil, 0, err
+	}
+
+	ids := make([]string, len(scores))
+	for i, score := range scores {
+		ids[i] = score.ID
+	}
+	if len(ids) > 0 {
+		if err := db.Select("id", "name", "short_description", "description").Where("id IN ?", ids).Find(&products).Error; err != nil {
+			return nil, 0, err
+		}
+	}
+
+	byID := make(map[string]Product, len(products))
+	for _, product := range products {
+		byID[product.ID] = product
+	}
+
+	// MySQL tidak punya ts_headline, highlight dibuat manual dari kata pencarian
+	pattern := highlightPattern(query)
+	rows := make([]productSearchRow, len(scores))
+	for i, score := range scores {
+		product := byID[score.ID]
+		snippet := product.ShortDescription
+		if snippet == "" {
+			snippet = product.Description
+		}
+
+		rows[i] = productSearchRow{
+			ID:               score.ID,
+			Score:            score.Score,
+			NameHighlight:    markMatches(product.Name, pattern),
+			SnippetHighlight: markMatches(snippetAround(snippet, pattern), pattern),
+		}
+	}
+
+	return rows, count, nil
+}
+
+// SearchResultsByIDs memuat hasil pencarian dari index in-memory sesuai urutan ids,
+// dengan highlight untuk terms yang cocok.
+func (p *Product) SearchResultsByIDs(db *gorm.DB, ids []string, terms []string) ([]ProductSearchResult, error) {
+	if len(ids) == 0 {
+		return nil, nil
+	}
+
+	var products []Product
+	if err := db.Select("id", "name", "short_description", "description").Where("id IN ?", ids).Find(&products).Error; err != nil {
+		return nil, err
+	}
+
+	byID := make(map[string]Product, len(products))
+	for _, product := range products {
+		byID[product.ID] = product
+	}
+
+	pattern := highlightPattern(strings.Join(terms, " "))
+	rows := make([]productSearchRow, 0, len(ids))
+	for _, id := range ids {
+		product, ok := byID[id]
+		if !ok {
+			continue
+		}
+
+		snippet := product.ShortDescription
+		if snippet == "" {
+			snippet = product.Description
+		}
+
+		rows = append(rows, productSearchRow{
+			ID:               id,
+			NameHighlight:    markMatches(product.Name, pattern),
+			SnippetHighlight: markMatches(snippetAround(snippet, pattern), pattern),
+		})
+	}
+
+	results, _, err := loadSearchResults(db, rows, int64(len(rows)))
+	return results, err
+}
+
+// loadSearchResults memuat produk lengkap (dengan gambar & kategori) dengan urutan sesuai skor.
+func loadSearchResults(db *gorm.DB, rows []productSearchRow, count int64) ([]ProductSearchResult, int64, error) {
+	if len(rows) == 0 {
+		return nil, count, nil
+	}
+
+	ids := make([]string, len(rows))
+	for i, row := range rows {
+		ids[i] = row.ID
+	}
+
+	var products []Product
+	err := db.Preload("ProductImages", OrderProductImages).
+		Preload("Categories").
+		Where("id IN ?", ids).
+		Find(&products).Error
+	if err != nil {
+		return nil, 0, err
+	}
+
+	byID := make(map[string]Product, len(products))
+	for _, product := range products {
+		byID[product.ID] = product
+	}
+
+	results := make([]ProductSearchResult, 0, len(rows))
+	for _, row := range rows {
+		product, ok := byID[row.ID]
+		if !ok {
+			continue
+		}
+
+		results = append(results, ProductSearchResult{
+			Product:          product,
+			Score:            row.Score,
+			NameHighlight:    renderHighlight(row.NameHighlight),
+			SnippetHighlight: renderHighlight(row.SnippetHighlight),
+		})
+	}
+
+	return results, count, nil
+}
+
+func renderHighlight(text string) template.HTML {
+	escaped := html.EscapeString(text)
+	escaped = strings.ReplaceAll(escaped, html.EscapeString(highlightStart), "<mark>")
+	escaped = strings.ReplaceAll(escaped, html.EscapeString(highlightStop), "</mark>")
+
+	return template.HTML(escaped)
+}
+
+func highlightPattern(query string) *regexp.Regexp {
+	var words []string
+	for _, word := range strings.Fields(query) {
+		word = strings.Trim(word, `"+-*()~<>`)
+		if utf8.RuneCountInString(word) >= 2 {
+			words = append(words, regexp.QuoteMeta(word))
+		}
+	}
+
+	if len(words) == 0 {
+		return nil
+	}
+
+	return regexp.MustCompile(`(?i)(` + strings.Join(words, "|") + `)`)
+}
+
+func markMatches(text string, pattern *regexp.Regexp) string {
+	if pattern == nil {
+		return text
+	}
+
+	return pattern.ReplaceAllString(text, highlightStart+"$1"+highlightStop)
+}
+
+// snippetAround memotong teks panjang di sekitar kata pertama yang cocok.
+func snippetAround(text string, pattern *regexp.Regexp) string {
+	runes := []rune(text)
+	if len(runes) <= snippetLength {
+		return text
+	}
+
+	start := 0
+	if pattern != nil {
+		if loc := pattern.FindStringIndex(text); loc != nil {
+			start = utf8.RuneCountInString(text[:loc[0]]) - snippetLength/4
+		}
+	}
+	if start < 0 {
+		start = 0
+	}
+
+	end := start + snippetLength
+	if end > len(runes) {
+		end = len(runes)
+		start = end - snippetLength
+	}
+
+	snippet := string(runes[start:end])
+	if start > 0 {
+		snippet = "…" + snippet
+	}
+	if end < len(runes) {
+		snippet += "…"
+	}
+
+	return snippet
+}
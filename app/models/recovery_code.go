@@ -0,0 +1,61 @@
+package models
+
+import (
+	"time"
+
+	"github.com/gieart87/gotoko/app/core/twofactor"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// RecoveryCode adalah kode cadangan 2FA sekali pakai, disimpan dalam bentuk hash.
+type RecoveryCode struct {
+	ID        string `gorm:"size:36;not null;uniqueIndex;primary_key"`
+	UserID    string `gorm:"size:36;index"`
+	CodeHash  string `gorm:"size:64;not null"`
+	UsedAt    *time.Time
+	CreatedAt time.Time
+}
+
+func (c *RecoveryCode) BeforeCreate(db *gorm.DB) error {
+	if c.ID == "" {
+		c.ID = uuid.New().String()
+	}
+
+	return nil
+}
+
+// ReplaceRecoveryCodes menghapus kode cadangan lama user dan menyimpan kode baru.
+func (c *RecoveryCode) ReplaceRecoveryCodes(db *gorm.DB, userID string, codes []string) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("user_id = ?", userID).Delete(&RecoveryCode{}).Error; err != nil {
+			return err
+		}
+
+		recoveryCodes := make([]RecoveryCode, len(codes))
+		for i, code := range codes {
+			recoveryCodes[i] = RecoveryCode{UserID: userID, CodeHash: twofactor.HashRecoveryCode(code)}
+		}
+
+		return tx.Create(&recoveryCodes).Error
+	})
+}
+
+// UseRecoveryCode menandai kode cadangan sudah dipakai; false jika kode salah atau sudah dipakai.
+func (c *RecoveryCode) UseRecoveryCode(db *gorm.DB, userID string, code string) (bool, error) {
+	result := db.Model(&RecoveryCode{}).
+		Where("user_id = ? AND code_hash = ? AND used_at IS NULL", userID, twofactor.HashRecoveryCode(code)).
+		Update("used_at", time.Now())
+	if result.Error != nil {
+		return false, result.Error
+	}
+
+	return result.RowsAffected > 0, nil
+}
+
+func (c *RecoveryCode) CountUnusedRecoveryCodes(db *gorm.DB, userID string) (int64, error) {
+	var count int64
+	err := db.Model(&RecoveryCode{}).Where("user_id = ? AND used_at IS NULL", userID).Count(&count).Error
+
+	return count, err
+}
@@ -13,6 +13,7 @@ func RegisterModels() []Model {
 		{Model: Section{}},
 		{Model: Category{}},
 		{Model: Order{}},
+		{Model: OrderStatusHistory{}},
 		{Model: OrderItem{}},
 		{Model: OrderCustomer{}},
 		{Model: Payment{}},
@@ -20,6 +21,15 @@ func RegisterModels() []Model {
 		{Model: Cart{}},
 		{Model: CartItem{}},
 		{Model: Province{}},
+		{Model: Permission{}},
 		{Model: Role{}},
+		{Model: Synonym{}},
+		{Model: AIOutboxEvent{}},
+		{Model: SearchQuery{}},
+		{Model: UserToken{}},
+		{Model: LoginAttempt{}},
+		{Model: RecoveryCode{}},
+		{Model: UserIdentity{}},
+		{Model: PasswordResetRequest{}},
 	}
 }
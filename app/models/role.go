@@ -2,15 +2,69 @@ package models
 
 import (
 	"time"
-	"gorm.io/gorm"
 
+	"github.com/google/uuid"
+	"gorm.io/gorm"
 )
 
 type Role struct {
-	ID          string `gorm:"size:36;not null;uniqueIndex;primary_key"`
-	Name        string `gorm:"size:100;not null;index"`
-	Description string `gorm:"size:255"`
+	ID          string       `gorm:"size:36;not null;uniqueIndex;primary_key"`
+	Name        string       `gorm:"size:100;not null;index"`
+	Description string       `gorm:"size:255"`
+	Permissions []Permission `gorm:"many2many:role_permissions;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;"`
 	CreatedAt   time.Time
-	UpdatedAt 	time.Time
-	DeletedAt 	gorm.DeletedAt
-}
\ No newline at end of file
+	UpdatedAt   time.Time
+	DeletedAt   gorm.DeletedAt
+}
+
+func (r *Role) BeforeCreate(db *gorm.DB) error {
+	if r.ID == "" {
+		r.ID = uuid.New().String()
+	}
+
+	return nil
+}
+
+// HasPermission membutuhkan Permissions sudah di-preload.
+func (r *Role) HasPermission(name string) bool {
+	for _, permission := range r.Permissions {
+		if permission.Name == name {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (r *Role) GetRoles(db *gorm.DB) ([]Role, error) {
+	var roles []Role
+
+	err := db.Debug().Preload("Permissions").Order("name asc").Find(&roles).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return roles, nil
+}
+
+func (r *Role) FindByID(db *gorm.DB, roleID string) (*Role, error) {
+	var role Role
+
+	err := db.Debug().Preload("Permissions").Where("id = ?", roleID).First(&role).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return &role, nil
+}
+
+func (r *Role) FindByName(db *gorm.DB, name string) (*Role, error) {
+	var role Role
+
+	err := db.Debug().Preload("Permissions").Where("name = ?", name).First(&role).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return &role, nil
+}
@@ -0,0 +1,319 @@
+package models
+
+import (
+	"time"
+
+	"github.com/gieart87/gotoko/app/consts"
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+)
+
+// Pengelompokan pendapatan pada grafik penjualan.
+const (
+	SalesPeriodDay   = "day"
+	SalesPeriodWeek  = "week"
+	SalesPeriodMonth = "month"
+)
+
+// SalesRange adalah rentang tanggal laporan, From inklusif dan To eksklusif.
+type SalesRange struct {
+	From time.Time
+	To   time.Time
+}
+
+type SalesSummary struct {
+	Orders            int64
+	PaidOrders        int64
+	UnpaidOrders      int64
+	CancelledOrders   int64
+	Revenue           decimal.Decimal
+	AverageOrderValue decimal.Decimal
+}
+
+type RevenuePoint struct {
+	Period  string
+	Orders  int64
+	Revenue decimal.Decimal
+}
+
+type ProductSales struct {
+	ProductID string
+	Name      string
+	Qty       int64
+	Revenue   decimal.Decimal
+}
+
+type CategorySales struct {
+	CategoryID string
+	Name       string
+	Qty        int64
+	Revenue    decimal.Decimal
+}
+
+type CustomerSplit struct {
+	NewCustomers       int64
+	ReturningCustomers int64
+}
+
+// SalesReport menghitung metrik penjualan langsung dengan agregat SQL. Pendapatan hanya
+// menghitung order yang sudah dibayar dan tidak dibatalkan.
+type SalesReport struct{}
+
+// paidOrders adalah order yang dihitung sebagai pendapatan dalam rentang laporan (tanggal order).
+func paidOrders(db *gorm.DB, r SalesRange) *gorm.DB {
+	return db.Model(&Order{}).
+		Where("orders.created_at >= ? AND orders.created_at < ?", r.From, r.To).
+		Where("orders.payment_status = ? AND orders.status <> ?", consts.OrderPaymentStatusPaid, consts.OrderStatusCancelled)
+}
+
+// settledOrders sama dengan paidOrders tetapi berdasarkan tanggal pembayaran (paid_at).
+// Dipakai laporan keuangan agar sejalan dengan PaymentsByType.
+func settledOrders(db *gorm.DB, r SalesRange) *gorm.DB {
+	return db.Model(&Order{}).
+		Where("orders.paid_at >= ? AND orders.paid_at < ?", r.From, r.To).
+		Where("orders.payment_status = ? AND orders.status <> ?", consts.OrderPaymentStatusPaid, consts.OrderStatusCancelled)
+}
+
+func (s *SalesReport) Summary(db *gorm.DB, r SalesRange) (*SalesSummary, error) {
+	var row struct {
+		Orders          int64
+		PaidOrders      int64
+		CancelledOrders int64
+		Revenue         decimal.Decimal
+	}
+
+	err := db.Debug().Model(&Order{}).
+		Select("COUNT(*) AS orders, "+
+			"COALESCE(SUM(CASE WHEN payment_status = ? AND status <> ? THEN 1 ELSE 0 END), 0) AS paid_orders, "+
+			"COALESCE(SUM(CASE WHEN status = ? THEN 1 ELSE 0 END), 0) AS cancelled_orders, "+
+			"COALESCE(SUM(CASE WHEN payment_status = ? AND status <> ? THEN grand_total ELSE 0 END), 0) AS revenue",
+			consts.OrderPaymentStatusPaid, consts.OrderStatusCancelled,
+			consts.OrderStatusCancelled,
+			consts.OrderPaymentStatusPaid, consts.OrderStatusCancelled).
+		Where("created_at >= ? AND created_at < ?", r.From, r.To).
+		Scan(&row).Error
+	if err != nil {
+		return nil, err
+	}
+
+	summary := &SalesSummary{
+		Orders:          row.Orders,
+		PaidOrders:      row.PaidOrders,
+		CancelledOrders: row.CancelledOrders,
+		UnpaidOrders:    row.Orders - row.PaidOrders - row.CancelledOrders,
+		Revenue:         row.Revenue,
+	}
+	if row.PaidOrders > 0 {
+		summary.AverageOrderValue = row.Revenue.Div(decimal.NewFromInt(row.PaidOrders)).Round(2)
+	}
+
+	return summary, nil
+}
+
+// Revenue mengelompokkan pendapatan per hari, minggu (mulai Senin) atau bulan.
+func (s *SalesReport) Revenue(db *gorm.DB, r SalesRange, period string) ([]RevenuePoint, error) {
+	var points []RevenuePoint
+
+	bucket := salesPeriodExpr(db, "orders.created_at", period)
+	err := paidOrders(db.Debug(), r).
+		Select(bucket + " AS period, COUNT(*) AS orders, COALESCE(SUM(grand_total), 0) AS revenue").
+		Group(bucket).
+		Order(bucket + " asc").
+		Scan(&points).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return points, nil
+}
+
+// salesPeriodExpr mengelompokkan column (kolom waktu, bukan input user) per periode.
+func salesPeriodExpr(db *gorm.DB, column string, period string) string {
+	if db.Dialector.Name() == "mysql" {
+		switch period {
+		case SalesPeriodWeek:
+			return "DATE_FORMAT(DATE_SUB(" + column + ", INTERVAL WEEKDAY(" + column + ") DAY), '%Y-%m-%d')"
+		case SalesPeriodMonth:
+			return "DATE_FORMAT(" + column + ", '%Y-%m')"
+		default:
+			return "DATE_FORMAT(" + column + ", '%Y-%m-%d')"
+		}
+	}
+
+	switch period {
+	case SalesPeriodWeek:
+		return "TO_CHAR(DATE_TRUNC('week', " + column + "), 'YYYY-MM-DD')"
+	case SalesPeriodMonth:
+		return "TO_CHAR(" + column + ", 'YYYY-MM')"
+	default:
+		return "TO_CHAR(" + column + ", 'YYYY-MM-DD')"
+	}
+}
+
+func (s *SalesReport) TopProducts(db *gorm.DB, r SalesRange, limit int) ([]ProductSales, error) {
+	var products []ProductSales
+
+	err := db.Debug().Table("order_items").
+		Select("order_items.product_id AS product_id, MAX(order_items.name) AS name, "+
+			"SUM(order_items.qty) AS qty, COALESCE(SUM(order_items.sub_total), 0) AS revenue").
+		Where("order_items.order_id IN (?)", paidOrders(db, r).Select("orders.id")).
+		Group("order_items.product_id").
+		Order("qty desc, revenue desc").
+		Limit(limit).
+		Scan(&products).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return products, nil
+}
+
+// TopCategories menjumlahkan penjualan per kategori langsung produk. Produk dengan beberapa
+// kategori dihitung di setiap kategorinya, jadi totalnya bisa melebihi total penjualan.
+func (s *SalesReport) TopCategories(db *gorm.DB, r SalesRange, limit int) ([]CategorySales, error) {
+	var categories []CategorySales
+
+	err := db.Debug().Table("order_items").
+		Select("categories.id AS category_id, categories.name AS name, "+
+			"SUM(order_items.qty) AS qty, COALESCE(SUM(order_items.sub_total), 0) AS revenue").
+		Joins("JOIN product_categories ON product_categories.product_id = order_items.product_id").
+		Joins("JOIN categories ON categories.id = product_categories.category_id").
+		Where("order_items.order_id IN (?)", paidOrders(db, r).Select("orders.id")).
+		Group("categories.id, categories.name").
+		Order("qty desc, revenue desc").
+		Limit(limit).
+		Scan(&categories).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return categories, nil
+}
+
+// LowStockProducts mengembalikan produk dengan stok di bawah atau sama dengan threshold.
+func (s *SalesReport) LowStockProducts(db *gorm.DB, threshold int, limit int) ([]Product, error) {
+	var products []Product
+
+	err := db.Debug().Where("stock <= ?", threshold).
+		Order("stock asc, name asc").
+		Limit(limit).
+		Find(&products).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return products, nil
+}
+
+// Customers membagi pelanggan yang membayar order dalam rentang menjadi pelanggan baru
+// (order lunas pertamanya di dalam rentang) dan pelanggan lama.
+func (s *SalesReport) Customers(db *gorm.DB, r SalesRange) (*CustomerSplit, error) {
+	split := &CustomerSplit{}
+
+	firstOrders := db.Model(&Order{}).
+		Select("user_id, MIN(created_at) AS first_order_at").
+		Where("payment_status = ? AND status <> ?", consts.OrderPaymentStatusPaid, consts.OrderStatusCancelled).
+		Group("user_id")
+
+	err := db.Debug().Table("(?) AS first_orders", firstOrders).
+		Select("COALESCE(SUM(CASE WHEN first_order_at >= ? THEN 1 ELSE 0 END), 0) AS new_customers, "+
+			"COALESCE(SUM(CASE WHEN first_order_at < ? THEN 1 ELSE 0 END), 0) AS returning_customers", r.From, r.From).
+		Where("user_id IN (?)", paidOrders(db, r).Select("orders.user_id")).
+		Scan(split).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return split, nil
+}
+
+type DailySales struct {
+	Date           string
+	Orders         int64
+	BaseTotal      decimal.Decimal
+	DiscountAmount decimal.Decimal
+	TaxAmount      decimal.Decimal
+	ShippingCost   decimal.Decimal
+	GrandTotal     decimal.Decimal
+}
+
+type PaymentTypeSales struct {
+	PaymentType string
+	Payments    int64
+	Amount      decimal.Decimal
+}
+
+// DailySales merinci order lunas per hari pembayaran untuk laporan keuangan.
+func (s *SalesReport) DailySales(db *gorm.DB, r SalesRange) ([]DailySales, error) {
+	var days []DailySales
+
+	bucket := salesPeriodExpr(db, "orders.paid_at", SalesPeriodDay)
+	err := settledOrders(db.Debug(), r).
+		Select(bucket + " AS date, COUNT(*) AS orders, " +
+			"COALESCE(SUM(base_total_price), 0) AS base_total, " +
+			"COALESCE(SUM(discount_amount), 0) AS discount_amount, " +
+			"COALESCE(SUM(tax_amount), 0) AS tax_amount, " +
+			"COALESCE(SUM(shipping_cost), 0) AS shipping_cost, " +
+			"COALESCE(SUM(grand_total), 0) AS grand_total").
+		Group(bucket).
+		Order(bucket + " asc").
+		Scan(&days).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return days, nil
+}
+
+// TaxedOrders mengembalikan order yang dibayar dalam rentang beserta pajaknya, urut tanggal pembayaran.
+func (s *SalesReport) TaxedOrders(db *gorm.DB, r SalesRange) ([]Order, error) {
+	var orders []Order
+
+	err := settledOrders(db.Debug(), r).Order("orders.paid_at asc").Find(&orders).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return orders, nil
+}
+
+// PaymentsByType menjumlahkan pembayaran per metode pembayaran untuk order yang sama dengan
+// DailySales/TaxedOrders (lunas pada rentang paid_at dan tidak dibatalkan). Setiap order dihitung
+// satu kali walaupun Midtrans mengirim beberapa notifikasi sukses (mis. capture lalu settlement);
+// metode diambil dari pembayaran sukses pertama.
+func (s *SalesReport) PaymentsByType(db *gorm.DB, r SalesRange) ([]PaymentTypeSales, error) {
+	var payments []PaymentTypeSales
+
+	paymentType := db.Model(&Payment{}).
+		Select("payments.payment_type").
+		Where("payments.order_id = orders.id AND payments.transaction_status IN ?",
+			[]string{consts.PaymentStatusCapture, consts.PaymentStatusSettlement}).
+		Order("payments.created_at asc").
+		Limit(1)
+
+	paidOrders := settledOrders(db, r).
+		Select("COALESCE((?), '') AS payment_type, orders.grand_total AS amount", paymentType)
+
+	err := db.Debug().Table("(?) AS paid_orders", paidOrders).
+		Select("payment_type, COUNT(*) AS payments, COALESCE(SUM(amount), 0) AS amount").
+		Group("payment_type").
+		Order("amount desc").
+		Scan(&payments).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return payments, nil
+}
+
+// StockValuation mengembalikan seluruh produk yang masih punya stok; nilai = stok x harga jual saat ini.
+func (s *SalesReport) StockValuation(db *gorm.DB) ([]Product, error) {
+	var products []Product
+
+	err := db.Debug().Where("stock > 0").Order("name asc").Find(&products).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return products, nil
+}
@@ -0,0 +1,82 @@
+package models
+
+import (
+	"database/sql"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/gieart87/gotoko/app/consts"
+	"github.com/shopspring/decimal"
+)
+
+func TestPaymentsByTypeCountsOnePaymentPerSettledOrder(t *testing.T) {
+	db := newTestDB(t, &Order{}, &Payment{})
+
+	now := time.Now()
+	paidAt := sql.NullTime{Time: now, Valid: true}
+	orders := []Order{
+		{ID: "card", Code: "card", PaymentStatus: consts.OrderPaymentStatusPaid, Status: consts.OrderStatusReceived, PaidAt: paidAt, GrandTotal: decimal.NewFromInt(100000)},
+		{ID: "transfer", Code: "transfer", PaymentStatus: consts.OrderPaymentStatusPaid, Status: consts.OrderStatusDelivered, PaidAt: paidAt, GrandTotal: decimal.NewFromInt(50000)},
+		{ID: "cancelled", Code: "cancelled", PaymentStatus: consts.OrderPaymentStatusPaid, Status: consts.OrderStatusCancelled, PaidAt: paidAt, GrandTotal: decimal.NewFromInt(70000)},
+		{ID: "old", Code: "old", PaymentStatus: consts.OrderPaymentStatusPaid, Status: consts.OrderStatusReceived, PaidAt: sql.NullTime{Time: now.AddDate(0, -2, 0), Valid: true}, GrandTotal: decimal.NewFromInt(90000)},
+	}
+	if err := db.Create(&orders).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	payload := json.RawMessage("{}")
+	payment := func(id string, orderID string, paymentType string, status string, amount int64) Payment {
+		return Payment{ID: id, OrderID: orderID, PaymentType: paymentType, TransactionStatus: status, Amount: decimal.NewFromInt(amount), PayLoad: &payload}
+	}
+	payments := []Payment{
+		// Kartu kredit: challenge (capture) lalu settlement untuk order yang sama
+		payment("p1", "card", "credit_card", consts.PaymentStatusCapture, 100000),
+		payment("p2", "card", "credit_card", consts.PaymentStatusSettlement, 100000),
+		payment("p3", "transfer", "bank_transfer", "pending", 50000),
+		payment("p4", "transfer", "bank_transfer", consts.PaymentStatusSettlement, 50000),
+		payment("p5", "cancelled", "gopay", consts.PaymentStatusSettlement, 70000),
+		payment("p6", "old", "gopay", consts.PaymentStatusSettlement, 90000),
+	}
+	if err := db.Create(&payments).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	reportModel := SalesReport{}
+	salesRange := SalesRange{From: now.AddDate(0, 0, -1), To: now.AddDate(0, 0, 1)}
+
+	got, err := reportModel.PaymentsByType(db, salesRange)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := map[string]PaymentTypeSales{
+		"credit_card":   {Payments: 1, Amount: decimal.NewFromInt(100000)},
+		"bank_transfer": {Payments: 1, Amount: decimal.NewFromInt(50000)},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("PaymentsByType() = %+v, want %d payment types", got, len(want))
+	}
+
+	total := decimal.Zero
+	for _, row := range got {
+		expected, ok := want[row.PaymentType]
+		if !ok || row.Payments != expected.Payments || !row.Amount.Equal(expected.Amount) {
+			t.Errorf("PaymentsByType() row = %+v, want %+v", row, expected)
+		}
+		total = total.Add(row.Amount)
+	}
+
+	// Total per metode pembayaran harus sama dengan total order di laporan pajak
+	taxed, err := reportModel.TaxedOrders(db, salesRange)
+	if err != nil {
+		t.Fatal(err)
+	}
+	taxedTotal := decimal.Zero
+	for _, order := range taxed {
+		taxedTotal = taxedTotal.Add(order.GrandTotal)
+	}
+	if !total.Equal(taxedTotal) {
+		t.Errorf("payments total = %s, taxed orders total = %s", total, taxedTotal)
+	}
+}
@@ -0,0 +1,130 @@
+package models
+
+import (
+	"strings"
+	"time"
+
+	"github.com/gieart87/gotoko/app/utils"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Sumber hasil pencarian yang dicatat di SearchQuery.Source.
+const (
+	SearchSourceFullText = "fulltext"
+	SearchSourceIndex    = "index"
+	SearchSourceAI       = "ai"
+	SearchSourceStandard = "standard"
+)
+
+// SearchQuery mencatat satu pencarian pelanggan beserta klik dan add-to-cart setelahnya.
+type SearchQuery struct {
+	ID               string `gorm:"size:36;not null;uniqueIndex;primary_key"`
+	Query            string `gorm:"size:255;index"` // sudah dinormalisasi (huruf kecil, spasi tunggal)
+	RawQuery         string `gorm:"size:255"`
+	ResultCount      int64
+	Source           string `gorm:"size:20;index"`
+	UserID           string `gorm:"size:36;index"`
+	ClickedProductID string `gorm:"size:36;index"`
+	ClickedAt        *time.Time
+	AddedToCart      bool `gorm:"default:false"`
+	AddedToCartAt    *time.Time
+	CreatedAt        time.Time `gorm:"index"`
+}
+
+type SearchQueryStat struct {
+	Query          string
+	Searches       int64
+	AvgResults     float64
+	Clicks         int64
+	AddToCarts     int64
+	ClickRate      float64
+	ConversionRate float64
+	LastSearchedAt time.Time
+}
+
+func (s *SearchQuery) BeforeCreate(db *gorm.DB) error {
+	if s.ID == "" {
+		s.ID = uuid.New().String()
+	}
+
+	return nil
+}
+
+func NormalizeSearchQuery(query string) string {
+	return strings.Join(strings.Fields(strings.ToLower(query)), " ")
+}
+
+func (s *SearchQuery) LogSearch(db *gorm.DB, rawQuery string, resultCount int64, source string, userID string) (*SearchQuery, error) {
+	rawQuery = utils.Truncate(rawQuery, 255)
+
+	searchQuery := &SearchQuery{
+		Query:       NormalizeSearchQuery(rawQuery),
+		RawQuery:    rawQuery,
+		ResultCount: resultCount,
+		Source:      source,
+		UserID:      userID,
+	}
+
+	if err := db.Create(searchQuery).Error; err != nil {
+		return nil, err
+	}
+
+	return searchQuery, nil
+}
+
+// RecordClick mencatat produk pertama yang dibuka dari hasil pencarian.
+func (s *SearchQuery) RecordClick(db *gorm.DB, searchQueryID string, productID string) error {
+	return db.Model(&SearchQuery{}).
+		Where("id = ? AND clicked_at IS NULL", searchQueryID).
+		Updates(map[string]interface{}{
+			"clicked_product_id": productID,
+			"clicked_at":         time.Now(),
+		}).Error
+}
+
+func (s *SearchQuery) RecordAddToCart(db *gorm.DB, searchQueryID string) error {
+	return db.Model(&SearchQuery{}).
+		Where("id = ? AND added_to_cart = ?", searchQueryID, false).
+		Updates(map[string]interface{}{
+			"added_to_cart":    true,
+			"added_to_cart_at": time.Now(),
+		}).Error
+}
+
+// TopSearchQueries mengembalikan query terbanyak sejak waktu tertentu beserta konversinya.
+func (s *SearchQuery) TopSearchQueries(db *gorm.DB, since time.Time, limit int) ([]SearchQueryStat, error) {
+	return searchQueryStats(db.Where("created_at >= ?", since), limit)
+}
+
+// ZeroResultQueries mengembalikan query yang tidak menemukan produk apa pun,
+// bahan untuk menambah produk atau sinonim.
+func (s *SearchQuery) ZeroResultQueries(db *gorm.DB, since time.Time, limit int) ([]SearchQueryStat, error) {
+	return searchQueryStats(db.Where("created_at >= ? AND result_count = 0", since), limit)
+}
+
+func searchQueryStats(query *gorm.DB, limit int) ([]SearchQueryStat, error) {
+	var stats []SearchQueryStat
+
+	err := query.Model(&SearchQuery{}).
+		Select("query, COUNT(*) AS searches, AVG(result_count) AS avg_results, " +
+			"COUNT(clicked_at) AS clicks, " +
+			"SUM(CASE WHEN added_to_cart THEN 1 ELSE 0 END) AS add_to_carts, " +
+			"MAX(created_at) AS last_searched_at").
+		Group("query").
+		Order("searches desc, last_searched_at desc").
+		Limit(limit).
+		Scan(&stats).Error
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range stats {
+		if stats[i].Searches > 0 {
+			stats[i].ClickRate = float64(stats[i].Clicks) / float64(stats[i].Searches) * 100
+			stats[i].ConversionRate = float64(stats[i].AddToCarts) / float64(stats[i].Searches) * 100
+		}
+	}
+
+	return stats, nil
+}
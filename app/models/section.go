@@ -1,6 +1,12 @@
 package models
 
-import "time"
+import (
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
 
 type Section struct {
 	ID         string `gorm:"size:36;not null;uniqueIndex;primary_key"`
@@ -10,3 +16,88 @@ type Section struct {
 	UpdatedAt  time.Time
 	Categories []Category
 }
+
+func (s *Section) BeforeCreate(db *gorm.DB) error {
+	if s.ID == "" {
+		s.ID = uuid.New().String()
+	}
+
+	return nil
+}
+
+func (s *Section) GetSections(db *gorm.DB) ([]Section, error) {
+	var sections []Section
+
+	err := db.Debug().Preload("Categories").Order("name asc").Find(&sections).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return sections, nil
+}
+
+func (s *Section) FindByID(db *gorm.DB, sectionID string) (*Section, error) {
+	var section Section
+
+	err := db.Debug().Where("id = ?", sectionID).First(&section).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return &section, nil
+}
+
+func (s *Section) CreateSection(db *gorm.DB, name string) (*Section, error) {
+	sectionSlug, err := uniqueSlug(db, &Section{}, name, "")
+	if err != nil {
+		return nil, err
+	}
+
+	section := &Section{
+		Name: name,
+		Slug: sectionSlug,
+	}
+
+	if err := db.Debug().Create(section).Error; err != nil {
+		return nil, err
+	}
+
+	return section, nil
+}
+
+func (s *Section) UpdateSection(db *gorm.DB, name string) (*Section, error) {
+	if s.Name != name {
+		sectionSlug, err := uniqueSlug(db, &Section{}, name, s.ID)
+		if err != nil {
+			return nil, err
+		}
+		s.Slug = sectionSlug
+	}
+	s.Name = name
+
+	err := db.Debug().Model(&Section{}).Where("id = ?", s.ID).Updates(map[string]interface{}{
+		"name":       s.Name,
+		"slug":       s.Slug,
+		"updated_at": time.Now(),
+	}).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return s, nil
+}
+
+// DeleteSection hanya menghapus section yang sudah tidak memiliki kategori,
+// karena categories.section_id memiliki foreign key ke sections.
+func (s *Section) DeleteSection(db *gorm.DB) error {
+	var count int64
+	if err := db.Model(&Category{}).Where("section_id = ?", s.ID).Count(&count).Error; err != nil {
+		return err
+	}
+
+	if count > 0 {
+		return errors.New("section masih memiliki kategori")
+	}
+
+	return db.Debug().Delete(s).Error
+}
@@ -32,3 +32,14 @@ type Shipment struct {
 	UpdatedAt   time.Time
 	DeletedAt   gorm.DeletedAt
 }
+
+func (s *Shipment) GetOrderShipments(db *gorm.DB, orderID string) ([]Shipment, error) {
+	var shipments []Shipment
+
+	err := db.Debug().Where("order_id = ?", orderID).Order("created_at desc").Find(&shipments).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return shipments, nil
+}
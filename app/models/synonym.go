@@ -0,0 +1,100 @@
+package models
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Synonym menyimpan satu grup istilah yang dianggap sama saat pencarian,
+// misalnya nama merek dan nama generik: Term "paracetamol", Terms "panadol, sanmol".
+type Synonym struct {
+	ID        string `gorm:"size:36;not null;uniqueIndex;primary_key"`
+	Term      string `gorm:"size:100;uniqueIndex"`
+	Terms     string `gorm:"type:text"`
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+func (s *Synonym) BeforeCreate(db *gorm.DB) error {
+	if s.ID == "" {
+		s.ID = uuid.New().String()
+	}
+
+	return nil
+}
+
+// Group mengembalikan Term beserta semua sinonimnya.
+func (s *Synonym) Group() []string {
+	group := []string{s.Term}
+	for _, term := range strings.Split(s.Terms, ",") {
+		if term = strings.TrimSpace(term); term != "" {
+			group = append(group, term)
+		}
+	}
+
+	return group
+}
+
+func (s *Synonym) GetSynonyms(db *gorm.DB) ([]Synonym, error) {
+	var synonyms []Synonym
+
+	err := db.Debug().Order("term asc").Find(&synonyms).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return synonyms, nil
+}
+
+func (s *Synonym) FindByID(db *gorm.DB, synonymID string) (*Synonym, error) {
+	var synonym Synonym
+
+	err := db.Debug().Where("id = ?", synonymID).First(&synonym).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return &synonym, nil
+}
+
+// SaveSynonym membuat grup sinonim baru, atau memperbarui grup dengan Term yang sama.
+func (s *Synonym) SaveSynonym(db *gorm.DB, term string, terms string) (*Synonym, error) {
+	term = strings.ToLower(strings.TrimSpace(term))
+	if term == "" {
+		return nil, errors.New("istilah tidak boleh kosong")
+	}
+
+	var normalized []string
+	for _, t := range strings.Split(terms, ",") {
+		t = strings.ToLower(strings.TrimSpace(t))
+		if t != "" && t != term {
+			normalized = append(normalized, t)
+		}
+	}
+	if len(normalized) == 0 {
+		return nil, errors.New("minimal satu sinonim harus diisi")
+	}
+
+	var synonym Synonym
+	err := db.Where("term = ?", term).First(&synonym).Error
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+
+	synonym.Term = term
+	synonym.Terms = strings.Join(normalized, ", ")
+
+	if err := db.Debug().Save(&synonym).Error; err != nil {
+		return nil, err
+	}
+
+	return &synonym, nil
+}
+
+func (s *Synonym) DeleteSynonym(db *gorm.DB) error {
+	return db.Debug().Delete(s).Error
+}
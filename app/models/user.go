@@ -4,6 +4,8 @@ import (
 	"time"
 	"strings"
 
+	"github.com/gieart87/gotoko/app/consts"
+	"github.com/google/uuid"
 	"gorm.io/gorm"
 )
 
@@ -17,6 +19,15 @@ type User struct {
 	Email         string `gorm:"size:100;not null;uniqueIndex"`
 	Password      string `gorm:"size:255;not null"`
 	RememberToken string `gorm:"size:255;not null"`
+	SuspendedAt   *time.Time `gorm:"index"`
+	MustResetPassword bool `gorm:"default:false"`
+	EmailVerifiedAt *time.Time
+	FailedLoginCount  int `gorm:"default:0"`
+	LastFailedLoginAt *time.Time
+	LockedUntil       *time.Time `gorm:"index"`
+	TOTPSecret        string     `gorm:"size:64"`
+	TOTPEnabledAt     *time.Time
+	TOTPLastStep      int64 `gorm:"default:0"`
 	CreatedAt     time.Time
 	UpdatedAt     time.Time
 	DeletedAt     gorm.DeletedAt
@@ -25,7 +36,7 @@ type User struct {
 func (u *User) FindByEmail(db *gorm.DB, email string) (*User, error) {
 	var err error
 	var user User
-	err = db.Debug().Preload("Role").Model(User{}).Where("LOWER(email) = ?", strings.ToLower(email)).
+	err = db.Debug().Preload("Role.Permissions").Model(User{}).Where("LOWER(email) = ?", strings.ToLower(email)).
 		First(&user).
 		Error
 	if err != nil {
@@ -38,7 +49,7 @@ func (u *User) FindByEmail(db *gorm.DB, email string) (*User, error) {
 // ✅ BENAR
 func (u *User) FindByID(db *gorm.DB, userID string) (*User, error) {
 	var user User
-	err := db.Debug().Preload("Role").Model(User{}).Where("id = ?", userID).First(&user).Error
+	err := db.Debug().Preload("Role.Permissions").Model(User{}).Where("id = ?", userID).First(&user).Error
 	if err != nil {
 		return nil, err
 	}
@@ -48,6 +59,8 @@ func (u *User) FindByID(db *gorm.DB, userID string) (*User, error) {
 func (u *User) CreateUser(db *gorm.DB, param *User) (*User, error) {
 	user := &User{
 		ID: param.ID,
+		RoleID: param.RoleID,
+		EmailVerifiedAt: param.EmailVerifiedAt,
 		FirstName: param.FirstName,
 		LastName: param.LastName, 
 		Email: param.Email,
@@ -60,4 +73,376 @@ func (u *User) CreateUser(db *gorm.DB, param *User) (*User, error) {
 	}
 
 	return user, nil
-}
\ No newline at end of file
+}
+
+// Can memeriksa apakah role user memiliki permission tertentu.
+// User harus dimuat lewat FindByID/FindByEmail agar Role.Permissions ikut ter-preload.
+func (u *User) Can(permission string) bool {
+	return u.RoleID != "" && u.Role.HasPermission(permission)
+}
+
+// AssignRole mengganti role user.
+func (u *User) AssignRole(db *gorm.DB, roleID string) error {
+	err := db.Debug().Model(&User{}).Where("id = ?", u.ID).Updates(map[string]interface{}{
+		"role_id":    roleID,
+		"updated_at": time.Now(),
+	}).Error
+	if err != nil {
+		return err
+	}
+
+	u.RoleID = roleID
+	return nil
+}
+
+// IsSuspended bernilai true jika akun dinonaktifkan admin; user tersebut tidak bisa login.
+func (u *User) IsSuspended() bool {
+	return u.SuspendedAt != nil
+}
+
+// Jeda login bertahap: mulai kegagalan ke-3 user harus menunggu 1, 2, 4, ... detik
+// (maksimal 1 menit) sebelum boleh mencoba lagi.
+const (
+	loginDelayAfter = 3
+	maxLoginDelay   = time.Minute
+)
+
+func (u *User) IsLocked() bool {
+	return u.LockedUntil != nil && time.Now().Before(*u.LockedUntil)
+}
+
+// LoginRetryAfter mengembalikan berapa lama lagi user boleh mencoba login (0 = boleh sekarang).
+func (u *User) LoginRetryAfter(now time.Time) time.Duration {
+	if u.LockedUntil != nil && now.Before(*u.LockedUntil) {
+		return u.LockedUntil.Sub(now)
+	}
+
+	if u.FailedLoginCount < loginDelayAfter || u.LastFailedLoginAt == nil {
+		return 0
+	}
+
+	delay := maxLoginDelay
+	if shift := u.FailedLoginCount - loginDelayAfter; shift < 6 {
+		delay = time.Second << uint(shift)
+	}
+
+	if wait := u.LastFailedLoginAt.Add(delay).Sub(now); wait > 0 {
+		return wait
+	}
+
+	return 0
+}
+
+// RegisterFailedLogin menambah jumlah login gagal dan mengunci akun selama lockout jika
+// sudah mencapai maxFailures. Hitungan baru di-reset setelah login berhasil atau dibuka admin,
+// sehingga kegagalan setelah masa kunci habis langsung mengunci akun lagi.
+func (u *User) RegisterFailedLogin(db *gorm.DB, maxFailures int, lockout time.Duration) error {
+	now := time.Now()
+
+	err := db.Model(&User{}).Where("id = ?", u.ID).Updates(map[string]interface{}{
+		"failed_login_count":   gorm.Expr("failed_login_count + 1"),
+		"last_failed_login_at": now,
+	}).Error
+	if err != nil {
+		return err
+	}
+
+	if err := db.Model(&User{}).Where("id = ?", u.ID).Select("failed_login_count").Scan(&u.FailedLoginCount).Error; err != nil {
+		return err
+	}
+	u.LastFailedLoginAt = &now
+
+	if maxFailures > 0 && u.FailedLoginCount >= maxFailures {
+		lockedUntil := now.Add(lockout)
+		if err := db.Model(&User{}).Where("id = ?", u.ID).Update("locked_until", lockedUntil).Error; err != nil {
+			return err
+		}
+		u.LockedUntil = &lockedUntil
+	}
+
+	return nil
+}
+
+// ResetFailedLogins dipanggil setelah login berhasil atau saat admin membuka kunci akun.
+func (u *User) ResetFailedLogins(db *gorm.DB) error {
+	err := db.Model(&User{}).Where("id = ?", u.ID).Updates(map[string]interface{}{
+		"failed_login_count":   0,
+		"last_failed_login_at": nil,
+		"locked_until":         nil,
+	}).Error
+	if err != nil {
+		return err
+	}
+
+	u.FailedLoginCount = 0
+	u.LastFailedLoginAt = nil
+	u.LockedUntil = nil
+	return nil
+}
+
+// GetLockedUsers mengambil user yang sedang terkunci karena terlalu banyak login gagal.
+func (u *User) GetLockedUsers(db *gorm.DB) ([]User, error) {
+	var users []User
+
+	err := db.Debug().Preload("Role").Where("locked_until > ?", time.Now()).Order("locked_until desc").Find(&users).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return users, nil
+}
+
+// RequiresTwoFactor bernilai true untuk role yang wajib memakai 2FA (admin dan operator).
+func (u *User) RequiresTwoFactor() bool {
+	return u.Role.Name == consts.RoleAdmin || u.Role.Name == consts.RoleOperator
+}
+
+func (u *User) HasTwoFactor() bool {
+	return u.TOTPEnabledAt != nil && u.TOTPSecret != ""
+}
+
+// EnableTwoFactor menyimpan secret TOTP yang sudah dikonfirmasi beserta kode cadangan baru.
+func (u *User) EnableTwoFactor(db *gorm.DB, secret string, step int64, recoveryCodes []string) error {
+	now := time.Now()
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Model(&User{}).Where("id = ?", u.ID).Updates(map[string]interface{}{
+			"totp_secret":     secret,
+			"totp_enabled_at": now,
+			"totp_last_step":  step,
+			"updated_at":      now,
+		}).Error
+		if err != nil {
+			return err
+		}
+
+		recoveryCodeModel := RecoveryCode{}
+		return recoveryCodeModel.ReplaceRecoveryCodes(tx, u.ID, recoveryCodes)
+	})
+	if err != nil {
+		return err
+	}
+
+	u.TOTPSecret = secret
+	u.TOTPEnabledAt = &now
+	u.TOTPLastStep = step
+	return nil
+}
+
+// DisableTwoFactor menghapus secret TOTP dan semua kode cadangan.
+func (u *User) DisableTwoFactor(db *gorm.DB) error {
+	err := db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Model(&User{}).Where("id = ?", u.ID).Updates(map[string]interface{}{
+			"totp_secret":     "",
+			"totp_enabled_at": nil,
+			"totp_last_step":  0,
+			"updated_at":      time.Now(),
+		}).Error
+		if err != nil {
+			return err
+		}
+
+		return tx.Where("user_id = ?", u.ID).Delete(&RecoveryCode{}).Error
+	})
+	if err != nil {
+		return err
+	}
+
+	u.TOTPSecret = ""
+	u.TOTPEnabledAt = nil
+	u.TOTPLastStep = 0
+	return nil
+}
+
+// UseTOTPStep mencatat periode kode TOTP yang baru dipakai. false jika periode tersebut
+// (atau yang lebih baru) sudah dipakai, misalnya oleh request lain secara bersamaan.
+func (u *User) UseTOTPStep(db *gorm.DB, step int64) (bool, error) {
+	result := db.Model(&User{}).
+		Where("id = ? AND totp_last_step < ?", u.ID, step).
+		Update("totp_last_step", step)
+	if result.Error != nil {
+		return false, result.Error
+	}
+
+	if result.RowsAffected == 0 {
+		return false, nil
+	}
+
+	u.TOTPLastStep = step
+	return true, nil
+}
+
+func (u *User) UpdateProfile(db *gorm.DB, firstName string, lastName string) error {
+	err := db.Debug().Model(&User{}).Where("id = ?", u.ID).Updates(map[string]interface{}{
+		"first_name": firstName,
+		"last_name":  lastName,
+		"updated_at": time.Now(),
+	}).Error
+	if err != nil {
+		return err
+	}
+
+	u.FirstName = firstName
+	u.LastName = lastName
+	return nil
+}
+
+// HasPassword bernilai false untuk user yang mendaftar lewat login eksternal (OIDC).
+func (u *User) HasPassword() bool {
+	return u.Password != ""
+}
+
+func (u *User) IsEmailVerified() bool {
+	return u.EmailVerifiedAt != nil
+}
+
+func (u *User) MarkEmailVerified(db *gorm.DB) error {
+	now := time.Now()
+
+	err := db.Debug().Model(&User{}).Where("id = ? AND email_verified_at IS NULL", u.ID).Updates(map[string]interface{}{
+		"email_verified_at": now,
+		"updated_at":        now,
+	}).Error
+	if err != nil {
+		return err
+	}
+
+	if u.EmailVerifiedAt == nil {
+		u.EmailVerifiedAt = &now
+	}
+	return nil
+}
+
+// MigrateUserEmailVerification menambahkan kolom email_verified_at dan menandai terverifikasi
+// user yang terdaftar sebelum verifikasi email diwajibkan, agar mereka tidak terkunci.
+// Hanya berjalan sekali, saat kolom belum ada; user baru setelah itu tetap harus verifikasi.
+// Dipanggil sebelum AutoMigrate.
+func MigrateUserEmailVerification(db *gorm.DB) error {
+	migrator := db.Migrator()
+	if !migrator.HasTable(&User{}) || migrator.HasColumn(&User{}, "EmailVerifiedAt") {
+		return nil
+	}
+
+	return db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Migrator().AddColumn(&User{}, "EmailVerifiedAt"); err != nil {
+			return err
+		}
+
+		return tx.Exec("UPDATE users SET email_verified_at = ?", time.Now()).Error
+	})
+}
+
+// Status akun untuk filter daftar user.
+const (
+	UserStatusActive    = "active"
+	UserStatusSuspended = "suspended"
+)
+
+type UserFilter struct {
+	Query  string
+	RoleID string
+	Status string
+}
+
+// SearchUsers mencari user berdasarkan nama/email, role dan status, beserta total untuk paginasi.
+func (u *User) SearchUsers(db *gorm.DB, filter UserFilter, perPage int, page int) ([]User, int64, error) {
+	var users []User
+	var count int64
+
+	query := db.Debug().Model(&User{})
+
+	if keyword := strings.TrimSpace(filter.Query); keyword != "" {
+		like := "%" + strings.ToLower(keyword) + "%"
+		query = query.Where("LOWER(first_name) LIKE ? OR LOWER(last_name) LIKE ? OR LOWER(email) LIKE ?", like, like, like)
+	}
+
+	if filter.RoleID != "" {
+		query = query.Where("role_id = ?", filter.RoleID)
+	}
+
+	switch filter.Status {
+	case UserStatusActive:
+		query = query.Where("suspended_at IS NULL")
+	case UserStatusSuspended:
+		query = query.Where("suspended_at IS NOT NULL")
+	}
+
+	if err := query.Count(&count).Error; err != nil {
+		return nil, 0, err
+	}
+
+	offset := (page - 1) * perPage
+	err := query.Preload("Role").
+		Order("created_at desc").
+		Limit(perPage).
+		Offset(offset).
+		Find(&users).Error
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return users, count, nil
+}
+
+func (u *User) Suspend(db *gorm.DB) error {
+	now := time.Now()
+
+	err := db.Debug().Model(&User{}).Where("id = ?", u.ID).Updates(map[string]interface{}{
+		"suspended_at": now,
+		"updated_at":   now,
+	}).Error
+	if err != nil {
+		return err
+	}
+
+	u.SuspendedAt = &now
+	return nil
+}
+
+func (u *User) Activate(db *gorm.DB) error {
+	err := db.Debug().Model(&User{}).Where("id = ?", u.ID).Updates(map[string]interface{}{
+		"suspended_at": nil,
+		"updated_at":   time.Now(),
+	}).Error
+	if err != nil {
+		return err
+	}
+
+	u.SuspendedAt = nil
+	return nil
+}
+
+// ForcePasswordReset mewajibkan user mengganti password setelah login berikutnya.
+func (u *User) ForcePasswordReset(db *gorm.DB) error {
+	err := db.Debug().Model(&User{}).Where("id = ?", u.ID).Updates(map[string]interface{}{
+		"must_reset_password": true,
+		"updated_at":          time.Now(),
+	}).Error
+	if err != nil {
+		return err
+	}
+
+	u.MustResetPassword = true
+	return nil
+}
+
+// UpdatePassword menyimpan password baru (sudah di-hash) dan menghapus kewajiban reset password.
+// RememberToken ikut diganti sehingga semua sesi lama tidak berlaku lagi.
+func (u *User) UpdatePassword(db *gorm.DB, hashedPassword string) error {
+	rememberToken := uuid.New().String()
+
+	err := db.Debug().Model(&User{}).Where("id = ?", u.ID).Updates(map[string]interface{}{
+		"password":            hashedPassword,
+		"must_reset_password": false,
+		"remember_token":      rememberToken,
+		"updated_at":          time.Now(),
+	}).Error
+	if err != nil {
+		return err
+	}
+
+	u.Password = hashedPassword
+	u.MustResetPassword = false
+	u.RememberToken = rememberToken
+	return nil
+}
@@ -0,0 +1,75 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// UserIdentity menghubungkan user dengan akun di provider login eksternal (OIDC).
+// Satu user bisa punya beberapa identitas, tetapi satu identitas hanya milik satu user.
+type UserIdentity struct {
+	ID          string `gorm:"size:36;not null;uniqueIndex;primary_key"`
+	UserID      string `gorm:"size:36;index"`
+	User        User
+	Provider    string `gorm:"size:50;not null;uniqueIndex:idx_user_identities_provider_subject"`
+	Subject     string `gorm:"size:255;not null;uniqueIndex:idx_user_identities_provider_subject"`
+	Email       string `gorm:"size:100"`
+	LastLoginAt time.Time
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+}
+
+func (i *UserIdentity) BeforeCreate(db *gorm.DB) error {
+	if i.ID == "" {
+		i.ID = uuid.New().String()
+	}
+
+	return nil
+}
+
+func (i *UserIdentity) FindByProviderSubject(db *gorm.DB, provider string, subject string) (*UserIdentity, error) {
+	var identity UserIdentity
+
+	err := db.Debug().Where("provider = ? AND subject = ?", provider, subject).First(&identity).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return &identity, nil
+}
+
+func (i *UserIdentity) LinkIdentity(db *gorm.DB, userID string, provider string, subject string, email string) (*UserIdentity, error) {
+	identity := &UserIdentity{
+		UserID:      userID,
+		Provider:    provider,
+		Subject:     subject,
+		Email:       email,
+		LastLoginAt: time.Now(),
+	}
+
+	if err := db.Create(identity).Error; err != nil {
+		return nil, err
+	}
+
+	return identity, nil
+}
+
+func (i *UserIdentity) Touch(db *gorm.DB, email string) error {
+	return db.Model(&UserIdentity{}).Where("id = ?", i.ID).Updates(map[string]interface{}{
+		"email":         email,
+		"last_login_at": time.Now(),
+	}).Error
+}
+
+func (i *UserIdentity) GetUserIdentities(db *gorm.DB, userID string) ([]UserIdentity, error) {
+	var identities []UserIdentity
+
+	err := db.Debug().Where("user_id = ?", userID).Order("created_at asc").Find(&identities).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return identities, nil
+}
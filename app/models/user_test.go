@@ -0,0 +1,45 @@
+package models
+
+import "testing"
+
+func TestMigrateUserEmailVerificationOnlyVerifiesExistingUsers(t *testing.T) {
+	db := newTestDB(t, &User{})
+
+	// Database lama: tabel users belum punya kolom email_verified_at
+	if err := db.Migrator().DropColumn(&User{}, "EmailVerifiedAt"); err != nil {
+		t.Fatal(err)
+	}
+	existing := User{ID: "existing", FirstName: "Lama", LastName: "User", Email: "lama@example.com"}
+	if err := db.Omit("EmailVerifiedAt").Create(&existing).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	if err := MigrateUserEmailVerification(db); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.AutoMigrate(&User{}); err != nil {
+		t.Fatal(err)
+	}
+
+	// Pendaftaran setelah migrasi tidak punya token selama email verifikasi belum terkirim
+	registered := User{ID: "new", FirstName: "Baru", LastName: "User", Email: "baru@example.com"}
+	if err := db.Create(&registered).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	// db:migrate berikutnya tidak boleh memverifikasi user baru
+	if err := MigrateUserEmailVerification(db); err != nil {
+		t.Fatal(err)
+	}
+
+	userModel := User{}
+	for id, want := range map[string]bool{"existing": true, "new": false} {
+		user, err := userModel.FindByID(db, id)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if user.IsEmailVerified() != want {
+			t.Errorf("user %s verified = %v, want %v", id, user.IsEmailVerified(), want)
+		}
+	}
+}
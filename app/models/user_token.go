@@ -0,0 +1,130 @@
+package models
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Kegunaan token yang dikirim ke email user.
+const (
+	UserTokenPasswordReset     = "password_reset"
+	UserTokenEmailVerification = "email_verification"
+)
+
+var ErrInvalidUserToken = errors.New("token tidak valid atau sudah kedaluwarsa")
+
+// UserToken adalah token sekali pakai yang dikirim lewat email. Hanya hash SHA-256
+// yang disimpan, sehingga isi tabel tidak bisa dipakai untuk mereset password.
+type UserToken struct {
+	ID        string `gorm:"size:36;not null;uniqueIndex;primary_key"`
+	UserID    string `gorm:"size:36;index"`
+	User      User
+	Purpose   string `gorm:"size:30;index"`
+	TokenHash string `gorm:"size:64;not null;uniqueIndex"`
+	ExpiresAt time.Time
+	UsedAt    *time.Time
+	CreatedAt time.Time
+}
+
+func (t *UserToken) BeforeCreate(db *gorm.DB) error {
+	if t.ID == "" {
+		t.ID = uuid.New().String()
+	}
+
+	return nil
+}
+
+func hashUserToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// CreateUserToken membuat token baru dan mengembalikan nilai aslinya (untuk dikirim via email).
+// Token lama dengan kegunaan yang sama yang belum dipakai langsung dibatalkan.
+func (t *UserToken) CreateUserToken(db *gorm.DB, userID string, purpose string, ttl time.Duration) (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	token := base64.RawURLEncoding.EncodeToString(buf)
+
+	now := time.Now()
+	err := db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Model(&UserToken{}).
+			Where("user_id = ? AND purpose = ? AND used_at IS NULL", userID, purpose).
+			Update("used_at", now).Error
+		if err != nil {
+			return err
+		}
+
+		return tx.Create(&UserToken{
+			UserID:    userID,
+			Purpose:   purpose,
+			TokenHash: hashUserToken(token),
+			ExpiresAt: now.Add(ttl),
+			CreatedAt: now,
+		}).Error
+	})
+	if err != nil {
+		return "", err
+	}
+
+	return token, nil
+}
+
+// FindValidUserToken mencari token yang belum dipakai dan belum kedaluwarsa.
+func (t *UserToken) FindValidUserToken(db *gorm.DB, purpose string, token string) (*UserToken, error) {
+	var userToken UserToken
+
+	err := db.Debug().
+		Where("token_hash = ? AND purpose = ? AND used_at IS NULL AND expires_at > ?", hashUserToken(token), purpose, time.Now()).
+		First(&userToken).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrInvalidUserToken
+		}
+		return nil, err
+	}
+
+	return &userToken, nil
+}
+
+// ConsumeUserToken menandai token sudah dipakai. Update bersyarat membuat token yang sama
+// tidak bisa dipakai dua kali walaupun ada dua request bersamaan.
+func (t *UserToken) ConsumeUserToken(db *gorm.DB, purpose string, token string) (*UserToken, error) {
+	userToken, err := t.FindValidUserToken(db, purpose, token)
+	if err != nil {
+		return nil, err
+	}
+
+	result := db.Model(&UserToken{}).
+		Where("id = ? AND used_at IS NULL", userToken.ID).
+		Update("used_at", time.Now())
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	if result.RowsAffected != 1 {
+		return nil, ErrInvalidUserToken
+	}
+
+	return userToken, nil
+}
+
+// CountRecentUserTokens menghitung token yang dibuat sejak waktu tertentu, untuk membatasi
+// pengiriman ulang email.
+func (t *UserToken) CountRecentUserTokens(db *gorm.DB, userID string, purpose string, since time.Time) (int64, error) {
+	var count int64
+
+	err := db.Model(&UserToken{}).
+		Where("user_id = ? AND purpose = ? AND created_at >= ?", userID, purpose, since).
+		Count(&count).Error
+
+	return count, err
+}
@@ -5,8 +5,15 @@ import (
 	"flag"
 	"log"
 	"os"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/gieart87/gotoko/app/controllers"
+	"github.com/gieart87/gotoko/app/core/ai"
+	"github.com/gieart87/gotoko/app/core/mail"
+	"github.com/gieart87/gotoko/app/core/sso"
+	"github.com/gieart87/gotoko/app/core/storage"
 	"github.com/joho/godotenv"
 )
 
@@ -17,10 +24,55 @@ func getEnv(key, fallback string) string {
 	return fallback
 }
 
+func getEnvInt(key string, fallback int) int {
+	value, err := strconv.Atoi(getEnv(key, ""))
+	if err != nil {
+		return fallback
+	}
+	return value
+}
+
+func getEnvDuration(key string, fallback time.Duration) time.Duration {
+	value, err := time.ParseDuration(getEnv(key, ""))
+	if err != nil {
+		return fallback
+	}
+	return value
+}
+
+// ssoConfigsFromEnv membaca provider OIDC dari OIDC_PROVIDERS (contoh: "google,keycloak")
+// dan OIDC_<NAMA>_ISSUER, _CLIENT_ID, _CLIENT_SECRET, _DISPLAY_NAME, _SCOPES.
+func ssoConfigsFromEnv(appURL string) []sso.Config {
+	var configs []sso.Config
+
+	for _, name := range strings.Split(getEnv("OIDC_PROVIDERS", ""), ",") {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "" {
+			continue
+		}
+
+		prefix := "OIDC_" + strings.ToUpper(name) + "_"
+		configs = append(configs, sso.Config{
+			Name:         name,
+			DisplayName:  getEnv(prefix+"DISPLAY_NAME", name),
+			IssuerURL:    getEnv(prefix+"ISSUER", ""),
+			ClientID:     getEnv(prefix+"CLIENT_ID", ""),
+			ClientSecret: getEnv(prefix+"CLIENT_SECRET", ""),
+			RedirectURL:  strings.TrimRight(appURL, "/") + "/auth/" + name + "/callback",
+			Scopes:       strings.Fields(strings.ReplaceAll(getEnv(prefix+"SCOPES", ""), ",", " ")),
+		})
+	}
+
+	return configs
+}
+
 func Run() {
 	server := controllers.Server{}
 	appConfig := controllers.AppConfig{}
 	dbConfig := controllers.DBConfig{}
+	storageConfig := storage.Config{}
+	aiConfig := ai.Config{}
+	mailConfig := mail.Config{}
 
 	err := godotenv.Load()
 	if err != nil {
@@ -31,6 +83,14 @@ func Run() {
 	appConfig.AppEnv = getEnv("APP_ENV", "development")
 	appConfig.AppPort = getEnv("APP_PORT", "9000")
 	appConfig.AppURL = getEnv("APP_URL", "http://localhost:9000")
+	appConfig.RequireVerifiedLogin = getEnv("REQUIRE_VERIFIED_LOGIN", "false") == "true"
+	appConfig.LoginMaxFailures = getEnvInt("LOGIN_MAX_FAILURES", 10)
+	appConfig.LoginLockout = getEnvDuration("LOGIN_LOCKOUT", 15*time.Minute)
+	appConfig.LoginIPMaxFailures = getEnvInt("LOGIN_IP_MAX_FAILURES", 50)
+	appConfig.LoginIPWindow = getEnvDuration("LOGIN_IP_WINDOW", 15*time.Minute)
+	appConfig.TrustProxyHeaders = getEnv("TRUST_PROXY_HEADERS", "false") == "true"
+	appConfig.TrustedProxyHops = getEnvInt("TRUSTED_PROXY_HOPS", 1)
+	appConfig.LowStockThreshold = getEnvInt("LOW_STOCK_THRESHOLD", 10)
 
 	dbConfig.DBHost = getEnv("DB_HOST", "localhost")
 	dbConfig.DBUser = getEnv("DB_USER", "postgres")
@@ -39,21 +99,59 @@ func Run() {
 	dbConfig.DBPort = getEnv("DB_PORT", "5433")
 	dbConfig.DBDriver = getEnv("DB_DRIVER", "postgres")
 
+	storageConfig.Driver = getEnv("STORAGE_DRIVER", "local")
+	storageConfig.LocalRoot = getEnv("STORAGE_LOCAL_ROOT", "public")
+	storageConfig.LocalBaseURL = getEnv("STORAGE_LOCAL_BASE_URL", "/")
+	storageConfig.S3Endpoint = getEnv("S3_ENDPOINT", "localhost:9100")
+	storageConfig.S3Region = getEnv("S3_REGION", "us-east-1")
+	storageConfig.S3Bucket = getEnv("S3_BUCKET", "goobat")
+	storageConfig.S3AccessKey = getEnv("S3_ACCESS_KEY", "")
+	storageConfig.S3SecretKey = getEnv("S3_SECRET_KEY", "")
+	storageConfig.S3UseSSL = getEnv("S3_USE_SSL", "false") == "true"
+	storageConfig.S3PublicURL = getEnv("S3_PUBLIC_URL", "")
+
+	aiConfig.BaseURL = getEnv("AI_BASE_URL", "http://localhost:8000")
+	aiConfig.Timeout = getEnvDuration("AI_TIMEOUT", 5*time.Second)
+	aiConfig.SearchTimeout = getEnvDuration("AI_SEARCH_TIMEOUT", 2*time.Second)
+	aiConfig.MaxRetries = getEnvInt("AI_MAX_RETRIES", 3)
+	aiConfig.RetryBackoff = getEnvDuration("AI_RETRY_BACKOFF", 200*time.Millisecond)
+	aiConfig.BreakerThreshold = getEnvInt("AI_BREAKER_THRESHOLD", 5)
+	aiConfig.BreakerCooldown = getEnvDuration("AI_BREAKER_COOLDOWN", 30*time.Second)
+	aiConfig.OutboxInterval = getEnvDuration("AI_OUTBOX_INTERVAL", 10*time.Second)
+
+	// Tanpa MAIL_DRIVER, email disimpan sebagai file hanya di development;
+	// di environment lain server gagal start agar link reset/verifikasi tidak hilang diam-diam.
+	mailConfig.Driver = getEnv("MAIL_DRIVER", "")
+	if mailConfig.Driver == "" && appConfig.AppEnv == "development" {
+		mailConfig.Driver = "file"
+	}
+	mailConfig.From = getEnv("MAIL_FROM", "no-reply@goobat.local")
+	mailConfig.FileDir = getEnv("MAIL_FILE_DIR", "storage/mail")
+	mailConfig.SMTPHost = getEnv("SMTP_HOST", "")
+	mailConfig.SMTPPort = getEnv("SMTP_PORT", "587")
+	mailConfig.SMTPUsername = getEnv("SMTP_USERNAME", "")
+	mailConfig.SMTPPassword = getEnv("SMTP_PASSWORD", "")
+
+	ssoConfigs := ssoConfigsFromEnv(appConfig.AppURL)
+
 	flag.Parse()
 	arg := flag.Arg(0)
 
 	if arg != "" {
-		server.InitCommands(appConfig, dbConfig)
+		server.InitCommands(appConfig, dbConfig, storageConfig, aiConfig, mailConfig)
 		return
 	}
 
 	
-	server.Initialize(appConfig, dbConfig)
+	server.Initialize(appConfig, dbConfig, storageConfig, aiConfig, mailConfig, ssoConfigs)
 
-
-	server.Router.PathPrefix("/uploads/").
-		Handler(http.StripPrefix("/uploads/",
-			http.FileServer(http.Dir("public/uploads"))))
+	// File upload hanya dilayani aplikasi jika memakai storage lokal;
+	// untuk S3 file diakses langsung lewat S3_PUBLIC_URL.
+	if storageConfig.Driver == "local" {
+		server.Router.PathPrefix("/uploads/").
+			Handler(http.StripPrefix("/uploads/",
+				http.FileServer(http.Dir(storageConfig.LocalRoot+"/uploads"))))
+	}
 
 	server.Router.PathPrefix("/assets/").
 		Handler(http.StripPrefix("/assets/",
@@ -0,0 +1,178 @@
+package utils
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"image"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"net/http"
+
+	"golang.org/x/image/draw"
+	"golang.org/x/image/webp"
+)
+
+// MaxImageSize adalah batas ukuran file gambar yang boleh di-upload (10 MB)
+const MaxImageSize = 10 << 20
+
+// MaxImagePixels membatasi resolusi gambar (40 megapiksel). File kecil bisa mengaku
+// berukuran sangat besar, dan decode langsung akan mengalokasikan memori sebesar itu.
+const MaxImagePixels = 40_000_000
+
+const jpegQuality = 85
+
+type ImageVariant struct {
+	Name  string
+	Width int
+}
+
+// ImageVariants mengikuti kolom ExtraLarge, Large, Medium dan Small pada ProductImage
+var ImageVariants = []ImageVariant{
+	{Name: "extra_large", Width: 1200},
+	{Name: "large", Width: 800},
+	{Name: "medium", Width: 400},
+	{Name: "small", Width: 150},
+}
+
+var (
+	ErrImageTooLarge   = errors.New("ukuran gambar melebihi 10 MB")
+	ErrImageNotAllowed = errors.New("format gambar tidak didukung (hanya JPEG, PNG, GIF dan WebP)")
+	ErrImageDimensions = errors.New("resolusi gambar melebihi 40 megapiksel")
+)
+
+type ProcessedImage struct {
+	Hash        string
+	Extension   string
+	ContentType string
+	Original    []byte
+	Variants    map[string][]byte
+}
+
+// ProcessImage memvalidasi content type dari isi file (bukan dari nama file),
+// meng-encode ulang gambar, dan membuat seluruh ukuran pada ImageVariants.
+// Hash dihitung dari isi file asli sehingga upload yang sama menghasilkan nama yang sama.
+func ProcessImage(r io.Reader) (*ProcessedImage, error) {
+	raw, err := io.ReadAll(io.LimitReader(r, MaxImageSize+1))
+	if err != nil {
+		return nil, err
+	}
+
+	if len(raw) > MaxImageSize {
+		return nil, ErrImageTooLarge
+	}
+
+	src, err := decodeImage(raw)
+	if err != nil {
+		return nil, err
+	}
+
+	sum := sha256.Sum256(raw)
+	processed := &ProcessedImage{
+		Hash:     hex.EncodeToString(sum[:]),
+		Variants: make(map[string][]byte, len(ImageVariants)),
+	}
+
+	// Gambar dengan transparansi tetap disimpan sebagai PNG
+	encode := encodeJPEG
+	processed.Extension = ".jpg"
+	processed.ContentType = "image/jpeg"
+	if hasAlpha(src) {
+		encode = encodePNG
+		processed.Extension = ".png"
+		processed.ContentType = "image/png"
+	}
+
+	processed.Original, err = encode(src)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, variant := range ImageVariants {
+		data, err := encode(resizeToWidth(src, variant.Width))
+		if err != nil {
+			return nil, err
+		}
+		processed.Variants[variant.Name] = data
+	}
+
+	return processed, nil
+}
+
+type imageCodec struct {
+	decode       func(io.Reader) (image.Image, error)
+	decodeConfig func(io.Reader) (image.Config, error)
+}
+
+var imageCodecs = map[string]imageCodec{
+	"image/jpeg": {jpeg.Decode, jpeg.DecodeConfig},
+	"image/png":  {png.Decode, png.DecodeConfig},
+	"image/gif":  {gif.Decode, gif.DecodeConfig},
+	"image/webp": {webp.Decode, webp.DecodeConfig},
+}
+
+// decodeImage membaca header gambar lebih dulu dan menolak resolusi di atas MaxImagePixels
+// sebelum piksel di-decode.
+func decodeImage(raw []byte) (image.Image, error) {
+	codec, ok := imageCodecs[http.DetectContentType(raw)]
+	if !ok {
+		return nil, ErrImageNotAllowed
+	}
+
+	config, err := codec.decodeConfig(bytes.NewReader(raw))
+	if err != nil {
+		return nil, err
+	}
+	if config.Width <= 0 || config.Height <= 0 || int64(config.Width)*int64(config.Height) > MaxImagePixels {
+		return nil, ErrImageDimensions
+	}
+
+	return codec.decode(bytes.NewReader(raw))
+}
+
+// resizeToWidth mengecilkan gambar dengan menjaga rasio; gambar yang lebih kecil tidak diperbesar.
+func resizeToWidth(src image.Image, width int) image.Image {
+	bounds := src.Bounds()
+	if bounds.Dx() <= width {
+		return src
+	}
+
+	height := bounds.Dy() * width / bounds.Dx()
+	if height < 1 {
+		height = 1
+	}
+
+	dst := image.NewRGBA(image.Rect(0, 0, width, height))
+	draw.CatmullRom.Scale(dst, dst.Bounds(), src, bounds, draw.Over, nil)
+
+	return dst
+}
+
+func hasAlpha(img image.Image) bool {
+	if opaque, ok := img.(interface{ Opaque() bool }); ok {
+		return !opaque.Opaque()
+	}
+
+	return false
+}
+
+func encodeJPEG(img image.Image) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, img, &jpeg.Options{Quality: jpegQuality}); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+func encodePNG(img image.Image) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
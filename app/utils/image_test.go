@@ -0,0 +1,71 @@
+package utils
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"hash/crc32"
+	"image"
+	"image/color"
+	"image/png"
+	"testing"
+)
+
+func encodeTestPNG(t *testing.T, width int, height int) []byte {
+	t.Helper()
+
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	img.Set(0, 0, color.RGBA{R: 255, A: 255})
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		t.Fatal(err)
+	}
+
+	return buf.Bytes()
+}
+
+// withPNGSize mengubah ukuran yang tertulis di chunk IHDR tanpa menambah data piksel.
+func withPNGSize(data []byte, width uint32, height uint32) []byte {
+	out := append([]byte(nil), data...)
+
+	// signature (8) + length (4) + "IHDR" (4), lalu width dan height
+	binary.BigEndian.PutUint32(out[16:20], width)
+	binary.BigEndian.PutUint32(out[20:24], height)
+	binary.BigEndian.PutUint32(out[29:33], crc32.ChecksumIEEE(out[12:29]))
+
+	return out
+}
+
+func TestProcessImageRejectsHugeDimensions(t *testing.T) {
+	data := withPNGSize(encodeTestPNG(t, 1, 1), 60000, 60000)
+
+	if _, err := ProcessImage(bytes.NewReader(data)); !errors.Is(err, ErrImageDimensions) {
+		t.Fatalf("err = %v, want %v", err, ErrImageDimensions)
+	}
+}
+
+func TestProcessImageCreatesVariants(t *testing.T) {
+	processed, err := ProcessImage(bytes.NewReader(encodeTestPNG(t, 500, 250)))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(processed.Variants) != len(ImageVariants) {
+		t.Fatalf("variants = %d, want %d", len(processed.Variants), len(ImageVariants))
+	}
+
+	small, err := png.DecodeConfig(bytes.NewReader(processed.Variants["small"]))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if small.Width != 150 || small.Height != 75 {
+		t.Fatalf("small variant = %dx%d, want 150x75", small.Width, small.Height)
+	}
+}
+
+func TestProcessImageRejectsNonImage(t *testing.T) {
+	if _, err := ProcessImage(bytes.NewReader([]byte("not an image"))); !errors.Is(err, ErrImageNotAllowed) {
+		t.Fatalf("err = %v, want %v", err, ErrImageNotAllowed)
+	}
+}
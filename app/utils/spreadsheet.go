@@ -0,0 +1,172 @@
+package utils
+
+import (
+	"encoding/csv"
+	"errors"
+	"io"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/xuri/excelize/v2"
+)
+
+const (
+	FormatCSV  = "csv"
+	FormatXLSX = "xlsx"
+)
+
+var ErrUnsupportedFormat = errors.New("format file tidak didukung (hanya CSV dan XLSX)")
+
+// formulaPrefixes adalah karakter awal yang membuat Excel/LibreOffice membaca isi CSV sebagai formula.
+const formulaPrefixes = "=+-@\t\r"
+
+// escapeCSVCell menambahkan ' di depan nilai yang bisa dibaca sebagai formula (CSV injection).
+// Angka biasa seperti "-1500.00" dibiarkan apa adanya.
+func escapeCSVCell(value string) string {
+	if value == "" || !strings.ContainsRune(formulaPrefixes, rune(value[0])) {
+		return value
+	}
+	if _, err := strconv.ParseFloat(value, 64); err == nil {
+		return value
+	}
+
+	return "'" + value
+}
+
+// unescapeCSVCell membalik escapeCSVCell agar hasil export bisa di-import kembali.
+func unescapeCSVCell(value string) string {
+	if len(value) > 1 && value[0] == '\'' && strings.ContainsRune(formulaPrefixes, rune(value[1])) {
+		return value[1:]
+	}
+
+	return value
+}
+
+// SpreadsheetFormat menentukan format dari ekstensi nama file.
+func SpreadsheetFormat(filename string) (string, error) {
+	switch strings.ToLower(filepath.Ext(filename)) {
+	case ".csv":
+		return FormatCSV, nil
+	case ".xlsx":
+		return FormatXLSX, nil
+	default:
+		return "", ErrUnsupportedFormat
+	}
+}
+
+// ReadSpreadsheet membaca seluruh baris dari CSV atau sheet pertama XLSX.
+// Baris pertama tetap dikembalikan (biasanya header).
+func ReadSpreadsheet(r io.Reader, format string) ([][]string, error) {
+	switch format {
+	case FormatCSV:
+		reader := csv.NewReader(r)
+		reader.FieldsPerRecord = -1
+		reader.TrimLeadingSpace = true
+		rows, err := reader.ReadAll()
+		if err != nil {
+			return nil, err
+		}
+
+		for _, row := range rows {
+			for i, value := range row {
+				row[i] = unescapeCSVCell(value)
+			}
+		}
+
+		return rows, nil
+	case FormatXLSX:
+		file, err := excelize.OpenReader(r)
+		if err != nil {
+			return nil, err
+		}
+		defer file.Close()
+
+		sheets := file.GetSheetList()
+		if len(sheets) == 0 {
+			return nil, errors.New("file XLSX tidak memiliki sheet")
+		}
+
+		return file.GetRows(sheets[0])
+	default:
+		return nil, ErrUnsupportedFormat
+	}
+}
+
+// WriteSpreadsheet menulis rows sebagai CSV atau XLSX (satu sheet).
+// Di XLSX nilai ditulis sebagai sel teks, bukan formula, sehingga tidak perlu di-escape.
+// Nilai pada numericColumns (indeks mulai 0) yang berupa angka ditulis sebagai sel angka di XLSX
+// agar bisa langsung dijumlahkan; header dan sel non-angka tetap teks.
+func WriteSpreadsheet(w io.Writer, format string, sheetName string, rows [][]string, numericColumns ...int) error {
+	switch format {
+	case FormatCSV:
+		writer := csv.NewWriter(w)
+		for _, row := range rows {
+			escaped := make([]string, len(row))
+			for i, value := range row {
+				escaped[i] = escapeCSVCell(value)
+			}
+			if err := writer.Write(escaped); err != nil {
+				return err
+			}
+		}
+		writer.Flush()
+		return writer.Error()
+	case FormatXLSX:
+		file := excelize.NewFile()
+		defer file.Close()
+
+		if err := file.SetSheetName(file.GetSheetName(0), sheetName); err != nil {
+			return err
+		}
+
+		numeric := map[int]bool{}
+		for _, column := range numericColumns {
+			numeric[column] = true
+		}
+
+		for i, row := range rows {
+			cell, err := excelize.CoordinatesToCellName(1, i+1)
+			if err != nil {
+				return err
+			}
+
+			values := make([]interface{}, len(row))
+			for j, value := range row {
+				values[j] = value
+				if numeric[j] {
+					values[j] = spreadsheetNumber(value)
+				}
+			}
+
+			if err := file.SetSheetRow(sheetName, cell, &values); err != nil {
+				return err
+			}
+		}
+
+		return file.Write(w)
+	default:
+		return ErrUnsupportedFormat
+	}
+}
+
+// spreadsheetNumber mengubah teks angka menjadi int64/float64; selain itu dikembalikan apa adanya.
+func spreadsheetNumber(value string) interface{} {
+	if number, err := strconv.ParseInt(value, 10, 64); err == nil {
+		return number
+	}
+	if number, err := strconv.ParseFloat(value, 64); err == nil {
+		return number
+	}
+
+	return value
+}
+
+// SpreadsheetContentType dipakai untuk header Content-Type saat export.
+func SpreadsheetContentType(format string) string {
+	if format == FormatXLSX {
+		return "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+	}
+
+	return "text/csv"
+}
@@ -0,0 +1,88 @@
+package utils
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+
+	"github.com/xuri/excelize/v2"
+)
+
+func TestWriteSpreadsheetEscapesFormulasInCSV(t *testing.T) {
+	rows := [][]string{
+		{"name", "price"},
+		{"=HYPERLINK(\"http://evil\")", "-1500.00"},
+		{"+62 812", "10"},
+		{"@SUM(A1)", "0"},
+		{"-cmd", "1"},
+		{"\tTab", "2"},
+		{"Paracetamol", "3"},
+	}
+
+	var buf bytes.Buffer
+	if err := WriteSpreadsheet(&buf, FormatCSV, "Sheet", rows); err != nil {
+		t.Fatal(err)
+	}
+
+	want := "name,price\n" +
+		"\"'=HYPERLINK(\"\"http://evil\"\")\",-1500.00\n" +
+		"'+62 812,10\n" +
+		"'@SUM(A1),0\n" +
+		"'-cmd,1\n" +
+		"'\tTab,2\n" +
+		"Paracetamol,3\n"
+	if buf.String() != want {
+		t.Fatalf("csv =\n%q\nwant\n%q", buf.String(), want)
+	}
+
+	got, err := ReadSpreadsheet(bytes.NewReader(buf.Bytes()), FormatCSV)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, rows) {
+		t.Errorf("round trip = %q, want %q", got, rows)
+	}
+}
+
+func TestWriteSpreadsheetWritesNumericColumnsAsNumbers(t *testing.T) {
+	rows := [][]string{
+		{"date", "orders", "grand_total"},
+		{"2026-01-02", "3", "150000.50"},
+		{"TOTAL", "3", ""},
+	}
+
+	var buf bytes.Buffer
+	if err := WriteSpreadsheet(&buf, FormatXLSX, "Sales", rows, 1, 2); err != nil {
+		t.Fatal(err)
+	}
+
+	file, err := excelize.OpenReader(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+
+	tests := []struct {
+		cell string
+		want excelize.CellType
+	}{
+		{"A2", excelize.CellTypeSharedString},
+		{"B1", excelize.CellTypeSharedString},
+		{"B2", excelize.CellTypeUnset},
+		{"C2", excelize.CellTypeUnset},
+		{"A3", excelize.CellTypeSharedString},
+	}
+	for _, tt := range tests {
+		cellType, err := file.GetCellType("Sales", tt.cell)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if cellType != tt.want {
+			t.Errorf("%s type = %v, want %v", tt.cell, cellType, tt.want)
+		}
+	}
+
+	if value, _ := file.GetCellValue("Sales", "C2"); value != "150000.5" {
+		t.Errorf("C2 = %q, want 150000.5", value)
+	}
+}
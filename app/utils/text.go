@@ -0,0 +1,24 @@
+package utils
+
+import "unicode/utf8"
+
+// Truncate memotong s menjadi maksimal max karakter (rune), bukan byte, sehingga karakter
+// multi-byte tidak terpotong di tengah. Kolom varchar(n) menghitung panjang dalam karakter.
+func Truncate(s string, max int) string {
+	if max <= 0 {
+		return ""
+	}
+	if utf8.RuneCountInString(s) <= max {
+		return s
+	}
+
+	count := 0
+	for i := range s {
+		if count == max {
+			return s[:i]
+		}
+		count++
+	}
+
+	return s
+}
@@ -0,0 +1,40 @@
+package utils
+
+import (
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestTruncate(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		max  int
+		want string
+	}{
+		{"short", "obat", 10, "obat"},
+		{"exact", "obat", 4, "obat"},
+		{"ascii", "paracetamol", 4, "para"},
+		{"multi-byte", "héllo", 2, "hé"},
+		{"emoji", "💊💊💊", 2, "💊💊"},
+		{"zero", "obat", 0, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Truncate(tt.in, tt.max); got != tt.want {
+				t.Errorf("Truncate(%q, %d) = %q, want %q", tt.in, tt.max, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTruncateKeepsValidUTF8(t *testing.T) {
+	in := strings.Repeat("a", 254) + "é" + "tail"
+	got := Truncate(in, 255)
+
+	if !utf8.ValidString(got) || utf8.RuneCountInString(got) != 255 || !strings.HasSuffix(got, "é") {
+		t.Errorf("Truncate() = %q", got[250:])
+	}
+}
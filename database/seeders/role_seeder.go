@@ -0,0 +1,67 @@
+package seeders
+
+import (
+	"errors"
+
+	"github.com/gieart87/gotoko/app/consts"
+	"github.com/gieart87/gotoko/app/models"
+	"gorm.io/gorm"
+)
+
+// SeedRoles membuat permission dan role bawaan (admin, operator, customer).
+// Aman dijalankan berulang kali: permission role bawaan disamakan dengan consts.RolePermissions,
+// sedangkan role lain yang dibuat admin tidak diubah.
+func SeedRoles(db *gorm.DB) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		permissionsByName := map[string]models.Permission{}
+		for name, description := range consts.Permissions {
+			var permission models.Permission
+			err := tx.Where("name = ?", name).First(&permission).Error
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				permission = models.Permission{Name: name, Description: description}
+				err = tx.Create(&permission).Error
+			}
+			if err != nil {
+				return err
+			}
+
+			permissionsByName[name] = permission
+		}
+
+		roles := map[string][]string{
+			consts.RoleAdmin: nil, // diisi semua permission
+		}
+		for role, permissions := range consts.RolePermissions {
+			roles[role] = permissions
+		}
+
+		for name, permissionNames := range roles {
+			var role models.Role
+			err := tx.Where("name = ?", name).First(&role).Error
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				role = models.Role{Name: name}
+				err = tx.Create(&role).Error
+			}
+			if err != nil {
+				return err
+			}
+
+			var permissions []models.Permission
+			if name == consts.RoleAdmin {
+				for _, permission := range permissionsByName {
+					permissions = append(permissions, permission)
+				}
+			} else {
+				for _, permissionName := range permissionNames {
+					permissions = append(permissions, permissionsByName[permissionName])
+				}
+			}
+
+			if err := tx.Model(&role).Association("Permissions").Replace(permissions); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
@@ -19,6 +19,11 @@ func RegisterSeeders(db *gorm.DB) []Seeder {
 // database/seeders/seeders.go
 
 func DBSeed(db *gorm.DB) error {
+	// Role dibuat lebih dulu agar instalasi baru langsung punya role admin
+	if err := SeedRoles(db); err != nil {
+		return err
+	}
+
 	for _, seeder := range RegisterSeeders(db) {
 		// Gunakan reflection atau interface untuk panggil Create()
 		// Tapi karena struct berbeda, lebih aman pakai switch atau pastikan semua seeder punya method Create()